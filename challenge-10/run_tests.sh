@@ -32,6 +32,9 @@ TEMP_DIR=$(mktemp -d)
 # Copy the participant's solution and the test file to the temporary directory
 cp "$SUBMISSION_FILE" "solution-template_test.go" "$TEMP_DIR/"
 
+# Copy the other source files of a multi-file submission (excluding test files)
+find "$SUBMISSION_DIR" -maxdepth 1 -name "*.go" ! -name "*_test.go" ! -path "$SUBMISSION_FILE" -exec cp {} "$TEMP_DIR/" \;
+
 echo "Running tests for user '$USERNAME'..."
 
 # Navigate to the temporary directory
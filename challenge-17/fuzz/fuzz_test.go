@@ -0,0 +1,49 @@
+//go:build fuzz
+
+package main
+
+import "testing"
+
+// referenceIsPalindrome is the implementation submissions are compared against
+func referenceIsPalindrome(s string) bool {
+	var cleaned []byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c >= 'A' && c <= 'Z':
+			cleaned = append(cleaned, c+'a'-'A')
+		case c >= 'a' && c <= 'z', c >= '0' && c <= '9':
+			cleaned = append(cleaned, c)
+		}
+	}
+	for i, j := 0, len(cleaned)-1; i < j; i, j = i+1, j-1 {
+		if cleaned[i] != cleaned[j] {
+			return false
+		}
+	}
+	return true
+}
+
+// fuzzIsASCII reports whether s only holds ASCII; other letters are not specified by the challenge
+func fuzzIsASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] > 127 {
+			return false
+		}
+	}
+	return true
+}
+
+func FuzzIsPalindrome(f *testing.F) {
+	for _, seed := range []string{"", "a", "racecar", "hello", "A man, a plan, a canal: Panama", "12321", "!@#$%^&*()"} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, s string) {
+		if !fuzzIsASCII(s) {
+			t.Skip()
+		}
+		if got, want := IsPalindrome(s), referenceIsPalindrome(s); got != want {
+			t.Errorf("IsPalindrome(%q) = %v, want %v", s, got, want)
+		}
+	})
+}
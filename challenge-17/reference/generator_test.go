@@ -0,0 +1,20 @@
+package main
+
+import "math/rand"
+
+// generateDifferentialInput returns mixed-case letters, digits, spaces and
+// punctuation, mirrored half of the time so both answers are common
+func generateDifferentialInput(function string, r *rand.Rand, size int) []any {
+	const characters = "aAbBcC01 ,.!:'"
+	half := make([]byte, r.Intn(size+1))
+	for i := range half {
+		half[i] = characters[r.Intn(len(characters))]
+	}
+	s := string(half)
+	if r.Intn(2) == 0 {
+		for i := len(half) - 1; i >= 0; i-- {
+			s += string(half[i])
+		}
+	}
+	return []any{s}
+}
@@ -0,0 +1,28 @@
+package main
+
+import "fmt"
+
+func main() {
+	fmt.Println(IsPalindrome("A man, a plan, a canal: Panama"))
+}
+
+// IsPalindrome reports whether the letters and digits of s read the same
+// forward and backward, ignoring case
+func IsPalindrome(s string) bool {
+	var cleaned []byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c >= 'A' && c <= 'Z':
+			cleaned = append(cleaned, c+'a'-'A')
+		case c >= 'a' && c <= 'z', c >= '0' && c <= '9':
+			cleaned = append(cleaned, c)
+		}
+	}
+	for i, j := 0, len(cleaned)-1; i < j; i, j = i+1, j-1 {
+		if cleaned[i] != cleaned[j] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,38 @@
+//go:build fuzz
+
+package main
+
+import "testing"
+
+// referenceReverseString is the implementation submissions are compared against
+func referenceReverseString(s string) string {
+	reversed := []byte(s)
+	for i, j := 0, len(reversed)-1; i < j; i, j = i+1, j-1 {
+		reversed[i], reversed[j] = reversed[j], reversed[i]
+	}
+	return string(reversed)
+}
+
+// fuzzIsASCII reports whether s only holds ASCII, the input the challenge specifies
+func fuzzIsASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] > 127 {
+			return false
+		}
+	}
+	return true
+}
+
+func FuzzReverseString(f *testing.F) {
+	for _, seed := range []string{"", "a", "hello", "Go is fun!", "12345!@#$%", "GoLang"} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, s string) {
+		if !fuzzIsASCII(s) {
+			t.Skip()
+		}
+		if got, want := ReverseString(s), referenceReverseString(s); got != want {
+			t.Errorf("ReverseString(%q) = %q, want %q", s, got, want)
+		}
+	})
+}
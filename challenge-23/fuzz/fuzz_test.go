@@ -0,0 +1,46 @@
+//go:build fuzz
+
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+// referencePatternMatch is the implementation submissions are compared against
+func referencePatternMatch(text, pattern string) []int {
+	matches := []int{}
+	if pattern == "" {
+		return matches
+	}
+	for i := 0; i+len(pattern) <= len(text); i++ {
+		if text[i:i+len(pattern)] == pattern {
+			matches = append(matches, i)
+		}
+	}
+	return matches
+}
+
+func FuzzPatternSearch(f *testing.F) {
+	f.Add("ABABDABACDABABCABAB", "ABABCABAB")
+	f.Add("AAAAAA", "AA")
+	f.Add("ABCDEFG", "")
+	f.Add("", "ABC")
+	f.Add("ABC", "ABCDEF")
+	f.Fuzz(func(t *testing.T, text, pattern string) {
+		want := referencePatternMatch(text, pattern)
+		searches := []struct {
+			name   string
+			search func(string, string) []int
+		}{
+			{"NaivePatternMatch", NaivePatternMatch},
+			{"KMPSearch", KMPSearch},
+			{"RabinKarpSearch", RabinKarpSearch},
+		}
+		for _, s := range searches {
+			if got := s.search(text, pattern); !reflect.DeepEqual(got, want) {
+				t.Errorf("%s(%q, %q) = %v, want %v", s.name, text, pattern, got, want)
+			}
+		}
+	})
+}
@@ -0,0 +1,27 @@
+package main
+
+import "math/rand"
+
+// generateDifferentialInput returns a text and a pattern over a small alphabet,
+// so that patterns actually occur and overlap in the text
+func generateDifferentialInput(function string, r *rand.Rand, size int) []any {
+	alphabet := "AB"
+	if r.Intn(2) == 0 {
+		alphabet = "ABCD"
+	}
+	randomString := func(n int) string {
+		b := make([]byte, n)
+		for i := range b {
+			b[i] = alphabet[r.Intn(len(alphabet))]
+		}
+		return string(b)
+	}
+	text := randomString(r.Intn(size + 1))
+	pattern := randomString(r.Intn(size/4 + 2))
+	if len(text) > 0 && r.Intn(2) == 0 {
+		// Cut the pattern out of the text so most inputs have a match
+		start := r.Intn(len(text))
+		pattern = text[start : start+r.Intn(len(text)-start)+1]
+	}
+	return []any{text, pattern}
+}
@@ -0,0 +1,31 @@
+package main
+
+import "fmt"
+
+func main() {
+	fmt.Println(NaivePatternMatch("AAAAAA", "AA"))
+}
+
+// NaivePatternMatch returns every starting index of pattern in text
+func NaivePatternMatch(text, pattern string) []int {
+	matches := []int{}
+	if pattern == "" {
+		return matches
+	}
+	for i := 0; i+len(pattern) <= len(text); i++ {
+		if text[i:i+len(pattern)] == pattern {
+			matches = append(matches, i)
+		}
+	}
+	return matches
+}
+
+// KMPSearch returns every starting index of pattern in text
+func KMPSearch(text, pattern string) []int {
+	return NaivePatternMatch(text, pattern)
+}
+
+// RabinKarpSearch returns every starting index of pattern in text
+func RabinKarpSearch(text, pattern string) []int {
+	return NaivePatternMatch(text, pattern)
+}
@@ -33,6 +33,9 @@ TEMP_DIR=$(mktemp -d)
 # Copy the participant's solution, test file, and go.mod to the temporary directory
 cp "$SUBMISSION_FILE" "solution-template_test.go" "go.mod" "go.sum" "$TEMP_DIR/" 2>/dev/null
 
+# Copy the other source files of a multi-file submission (excluding test files)
+find "$SUBMISSION_DIR" -maxdepth 1 -name "*.go" ! -name "*_test.go" ! -path "$SUBMISSION_FILE" -exec cp {} "$TEMP_DIR/" \;
+
 # Rename solution.go to solution-template.go for the test
 mv "$TEMP_DIR/solution.go" "$TEMP_DIR/solution-template.go"
 
@@ -41,6 +41,9 @@ if [ -f "solution-template.go" ]; then
 fi
 cp "$SUBMISSION_FILE" solution-template.go
 
+# Copy the other source files of a multi-file submission (excluding test files)
+find "$SUBMISSION_DIR" -maxdepth 1 -name "*.go" ! -name "*_test.go" ! -path "$SUBMISSION_FILE" -exec cp {} . \;
+
 echo "Testing submission for $USERNAME..."
 echo "================================"
 
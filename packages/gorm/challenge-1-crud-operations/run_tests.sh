@@ -33,6 +33,9 @@ TEMP_DIR=$(mktemp -d)
 # Copy the participant's solution, test file, and go.mod to the temporary directory
 cp "$SUBMISSION_FILE" "solution-template_test.go" "$TEMP_DIR/"
 
+# Copy the other source files of a multi-file submission (excluding test files)
+find "$SUBMISSION_DIR" -maxdepth 1 -name "*.go" ! -name "*_test.go" ! -path "$SUBMISSION_FILE" -exec cp {} "$TEMP_DIR/" \;
+
 # Copy go.mod and go.sum if they exist
 if [ -f "go.mod" ]; then
     cp "go.mod" "$TEMP_DIR/"
@@ -38,6 +38,9 @@ fi
 # Replace the template file with the submission
 cp "$SUBMISSION_FILE" "$TEMP_DIR/solution-template.go"
 
+# Copy the other source files of a multi-file submission (excluding test files)
+find "$SUBMISSION_DIR" -maxdepth 1 -name "*.go" ! -name "*_test.go" ! -path "$SUBMISSION_FILE" -exec cp {} "$TEMP_DIR/" \;
+
 # Navigate to the temporary directory
 cd "$TEMP_DIR"
 
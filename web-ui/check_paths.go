@@ -0,0 +1,65 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"web-ui/internal/services"
+)
+
+// runCheckSubmissionPaths implements "web-ui check-submission-paths", the PR
+// security check. It reads the files a pull request changes, one per line on
+// stdin or as arguments, and checks those in submission directories with the
+// rules the web UI saves by. It prints every violation and returns the exit
+// status: 0 when all files pass, 1 on violations and 2 on usage errors.
+func runCheckSubmissionPaths(args []string) int {
+	flags := flag.NewFlagSet("check-submission-paths", flag.ContinueOnError)
+	author := flags.String("author", "", "GitHub username of the pull request author")
+	root := flags.String("root", "..", "repository root the changed files are relative to")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if err := services.ValidateUsername(*author); err != nil {
+		fmt.Fprintf(os.Stderr, "check-submission-paths: -author: %v\n", err)
+		return 2
+	}
+
+	files := flags.Args()
+	if len(files) == 0 {
+		scanner := bufio.NewScanner(os.Stdin)
+		for scanner.Scan() {
+			files = append(files, scanner.Text())
+		}
+		if err := scanner.Err(); err != nil {
+			fmt.Fprintf(os.Stderr, "check-submission-paths: could not read stdin: %v\n", err)
+			return 2
+		}
+	}
+
+	paths := services.NewSubmissionPaths(services.NewContentRoot(*root))
+	checked, violations := 0, 0
+	for _, file := range files {
+		file = strings.TrimSpace(file)
+		if file == "" {
+			continue
+		}
+		isSubmission, err := paths.CheckChangedFile(*author, file)
+		if err != nil {
+			fmt.Println(err)
+			violations++
+		}
+		if isSubmission {
+			checked++
+		}
+	}
+
+	if violations > 0 {
+		fmt.Printf("%d of %d submission files break the submission rules\n", violations, checked)
+		return 1
+	}
+	fmt.Printf("%d submission files pass the submission rules\n", checked)
+	return 0
+}
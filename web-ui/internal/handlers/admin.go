@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"os"
+	"strings"
+
+	"web-ui/internal/services"
+)
+
+// AdminHandler serves the maintenance endpoints. They are turned off unless
+// ADMIN_TOKEN is set, and requests must send it as a bearer token.
+type AdminHandler struct {
+	reloader *services.ContentReloader
+}
+
+// NewAdminHandler creates a new admin handler
+func NewAdminHandler(reloader *services.ContentReloader) *AdminHandler {
+	return &AdminHandler{reloader: reloader}
+}
+
+// Reload reloads every challenge, scoreboard and package from the content root
+func (h *AdminHandler) Reload(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !h.authorize(w, r) {
+		return
+	}
+
+	summary, err := h.reloader.ReloadAll()
+	if err != nil {
+		http.Error(w, "Reload failed: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(struct {
+		Success bool `json:"success"`
+		services.ReloadSummary
+	}{true, summary})
+}
+
+// authorize checks the request's bearer token against ADMIN_TOKEN. It writes
+// the error response and returns false when the request may not proceed.
+func (h *AdminHandler) authorize(w http.ResponseWriter, r *http.Request) bool {
+	token := os.Getenv("ADMIN_TOKEN")
+	if token == "" {
+		http.Error(w, "Admin endpoints are disabled; set ADMIN_TOKEN to enable them", http.StatusNotFound)
+		return false
+	}
+	given, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="admin"`)
+		http.Error(w, "Invalid admin token", http.StatusUnauthorized)
+		return false
+	}
+	return true
+}
@@ -1,12 +1,14 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
+	"net"
 	"net/http"
-	"os"
-	"path/filepath"
+	"path"
 	"regexp"
 	"sort"
 	"strconv"
@@ -146,13 +148,17 @@ func (h *APIHandler) scrapeSponsorsFromGitHub() map[string]bool {
 
 // APIHandler handles all API endpoints
 type APIHandler struct {
-	challengeService  *services.ChallengeService
-	scoreboardService *services.ScoreboardService
-	userService       *services.UserService
-	executionService  *services.ExecutionService
-	packageService    *services.PackageService
-	aiService         *services.AIService
-	submissions       []models.Submission
+	challengeService   *services.ChallengeService
+	scoreboardService  *services.ScoreboardService
+	userService        *services.UserService
+	executionService   *services.ExecutionService
+	packageService     *services.PackageService
+	aiService          *services.AIService
+	performanceService *services.PerformanceService
+	submissionStore    services.SubmissionStore
+	authService        *services.AuthService
+	contentRoot        *services.ContentRoot
+	trustedProxies     []*net.IPNet
 }
 
 // NewAPIHandler creates a new API handler
@@ -163,15 +169,23 @@ func NewAPIHandler(
 	executionService *services.ExecutionService,
 	packageService *services.PackageService,
 	aiService *services.AIService,
+	performanceService *services.PerformanceService,
+	submissionStore services.SubmissionStore,
+	authService *services.AuthService,
+	contentRoot *services.ContentRoot,
 ) *APIHandler {
 	return &APIHandler{
-		challengeService:  challengeService,
-		scoreboardService: scoreboardService,
-		userService:       userService,
-		executionService:  executionService,
-		packageService:    packageService,
-		aiService:         aiService,
-		submissions:       make([]models.Submission, 0),
+		challengeService:   challengeService,
+		scoreboardService:  scoreboardService,
+		userService:        userService,
+		executionService:   executionService,
+		packageService:     packageService,
+		aiService:          aiService,
+		performanceService: performanceService,
+		submissionStore:    submissionStore,
+		authService:        authService,
+		contentRoot:        contentRoot,
+		trustedProxies:     services.TrustedProxies(),
 	}
 }
 
@@ -242,6 +256,14 @@ func (h *APIHandler) createSubmission(w http.ResponseWriter, r *http.Request) {
 
 	// Set submission timestamp
 	submission.SubmittedAt = time.Now()
+	submission.Kind = models.SubmissionKindSubmit
+
+	// Scoreboard entries belong to the signed-in user, not to a name in the body
+	username, ok := h.requireUsername(w, r, submission.Username)
+	if !ok {
+		return
+	}
+	submission.Username = username
 
 	// Validate challenge exists
 	challenge, exists := h.challengeService.GetChallenge(submission.ChallengeID)
@@ -250,30 +272,50 @@ func (h *APIHandler) createSubmission(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	files, ok := submissionFiles(w, submission.Code, submission.Files, challenge.Template)
+	if !ok {
+		return
+	}
+	submission.Code = files[services.MainSolutionFile]
+	if len(files) == 1 {
+		submission.Files = nil
+	}
+
+	// Challenges with benchmarks are graded on performance as well
+	if challenge.Benchmark != nil {
+		submission.Options.Bench = true
+	}
+	if challenge.Fuzz != nil {
+		submission.Options.Fuzz = true
+	}
+	if challenge.Differential != nil {
+		submission.Options.Differential = true
+	}
+
 	// Run the code
-	result := h.executionService.RunCode(submission.Code, challenge)
-	submission.Passed = result.Passed
-	submission.TestOutput = result.Output
-	submission.ExecutionMs = result.ExecutionMs
+	result, ok := h.runQueued(w, r, submission.Username, func(ctx context.Context) services.ExecutionResult {
+		return h.executionService.SubmitCode(ctx, files, challenge, submission.Options)
+	})
+	if !ok {
+		return
+	}
+	applyResult(&submission, result)
 
 	// Store submission
-	h.submissions = append(h.submissions, submission)
+	h.recordSubmission(&submission)
 
 	// Add to scoreboard if passed
 	if submission.Passed {
 		h.scoreboardService.AddSubmission(submission)
+		if err := h.performanceService.RecordResult(submission); err != nil {
+			fmt.Printf("Warning: could not store benchmark result: %v\n", err)
+		}
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(submission)
 }
 
-// getSubmissions returns all submissions
-func (h *APIHandler) getSubmissions(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(h.submissions)
-}
-
 // GetScoreboard returns the scoreboard for a challenge
 func (h *APIHandler) GetScoreboard(w http.ResponseWriter, r *http.Request) {
 	if r.Method != "GET" {
@@ -298,6 +340,39 @@ func (h *APIHandler) GetScoreboard(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(scoreboard)
 }
 
+// GetPerformanceLeaderboard returns the fastest solutions for a challenge
+func (h *APIHandler) GetPerformanceLeaderboard(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Extract challenge ID from URL
+	path := strings.TrimPrefix(r.URL.Path, "/api/performance/")
+	id, err := strconv.Atoi(path)
+	if err != nil {
+		http.Error(w, "Invalid challenge ID", http.StatusBadRequest)
+		return
+	}
+
+	challenge, exists := h.challengeService.GetChallenge(id)
+	if !exists {
+		http.Error(w, "Challenge not found", http.StatusNotFound)
+		return
+	}
+
+	response := struct {
+		Benchmark   *models.BenchmarkConfig   `json:"benchmark"`
+		Leaderboard []models.PerformanceEntry `json:"leaderboard"`
+	}{
+		Benchmark:   challenge.Benchmark,
+		Leaderboard: h.performanceService.GetLeaderboard(id),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
 // RunCode executes submitted code
 func (h *APIHandler) RunCode(w http.ResponseWriter, r *http.Request) {
 	if r.Method != "POST" {
@@ -306,8 +381,10 @@ func (h *APIHandler) RunCode(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var request struct {
-		ChallengeID int    `json:"challengeId"`
-		Code        string `json:"code"`
+		ChallengeID int               `json:"challengeId"`
+		Code        string            `json:"code"`
+		Files       map[string]string `json:"files"`
+		Options     models.RunOptions `json:"options"`
 	}
 
 	err := json.NewDecoder(r.Body).Decode(&request)
@@ -322,7 +399,306 @@ func (h *APIHandler) RunCode(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	result := h.executionService.RunCode(request.Code, challenge)
+	files, ok := submissionFiles(w, request.Code, request.Files, challenge.Template)
+	if !ok {
+		return
+	}
+
+	result, ok := h.runQueued(w, r, "", func(ctx context.Context) services.ExecutionResult {
+		return h.executionService.RunCode(ctx, files, challenge, request.Options)
+	})
+	if !ok {
+		return
+	}
+	h.recordResult(r, models.SubmissionKindRun, "", models.Submission{ChallengeID: challenge.ID}, files, result)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// RunCodeStream executes submitted code and streams progress as Server-Sent Events.
+// Each event is named after its type and carries a services.RunEvent as JSON;
+// the last one is a "result" with the same payload /api/run returns.
+func (h *APIHandler) RunCodeStream(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request struct {
+		ChallengeID int               `json:"challengeId"`
+		Code        string            `json:"code"`
+		Files       map[string]string `json:"files"`
+		Username    string            `json:"username"`
+		Options     models.RunOptions `json:"options"`
+	}
+
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		http.Error(w, "Invalid request data", http.StatusBadRequest)
+		return
+	}
+
+	challenge, exists := h.challengeService.GetChallenge(request.ChallengeID)
+	if !exists {
+		http.Error(w, "Challenge not found", http.StatusNotFound)
+		return
+	}
+
+	files, ok := submissionFiles(w, request.Code, request.Files, challenge.Template)
+	if !ok {
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no") // Disable proxy buffering
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	// Events arrive from the sandbox output and queue goroutines. Those may
+	// still fire after the handler returned, when w must no longer be used.
+	var writeMutex sync.Mutex
+	closed := false
+	defer func() {
+		writeMutex.Lock()
+		closed = true
+		writeMutex.Unlock()
+	}()
+	send := func(event services.RunEvent) {
+		data, err := json.Marshal(event)
+		if err != nil {
+			return
+		}
+		writeMutex.Lock()
+		defer writeMutex.Unlock()
+		if closed || r.Context().Err() != nil {
+			return
+		}
+		fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, data)
+		flusher.Flush()
+	}
+
+	onPosition := func(position int) {
+		send(services.RunEvent{Type: services.RunEventQueued, Position: position})
+	}
+	result, err := h.executionService.Queue().Run(r.Context(), h.executionOwner(r, request.Username), onPosition, func(ctx context.Context) services.ExecutionResult {
+		return h.executionService.RunCodeStream(ctx, files, challenge, request.Options, send)
+	})
+	if err != nil {
+		send(services.RunEvent{Type: services.RunEventError, Message: err.Error()})
+		return
+	}
+	h.recordResult(r, models.SubmissionKindRun, request.Username, models.Submission{ChallengeID: challenge.ID}, files, result)
+	send(services.RunEvent{Type: services.RunEventResult, Result: &result})
+}
+
+// submissionFiles collects the files of a run, submit or save request and checks
+// them against the submission allow-list. It writes a 400 response when they fail.
+func submissionFiles(w http.ResponseWriter, code string, files map[string]string, template string) (map[string]string, bool) {
+	files = services.SubmissionFiles(code, files)
+	if err := services.ValidateSubmissionFiles(files, template); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return nil, false
+	}
+	return files, true
+}
+
+// runQueued runs fn through the execution queue for the current user, who may
+// be claimed in dev mode. It writes the error response and returns false when
+// the run was rejected or the client went away.
+func (h *APIHandler) runQueued(w http.ResponseWriter, r *http.Request, claimed string, fn func(ctx context.Context) services.ExecutionResult) (services.ExecutionResult, bool) {
+	result, err := h.executionService.Queue().Run(r.Context(), h.executionOwner(r, claimed), nil, fn)
+	switch {
+	case err == nil:
+		return result, true
+	case errors.Is(err, services.ErrTooManyRuns):
+		http.Error(w, err.Error(), http.StatusTooManyRequests)
+	case errors.Is(err, services.ErrQueueFull):
+		w.Header().Set("Retry-After", "10")
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+	default:
+		// The client disconnected while waiting; nobody is left to answer
+	}
+	return result, false
+}
+
+// executionOwner identifies who a run belongs to for the per-user queue cap:
+// the current user, or else the client address
+func (h *APIHandler) executionOwner(r *http.Request, claimed string) string {
+	if username := h.currentUsername(r, claimed); username != "" {
+		return "user:" + username
+	}
+	return "addr:" + services.ClientAddress(r, h.trustedProxies)
+}
+
+// GetQueueStatus returns the execution queue load and the caller's position in it
+func (h *APIHandler) GetQueueStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	queue := h.executionService.Queue()
+	response := struct {
+		services.QueueStatus
+		Position int `json:"position"`
+	}{
+		QueueStatus: queue.Status(),
+		Position:    queue.Position(h.executionOwner(r, r.URL.Query().Get("username"))),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// GetToolchains lists the Go toolchains runs can select with options.toolchain
+func (h *APIHandler) GetToolchains(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.executionService.Toolchains().List())
+}
+
+// LanguageServer proxies the Language Server Protocol between the editor and a
+// gopls process over a WebSocket. The workspace holds the template of the
+// challenge in ?challengeId=N, or ?package=P&challenge=C, and is rooted at
+// services.LanguageServerRoot. Each WebSocket message is one JSON-RPC message;
+// gopls is stopped and its workspace removed when the socket closes or the
+// editor has been idle too long. Sessions count against the same owner as runs.
+func (h *APIHandler) LanguageServer(w http.ResponseWriter, r *http.Request) {
+	languageServers := h.executionService.LanguageServers()
+	if !languageServers.Available() {
+		http.Error(w, "gopls is not installed on this server", http.StatusServiceUnavailable)
+		return
+	}
+	owner := h.executionOwner(r, "")
+
+	var start func(ctx context.Context) (*services.LanguageServerSession, error)
+	query := r.URL.Query()
+	if packageName := query.Get("package"); packageName != "" {
+		challenge, err := h.packageService.GetPackageChallenge(packageName, query.Get("challenge"))
+		if err != nil {
+			http.Error(w, "Challenge not found", http.StatusNotFound)
+			return
+		}
+		start = func(ctx context.Context) (*services.LanguageServerSession, error) {
+			return languageServers.StartForPackageChallenge(ctx, owner, challenge)
+		}
+	} else {
+		id, err := strconv.Atoi(query.Get("challengeId"))
+		challenge, exists := h.challengeService.GetChallenge(id)
+		if err != nil || !exists {
+			http.Error(w, "Challenge not found", http.StatusNotFound)
+			return
+		}
+		start = func(ctx context.Context) (*services.LanguageServerSession, error) {
+			return languageServers.StartForChallenge(ctx, owner, challenge)
+		}
+	}
+
+	conn, err := upgradeWebSocket(w, r)
+	if err != nil {
+		return
+	}
+
+	// The request context ends with the handler, the session with the socket
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	session, err := start(ctx)
+	if errors.Is(err, services.ErrTooManyEditorSessions) || errors.Is(err, services.ErrTooManyLanguageServers) {
+		conn.Close(closeTryAgainLater, err.Error())
+		return
+	} else if err != nil {
+		conn.Close(closeInternalError, err.Error())
+		return
+	}
+	defer session.Close()
+
+	// gopls to editor
+	go func() {
+		defer cancel()
+		for {
+			message, err := session.ReadMessage()
+			if errors.Is(err, services.ErrLanguageServerIdle) {
+				conn.Close(closeNormal, err.Error())
+				return
+			} else if err != nil {
+				if ctx.Err() == nil {
+					conn.Close(closeInternalError, err.Error())
+				}
+				return
+			}
+			if err := conn.WriteMessage(message); err != nil {
+				return
+			}
+		}
+	}()
+
+	// Editor to gopls; a read error means the browser went away
+	go func() {
+		defer cancel()
+		for {
+			message, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			if err := session.WriteMessage(message); err != nil {
+				return
+			}
+		}
+	}()
+
+	<-ctx.Done()
+	conn.Close(closeNormal, "")
+}
+
+// FormatCode formats an editor buffer like gofmt and goimports. The formatted
+// source is returned, or the syntax errors with their positions.
+func (h *APIHandler) FormatCode(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request struct {
+		Code  string            `json:"code"`
+		Files map[string]string `json:"files"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request data", http.StatusBadRequest)
+		return
+	}
+
+	// Any editor can format, so there is no challenge template to check the package against
+	files, ok := submissionFiles(w, request.Code, request.Files, "")
+	if !ok {
+		return
+	}
+
+	formatted, syntaxErrors := services.FormatFiles(files)
+	result := models.FormatResult{
+		Code:   formatted[services.MainSolutionFile],
+		Errors: syntaxErrors,
+	}
+	for name, content := range formatted {
+		if content != files[name] {
+			result.Changed = true
+		}
+	}
+	if len(request.Files) > 0 {
+		result.Files = formatted
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(result)
@@ -342,22 +718,32 @@ func (h *APIHandler) SaveSubmissionToFilesystem(w http.ResponseWriter, r *http.R
 		return
 	}
 
-	if request.Username == "" {
-		http.Error(w, "Username is required", http.StatusBadRequest)
+	// Files are saved under the signed-in user's name
+	username, ok := h.requireUsername(w, r, request.Username)
+	if !ok {
 		return
 	}
-
-	// Set username cookie
-	h.setUsernameCookie(w, request.Username)
+	request.Username = username
+	h.authService.RememberDevUsername(w, r, username)
 
 	// Validate challenge exists
-	_, exists := h.challengeService.GetChallenge(request.ChallengeID)
+	challenge, exists := h.challengeService.GetChallenge(request.ChallengeID)
 	if !exists {
 		http.Error(w, "Challenge not found", http.StatusNotFound)
 		return
 	}
 
-	response := h.executionService.SaveSubmissionToFilesystem(request)
+	files, ok := submissionFiles(w, request.Code, request.Files, challenge.Template)
+	if !ok {
+		return
+	}
+	request.Files = files
+
+	response, err := h.executionService.SaveSubmissionToFilesystem(request)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
 	// Clear user attempts cache
 	h.userService.RefreshUserAttempts(request.Username, h.challengeService.GetChallenges())
@@ -383,12 +769,12 @@ func (h *APIHandler) RefreshUserAttempts(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	if request.Username == "" {
-		http.Error(w, "Username is required", http.StatusBadRequest)
+	username, ok := h.requireUsername(w, r, request.Username)
+	if !ok {
 		return
 	}
 
-	attempts := h.userService.RefreshUserAttempts(request.Username, h.challengeService.GetChallenges())
+	attempts := h.userService.RefreshUserAttempts(username, h.challengeService.GetChallenges())
 
 	response := struct {
 		Username     string       `json:"username"`
@@ -396,7 +782,7 @@ func (h *APIHandler) RefreshUserAttempts(w http.ResponseWriter, r *http.Request)
 		Scores       map[int]int  `json:"scores"`
 		Success      bool         `json:"success"`
 	}{
-		Username:     request.Username,
+		Username:     username,
 		AttemptedIDs: attempts.AttemptedIDs,
 		Scores:       attempts.Scores,
 		Success:      true,
@@ -431,20 +817,6 @@ func (h *APIHandler) GetGitUsername(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(response)
 }
 
-// setUsernameCookie sets the username cookie
-func (h *APIHandler) setUsernameCookie(w http.ResponseWriter, username string) {
-	// Cookie expires in 30 days
-	expiration := time.Now().Add(30 * 24 * time.Hour)
-	cookie := http.Cookie{
-		Name:     "username",
-		Value:    username,
-		Expires:  expiration,
-		Path:     "/",
-		HttpOnly: false, // Allow JavaScript to access it
-	}
-	http.SetCookie(w, &cookie)
-}
-
 // GetMainScoreboardRank returns the user's rank in the main scoreboard
 func (h *APIHandler) GetMainScoreboardRank(w http.ResponseWriter, r *http.Request) {
 	if r.Method != "GET" {
@@ -484,15 +856,9 @@ func (h *APIHandler) calculateMainScoreboardRank(username string) int {
 	// Process all challenge scoreboards to count actual completions
 	for challengeID := range challenges {
 		// Read scoreboard file directly to check test results
-		scoreboardPath := fmt.Sprintf("../challenge-%d/SCOREBOARD.md", challengeID)
-		content, err := ioutil.ReadFile(scoreboardPath)
+		content, err := h.contentRoot.ReadFile(fmt.Sprintf("challenge-%d", challengeID), "SCOREBOARD.md")
 		if err != nil {
-			// Try alternative path
-			scoreboardPath = fmt.Sprintf("challenge-%d/SCOREBOARD.md", challengeID)
-			content, err = ioutil.ReadFile(scoreboardPath)
-			if err != nil {
-				continue
-			}
+			continue
 		}
 
 		// Parse scoreboard to find users who passed ALL tests
@@ -634,25 +1000,19 @@ func (h *APIHandler) createPackageLeaderboard(packageName string, challenges []*
 	sponsors := h.LoadSponsors()
 
 	for _, challenge := range challenges {
-		submissionsDir := filepath.Join("..", "packages", packageName, challenge.ID, "submissions")
-		if _, err := os.Stat(submissionsDir); os.IsNotExist(err) {
-			continue
-		}
-		entries, err := ioutil.ReadDir(submissionsDir)
+		submissionsDir := path.Join("packages", packageName, challenge.ID, "submissions")
+		entries, err := h.contentRoot.ReadDir(submissionsDir)
 		if err != nil {
 			continue
 		}
 		for _, entry := range entries {
 			if entry.IsDir() {
 				username := entry.Name()
-				userDir := filepath.Join(submissionsDir, username)
-				solutionPath := filepath.Join(userDir, "solution.go")
-				altSolutionPath := filepath.Join(userDir, "solution-template.go")
 
 				var modTime time.Time
-				if stat, err := os.Stat(solutionPath); err == nil {
+				if stat, err := h.contentRoot.Stat(submissionsDir, username, "solution.go"); err == nil {
 					modTime = stat.ModTime()
-				} else if stat, err := os.Stat(altSolutionPath); err == nil {
+				} else if stat, err := h.contentRoot.Stat(submissionsDir, username, "solution-template.go"); err == nil {
 					modTime = stat.ModTime()
 				} else {
 					continue
@@ -724,15 +1084,9 @@ func (h *APIHandler) calculateMainLeaderboard() []LeaderboardUser {
 	// Process all challenge scoreboards to find completions
 	for challengeID := range challenges {
 		// Read scoreboard file directly to check test results
-		scoreboardPath := fmt.Sprintf("../challenge-%d/SCOREBOARD.md", challengeID)
-		content, err := ioutil.ReadFile(scoreboardPath)
+		content, err := h.contentRoot.ReadFile(fmt.Sprintf("challenge-%d", challengeID), "SCOREBOARD.md")
 		if err != nil {
-			// Try alternative path
-			scoreboardPath = fmt.Sprintf("challenge-%d/SCOREBOARD.md", challengeID)
-			content, err = ioutil.ReadFile(scoreboardPath)
-			if err != nil {
-				continue
-			}
+			continue
 		}
 
 		// Parse scoreboard to find users who passed ALL tests
@@ -845,8 +1199,10 @@ func (h *APIHandler) HandlePackageChallenge(w http.ResponseWriter, r *http.Reque
 
 	// Parse request body
 	var request struct {
-		Code     string `json:"code"`
-		Username string `json:"username"`
+		Code      string            `json:"code"`
+		Files     map[string]string `json:"files"`
+		Username  string            `json:"username"`
+		Toolchain string            `json:"toolchain"` // Optional Go version, e.g. "1.24"
 	}
 
 	body, err := ioutil.ReadAll(r.Body)
@@ -861,7 +1217,7 @@ func (h *APIHandler) HandlePackageChallenge(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	if request.Code == "" {
+	if request.Code == "" && len(request.Files) == 0 {
 		http.Error(w, "Code is required", http.StatusBadRequest)
 		return
 	}
@@ -875,72 +1231,62 @@ func (h *APIHandler) HandlePackageChallenge(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	// Convert PackageChallenge to Challenge format for ExecutionService
-	challengeForExecution := &models.Challenge{
-		ID:       0, // Package challenges don't use numeric IDs
-		Title:    challenge.Title,
-		TestFile: challenge.TestFile,
+	files, ok := submissionFiles(w, request.Code, request.Files, challenge.Template)
+	if !ok {
+		return
 	}
 
-	// Run the actual tests using ExecutionService
-	result := h.executionService.RunCode(request.Code, challengeForExecution)
+	// Run the tests with the challenge's pinned module, like run_tests.sh does
+	result, ok := h.runQueued(w, r, request.Username, func(ctx context.Context) services.ExecutionResult {
+		return h.executionService.RunPackageChallenge(ctx, files, challenge, request.Toolchain)
+	})
+	if !ok {
+		return
+	}
+	kind := models.SubmissionKindRun
+	if action == "submit" {
+		kind = models.SubmissionKindSubmit
+	}
+	h.recordResult(r, kind, request.Username, models.Submission{Package: packageName, PackageChallenge: challengeId}, files, result)
 
 	// Format response
 	response := map[string]interface{}{
 		"success":      result.Passed,
 		"execution_ms": result.ExecutionMs,
 		"output":       result.Output,
+		"go_version":   result.GoVersion,
+		"analysis":     result.Analysis,
+	}
+	if result.QueuePosition > 0 {
+		response["queue_position"] = result.QueuePosition
+		response["queued_ms"] = result.QueuedMs
 	}
 
-	// Count passed tests from output for display
-	testsPassed, testsTotal := h.parseTestResults(result.Output)
-	response["tests_passed"] = testsPassed
-	response["tests_total"] = testsTotal
+	// Per-test results from go test -json
+	response["tests"] = result.Report
+	response["tests_passed"] = 0
+	response["tests_total"] = 0
+	if result.Report != nil {
+		response["tests_passed"] = result.Report.Passed
+		response["tests_total"] = result.Report.Total
+	}
+	if result.Termination != "" {
+		response["termination"] = result.Termination
+		response["termination_reason"] = result.TerminationReason
+	}
 
 	if action == "submit" && result.Passed {
 		response["message"] = "Solution submitted successfully!"
 		response["show_pr_instructions"] = true
 
-		// Set username cookie if provided
-		if request.Username != "" {
-			h.setUsernameCookie(w, request.Username)
-		}
+		// Remember the username in dev mode
+		h.authService.RememberDevUsername(w, r, h.currentUsername(r, request.Username))
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response)
 }
 
-// parseTestResults parses Go test output to count passed and total tests
-func (h *APIHandler) parseTestResults(output string) (passed int, total int) {
-	lines := strings.Split(output, "\n")
-
-	for _, line := range lines {
-		// Look for test result lines like "--- PASS: TestGetUsers" or "--- FAIL: TestCreateUser"
-		if strings.Contains(line, "--- PASS:") {
-			passed++
-			total++
-		} else if strings.Contains(line, "--- FAIL:") {
-			total++
-		}
-	}
-
-	// If no individual test results found, check for overall result
-	if total == 0 {
-		if strings.Contains(output, "PASS") && !strings.Contains(output, "FAIL") {
-			// Assume basic success case
-			passed = 1
-			total = 1
-		} else if strings.Contains(output, "FAIL") {
-			// Assume basic failure case
-			passed = 0
-			total = 1
-		}
-	}
-
-	return passed, total
-}
-
 // SavePackageChallengeToFilesystem saves a package challenge submission to the filesystem
 func (h *APIHandler) SavePackageChallengeToFilesystem(w http.ResponseWriter, r *http.Request) {
 	if r.Method != "POST" {
@@ -948,12 +1294,7 @@ func (h *APIHandler) SavePackageChallengeToFilesystem(w http.ResponseWriter, r *
 		return
 	}
 
-	var request struct {
-		Username    string `json:"username"`
-		PackageName string `json:"packageName"`
-		ChallengeID string `json:"challengeId"`
-		Code        string `json:"code"`
-	}
+	var request packageSaveRequest
 
 	err := json.NewDecoder(r.Body).Decode(&request)
 	if err != nil {
@@ -961,89 +1302,59 @@ func (h *APIHandler) SavePackageChallengeToFilesystem(w http.ResponseWriter, r *
 		return
 	}
 
-	if request.Username == "" {
-		http.Error(w, "Username is required", http.StatusBadRequest)
+	// Files are saved under the signed-in user's name
+	username, ok := h.requireUsername(w, r, request.Username)
+	if !ok {
 		return
 	}
-
-	// Set username cookie
-	h.setUsernameCookie(w, request.Username)
+	request.Username = username
+	h.authService.RememberDevUsername(w, r, username)
 
 	// Validate challenge exists
-	_, err = h.packageService.GetPackageChallenge(request.PackageName, request.ChallengeID)
+	challenge, err := h.packageService.GetPackageChallenge(request.PackageName, request.ChallengeID)
 	if err != nil {
 		http.Error(w, "Challenge not found", http.StatusNotFound)
 		return
 	}
 
+	files, ok := submissionFiles(w, request.Code, request.Files, challenge.Template)
+	if !ok {
+		return
+	}
+	request.Files = files
+
 	// Save to filesystem
-	response := h.savePackageChallengeToFilesystem(request)
+	response, err := h.savePackageChallengeToFilesystem(request)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response)
 }
 
-// savePackageChallengeToFilesystem handles the actual file saving for package challenges
-func (h *APIHandler) savePackageChallengeToFilesystem(request struct {
-	Username    string `json:"username"`
-	PackageName string `json:"packageName"`
-	ChallengeID string `json:"challengeId"`
-	Code        string `json:"code"`
-}) services.SaveSubmissionResponse {
-	// Get working directory for correct relative paths
-	workDir, _ := os.Getwd()
-
-	// Try different path approaches to handle potential path issues
-	var submissionDir string
-	var fileSaved bool
-
-	// Try multiple path options for package challenges
-	pathOptions := []string{
-		// Option 1: From web-ui directory (standard case)
-		filepath.Join("..", "packages", request.PackageName, request.ChallengeID, "submissions", request.Username),
-		// Option 2: From root workspace
-		filepath.Join("packages", request.PackageName, request.ChallengeID, "submissions", request.Username),
-		// Option 3: Absolute path from detected workspace root
-		filepath.Join(workDir, "..", "packages", request.PackageName, request.ChallengeID, "submissions", request.Username),
-	}
-
-	for _, dirPath := range pathOptions {
-		err := os.MkdirAll(dirPath, 0755)
-		if err != nil {
-			continue
-		}
-
-		solutionFile := filepath.Join(dirPath, "solution.go")
-		err = ioutil.WriteFile(solutionFile, []byte(request.Code), 0644)
-		if err != nil {
-			continue
-		}
-
-		submissionDir = dirPath
-		fileSaved = true
-		break
-	}
-
-	if !fileSaved {
-		return services.SaveSubmissionResponse{
-			Success: false,
-			Message: "Failed to save solution to any available path",
-		}
-	}
+// packageSaveRequest is a request to save a package challenge submission
+type packageSaveRequest struct {
+	Username    string            `json:"username"`
+	PackageName string            `json:"packageName"`
+	ChallengeID string            `json:"challengeId"`
+	Code        string            `json:"code"`
+	Files       map[string]string `json:"files,omitempty"` // Multi-file submissions; Code is the main file otherwise
+}
 
-	// Return success response with git commands
-	relativePath := filepath.Join("packages", request.PackageName, request.ChallengeID, "submissions", request.Username, "solution.go")
-	return services.SaveSubmissionResponse{
-		Success:  true,
-		Message:  "Solution saved to filesystem",
-		FilePath: filepath.Join(submissionDir, "solution.go"),
-		GitCommands: []string{
-			"cd " + filepath.Join(workDir, ".."),
-			fmt.Sprintf("git add %s", relativePath),
-			fmt.Sprintf("git commit -m \"Add solution for %s %s by %s\"", request.PackageName, request.ChallengeID, request.Username),
-			"git push origin main",
-		},
+// savePackageChallengeToFilesystem handles the actual file saving for package
+// challenges. The error reports an invalid username, package or challenge.
+func (h *APIHandler) savePackageChallengeToFilesystem(request packageSaveRequest) (services.SaveSubmissionResponse, error) {
+	paths := h.executionService.SubmissionPaths()
+	dir, err := paths.PackageChallengeDir(request.Username, request.PackageName, request.ChallengeID)
+	if err != nil {
+		return services.SaveSubmissionResponse{}, err
 	}
+	// Package submissions keep the main file as solution.go
+	files := services.SubmissionFiles(request.Code, request.Files)
+	message := fmt.Sprintf("Add solution for %s %s by %s", request.PackageName, request.ChallengeID, request.Username)
+	return paths.SaveSubmission(dir, files, "solution.go", message), nil
 }
 
 // AICodeReview performs AI-powered code review
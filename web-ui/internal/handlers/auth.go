@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"web-ui/internal/services"
+)
+
+// Login starts GitHub login; ?next= is the local page to return to
+func (h *APIHandler) Login(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if h.authService.Mode() != services.AuthModeGitHub {
+		http.Error(w, "GitHub login is not configured", http.StatusNotFound)
+		return
+	}
+	h.authService.StartLogin(w, r, r.URL.Query().Get("next"))
+}
+
+// AuthCallback finishes GitHub login and returns to the page login started from
+func (h *APIHandler) AuthCallback(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if h.authService.Mode() != services.AuthModeGitHub {
+		http.Error(w, "GitHub login is not configured", http.StatusNotFound)
+		return
+	}
+	next, err := h.authService.CompleteLogin(w, r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	http.Redirect(w, r, next, http.StatusFound)
+}
+
+// Logout ends the current session
+func (h *APIHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	h.authService.Logout(w, r)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]bool{"success": true})
+}
+
+// GetAuthStatus returns the login mode and the signed-in user
+func (h *APIHandler) GetAuthStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.authService.Status(r))
+}
+
+// currentUsername returns who a request acts for. In dev mode a username
+// claimed in the request wins; with GitHub login only the session counts.
+func (h *APIHandler) currentUsername(r *http.Request, claimed string) string {
+	return h.authService.Username(r, claimed)
+}
+
+// requireUsername is currentUsername for requests that need a user. It writes
+// the error response and returns false when nobody is signed in or the claimed
+// username is not a valid GitHub username.
+func (h *APIHandler) requireUsername(w http.ResponseWriter, r *http.Request, claimed string) (string, bool) {
+	if h.authService.Mode() == services.AuthModeDev && claimed != "" {
+		if err := services.ValidateUsername(claimed); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return "", false
+		}
+	}
+	username := h.currentUsername(r, claimed)
+	if username != "" {
+		return username, true
+	}
+	if h.authService.Mode() == services.AuthModeGitHub {
+		http.Error(w, "Sign in with GitHub first", http.StatusUnauthorized)
+	} else {
+		http.Error(w, "Username is required", http.StatusBadRequest)
+	}
+	return "", false
+}
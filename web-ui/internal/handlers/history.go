@@ -0,0 +1,180 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"web-ui/internal/models"
+	"web-ui/internal/services"
+)
+
+// History pages hold 20 submissions unless pageSize asks for up to 100
+const (
+	defaultHistoryPageSize = 20
+	maxHistoryPageSize     = 100
+)
+
+// applyResult copies the outcome of a test run into a submission
+func applyResult(submission *models.Submission, result services.ExecutionResult) {
+	submission.Passed = result.Passed
+	submission.TestOutput = result.Output
+	submission.ExecutionMs = result.ExecutionMs
+	submission.Report = result.Report
+	submission.Options = result.Options
+	submission.VetIssues = result.VetIssues
+	submission.Coverage = result.Coverage
+	submission.Benchmarks = result.Benchmarks
+	submission.Fuzz = result.Fuzz
+	submission.Differential = result.Differential
+	submission.GoVersion = result.GoVersion
+	submission.Analysis = result.Analysis
+	if result.Report != nil {
+		submission.TestsPassed = result.Report.Passed
+		submission.TestsTotal = result.Report.Total
+	}
+	submission.Hidden = result.Hidden
+	if result.Hidden != nil {
+		submission.TestsPassed += result.Hidden.Passed
+		submission.TestsTotal += result.Hidden.Total
+	}
+}
+
+// recordSubmission stores a submission in the history; failures only lose history
+func (h *APIHandler) recordSubmission(submission *models.Submission) {
+	if err := h.submissionStore.Add(submission); err != nil {
+		fmt.Printf("Warning: could not store submission: %v\n", err)
+	}
+}
+
+// recordResult stores a run or package submit of files in the history of the
+// current user; claimed is the username in the request body, which only dev
+// mode trusts. Anonymous runs are not recorded.
+func (h *APIHandler) recordResult(r *http.Request, kind, claimed string, submission models.Submission, files map[string]string, result services.ExecutionResult) {
+	username := h.currentUsername(r, claimed)
+	if username == "" {
+		return
+	}
+	submission.Kind = kind
+	submission.Username = username
+	submission.SubmittedAt = time.Now()
+	submission.Code = files[services.MainSolutionFile]
+	if len(files) > 1 {
+		submission.Files = files
+	}
+	applyResult(&submission, result)
+	h.recordSubmission(&submission)
+}
+
+// getSubmissions returns a page of the current user's history, newest first.
+// ?challengeId=N or ?package=P&challenge=C narrows it to one challenge,
+// ?kind=run or ?kind=submit to one kind; ?page (from 1) and ?pageSize page it.
+func (h *APIHandler) getSubmissions(w http.ResponseWriter, r *http.Request) {
+	username := h.currentUsername(r, "")
+	if username == "" {
+		http.Error(w, "Sign in to see your submissions", http.StatusUnauthorized)
+		return
+	}
+
+	query := r.URL.Query()
+	filter := services.SubmissionQuery{
+		Username:         username,
+		Package:          query.Get("package"),
+		PackageChallenge: query.Get("challenge"),
+		Kind:             query.Get("kind"),
+	}
+	if value := query.Get("challengeId"); value != "" {
+		id, err := strconv.Atoi(value)
+		if err != nil {
+			http.Error(w, "Invalid challenge ID", http.StatusBadRequest)
+			return
+		}
+		filter.ChallengeID = id
+	}
+	if filter.Kind != "" && filter.Kind != models.SubmissionKindRun && filter.Kind != models.SubmissionKindSubmit {
+		http.Error(w, "Invalid kind. Must be 'run' or 'submit'", http.StatusBadRequest)
+		return
+	}
+
+	page, pageSize := 1, defaultHistoryPageSize
+	if value, err := strconv.Atoi(query.Get("page")); err == nil && value > 0 {
+		page = value
+	}
+	if value, err := strconv.Atoi(query.Get("pageSize")); err == nil && value > 0 {
+		pageSize = min(value, maxHistoryPageSize)
+	}
+	filter.Offset = (page - 1) * pageSize
+	filter.Limit = pageSize
+
+	submissions, total := h.submissionStore.List(filter)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.SubmissionHistory{
+		Submissions: submissions,
+		Total:       total,
+		Page:        page,
+		PageSize:    pageSize,
+	})
+}
+
+// HandleSubmission serves a stored submission with its code at
+// /api/submissions/{id}, and the diff of two of them at
+// /api/submissions/diff?from={id}&to={id}. Only their owner may read them.
+func (h *APIHandler) HandleSubmission(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	path := strings.TrimPrefix(r.URL.Path, "/api/submissions/")
+	if path == "diff" {
+		from, ok := h.ownedSubmission(w, r, r.URL.Query().Get("from"))
+		if !ok {
+			return
+		}
+		to, ok := h.ownedSubmission(w, r, r.URL.Query().Get("to"))
+		if !ok {
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(services.DiffSubmissions(from, to))
+		return
+	}
+
+	submission, ok := h.ownedSubmission(w, r, path)
+	if !ok {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(submission)
+}
+
+// ownedSubmission loads a submission of the current user. It writes the error
+// response and returns false when the ID is invalid, unknown or someone else's.
+func (h *APIHandler) ownedSubmission(w http.ResponseWriter, r *http.Request, value string) (*models.Submission, bool) {
+	id, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid submission ID", http.StatusBadRequest)
+		return nil, false
+	}
+
+	submission, err := h.submissionStore.Get(id)
+	switch {
+	case errors.Is(err, services.ErrSubmissionNotFound):
+		http.Error(w, "Submission not found", http.StatusNotFound)
+		return nil, false
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return nil, false
+	}
+
+	// Someone else's submission is reported as missing so IDs cannot be probed
+	if username := h.currentUsername(r, ""); username == "" || submission.Username != username {
+		http.Error(w, "Submission not found", http.StatusNotFound)
+		return nil, false
+	}
+	return submission, true
+}
@@ -5,14 +5,12 @@ import (
 	"html/template"
 	"log"
 	"net/http"
+	"path"
 	"sort"
 	"strconv"
 	"strings"
 	"time"
 
-	"io/ioutil"
-	"os"
-	"path/filepath"
 	"web-ui/internal/models"
 	"web-ui/internal/services"
 	"web-ui/internal/utils"
@@ -25,6 +23,8 @@ type WebHandler struct {
 	scoreboardService *services.ScoreboardService
 	userService       *services.UserService
 	packageService    *services.PackageService
+	authService       *services.AuthService
+	contentRoot       *services.ContentRoot
 }
 
 // NewWebHandler creates a new web handler
@@ -34,6 +34,8 @@ func NewWebHandler(
 	scoreboardService *services.ScoreboardService,
 	userService *services.UserService,
 	packageService *services.PackageService,
+	authService *services.AuthService,
+	contentRoot *services.ContentRoot,
 ) *WebHandler {
 	return &WebHandler{
 		content:           content,
@@ -41,6 +43,8 @@ func NewWebHandler(
 		scoreboardService: scoreboardService,
 		userService:       userService,
 		packageService:    packageService,
+		authService:       authService,
+		contentRoot:       contentRoot,
 	}
 }
 
@@ -86,8 +90,8 @@ func (h *WebHandler) HomePage(w http.ResponseWriter, r *http.Request) {
 		return packagesList[i].Stars > packagesList[j].Stars
 	})
 
-	// Get the username from cookie if available
-	username := h.getUsernameFromCookie(r)
+	// Get the signed-in user if there is one
+	username := h.authService.Username(r, "")
 
 	// Get user attempts if username is set
 	var userAttempt *models.UserAttemptedChallenges
@@ -153,18 +157,8 @@ func (h *WebHandler) ChallengePage(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Get username from cookie first
-	username := h.getUsernameFromCookie(r)
-
-	// If no username from cookie, try to get it from Git config
-	if username == "" {
-		gitInfo := utils.GetGitUsername()
-		if gitInfo.Username != "" {
-			username = gitInfo.Username
-			// Set the cookie for future requests
-			h.setUsernameCookie(w, username)
-		}
-	}
+	// Get the signed-in user, or the git config username in dev mode
+	username := h.pageUsername(w, r)
 
 	existingSolution := ""
 	hasAttempted := false
@@ -342,8 +336,8 @@ func (h *WebHandler) InterviewPage(w http.ResponseWriter, r *http.Request) {
 		challengeList = append(challengeList, challenge)
 	}
 
-	// Get username from cookie if available
-	username := h.getUsernameFromCookie(r)
+	// Get the signed-in user if there is one
+	username := h.authService.Username(r, "")
 
 	data := struct {
 		Challenges []*models.Challenge
@@ -360,27 +354,18 @@ func (h *WebHandler) InterviewPage(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-// getUsernameFromCookie retrieves the username from cookie
-func (h *WebHandler) getUsernameFromCookie(r *http.Request) string {
-	cookie, err := r.Cookie("username")
-	if err != nil {
+// pageUsername returns the signed-in user. In dev mode it falls back to the
+// git config username and remembers it in the username cookie.
+func (h *WebHandler) pageUsername(w http.ResponseWriter, r *http.Request) string {
+	if username := h.authService.Username(r, ""); username != "" {
+		return username
+	}
+	if h.authService.Mode() != services.AuthModeDev {
 		return ""
 	}
-	return cookie.Value
-}
-
-// setUsernameCookie sets the username cookie
-func (h *WebHandler) setUsernameCookie(w http.ResponseWriter, username string) {
-	// Cookie expires in 30 days
-	expiration := time.Now().Add(30 * 24 * time.Hour)
-	cookie := http.Cookie{
-		Name:     "username",
-		Value:    username,
-		Expires:  expiration,
-		Path:     "/",
-		HttpOnly: false, // Allow JavaScript to access it
-	}
-	http.SetCookie(w, &cookie)
+	username := h.authService.Username(r, utils.GetGitUsername().Username)
+	h.authService.RememberDevUsername(w, r, username)
+	return username
 }
 
 // PackageDetailPage renders the package detail page
@@ -433,8 +418,8 @@ func (h *WebHandler) PackageDetailPage(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Get the username from cookie if available
-	username := h.getUsernameFromCookie(r)
+	// Get the signed-in user if there is one
+	username := h.authService.Username(r, "")
 
 	// Check which package challenges the user has attempted
 	packageAttempts := make(map[string]bool)
@@ -529,18 +514,8 @@ func (h *WebHandler) PackageChallengePage(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	// Get username from cookie first
-	username := h.getUsernameFromCookie(r)
-
-	// If no username from cookie, try to get it from Git config
-	if username == "" {
-		gitInfo := utils.GetGitUsername()
-		if gitInfo.Username != "" {
-			username = gitInfo.Username
-			// Set the cookie for future requests
-			h.setUsernameCookie(w, username)
-		}
-	}
+	// Get the signed-in user, or the git config username in dev mode
+	username := h.pageUsername(w, r)
 
 	// Check if user has attempted this challenge
 	hasAttempted := false
@@ -575,15 +550,14 @@ func (h *WebHandler) PackageChallengePage(w http.ResponseWriter, r *http.Request
 
 // hasUserAttemptedPackageChallenge checks if a user has attempted a package challenge
 func (h *WebHandler) hasUserAttemptedPackageChallenge(username, packageName, challengeID string) bool {
-	// Check if submission file exists in ../packages/{packageName}/{challengeID}/submissions/{username}/solution.go
-	submissionPath := filepath.Join("..", "packages", packageName, challengeID, "submissions", username, "solution.go")
-	if _, err := os.Stat(submissionPath); err == nil {
+	// Check if submission file exists in packages/{packageName}/{challengeID}/submissions/{username}/solution.go
+	userDir := path.Join("packages", packageName, challengeID, "submissions", username)
+	if _, err := h.contentRoot.Stat(userDir, "solution.go"); err == nil {
 		return true
 	}
 
 	// Try alternative path in case of different file naming
-	altSubmissionPath := filepath.Join("..", "packages", packageName, challengeID, "submissions", username, "solution-template.go")
-	if _, err := os.Stat(altSubmissionPath); err == nil {
+	if _, err := h.contentRoot.Stat(userDir, "solution-template.go"); err == nil {
 		return true
 	}
 
@@ -597,15 +571,14 @@ func (h *WebHandler) getUserPackageChallengeSolution(username, packageName, chal
 	}
 
 	// Try solution.go first
-	submissionPath := filepath.Join("..", "packages", packageName, challengeID, "submissions", username, "solution.go")
-	content, err := ioutil.ReadFile(submissionPath)
+	userDir := path.Join("packages", packageName, challengeID, "submissions", username)
+	content, err := h.contentRoot.ReadFile(userDir, "solution.go")
 	if err == nil {
 		return string(content)
 	}
 
 	// Try solution-template.go as fallback
-	altSubmissionPath := filepath.Join("..", "packages", packageName, challengeID, "submissions", username, "solution-template.go")
-	content, err = ioutil.ReadFile(altSubmissionPath)
+	content, err = h.contentRoot.ReadFile(userDir, "solution-template.go")
 	if err == nil {
 		return string(content)
 	}
@@ -615,15 +588,10 @@ func (h *WebHandler) getUserPackageChallengeSolution(username, packageName, chal
 
 // countPackageChallengeSubmissions counts the number of submissions for a package challenge
 func (h *WebHandler) countPackageChallengeSubmissions(packageName, challengeID string) int {
-	submissionsDir := filepath.Join("..", "packages", packageName, challengeID, "submissions")
-
-	// Check if submissions directory exists
-	if _, err := os.Stat(submissionsDir); os.IsNotExist(err) {
-		return 0
-	}
+	submissionsDir := path.Join("packages", packageName, challengeID, "submissions")
 
-	// Read the submissions directory
-	entries, err := ioutil.ReadDir(submissionsDir)
+	// Read the submissions directory; a missing one has no submissions
+	entries, err := h.contentRoot.ReadDir(submissionsDir)
 	if err != nil {
 		return 0
 	}
@@ -632,13 +600,9 @@ func (h *WebHandler) countPackageChallengeSubmissions(packageName, challengeID s
 	for _, entry := range entries {
 		if entry.IsDir() {
 			// Check if this user directory has a solution file
-			userDir := filepath.Join(submissionsDir, entry.Name())
-			solutionPath := filepath.Join(userDir, "solution.go")
-			altSolutionPath := filepath.Join(userDir, "solution-template.go")
-
-			if _, err := os.Stat(solutionPath); err == nil {
+			if _, err := h.contentRoot.Stat(submissionsDir, entry.Name(), "solution.go"); err == nil {
 				count++
-			} else if _, err := os.Stat(altSolutionPath); err == nil {
+			} else if _, err := h.contentRoot.Stat(submissionsDir, entry.Name(), "solution-template.go"); err == nil {
 				count++
 			}
 		}
@@ -659,15 +623,10 @@ func (h *WebHandler) createPackageLeaderboard(packageName string, challenges []*
 
 	// Collect submission data for each challenge
 	for _, challenge := range challenges {
-		submissionsDir := filepath.Join("..", "packages", packageName, challenge.ID, "submissions")
-
-		// Check if submissions directory exists
-		if _, err := os.Stat(submissionsDir); os.IsNotExist(err) {
-			continue
-		}
+		submissionsDir := path.Join("packages", packageName, challenge.ID, "submissions")
 
-		// Read the submissions directory
-		entries, err := ioutil.ReadDir(submissionsDir)
+		// Read the submissions directory; skip challenges without one
+		entries, err := h.contentRoot.ReadDir(submissionsDir)
 		if err != nil {
 			continue
 		}
@@ -675,15 +634,12 @@ func (h *WebHandler) createPackageLeaderboard(packageName string, challenges []*
 		for _, entry := range entries {
 			if entry.IsDir() {
 				username := entry.Name()
-				userDir := filepath.Join(submissionsDir, username)
-				solutionPath := filepath.Join(userDir, "solution.go")
-				altSolutionPath := filepath.Join(userDir, "solution-template.go")
 
 				// Check if user has a solution file (either solution.go or solution-template.go)
 				var modTime time.Time
-				if stat, err := os.Stat(solutionPath); err == nil {
+				if stat, err := h.contentRoot.Stat(submissionsDir, username, "solution.go"); err == nil {
 					modTime = stat.ModTime()
-				} else if stat, err := os.Stat(altSolutionPath); err == nil {
+				} else if stat, err := h.contentRoot.Stat(submissionsDir, username, "solution-template.go"); err == nil {
 					modTime = stat.ModTime()
 				} else {
 					continue
@@ -0,0 +1,31 @@
+package models
+
+// Diagnostic severities
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+	SeverityInfo    = "info"
+)
+
+// AnalysisReport is the deterministic static analysis of a submission:
+// formatting, imports, go vet and the bundled analyzers
+type AnalysisReport struct {
+	Diagnostics []Diagnostic      `json:"diagnostics"`
+	Formatted   map[string]string `json:"formatted,omitempty"` // gofmt and goimports output of the files that differ from it
+	Skipped     []string          `json:"skipped,omitempty"`   // Analyzers that could not run, e.g. because the code does not type-check
+}
+
+// Diagnostic is a single finding at a position in a submitted file
+type Diagnostic struct {
+	SourceLocation
+	Analyzer string `json:"analyzer"` // "syntax", "gofmt", "goimports", "vet", "shadow", "errcheck", "ineffassign" or "copylocks"
+	Severity string `json:"severity"` // "error", "warning" or "info"
+}
+
+// FormatResult is the outcome of formatting an editor buffer
+type FormatResult struct {
+	Code    string            `json:"code"`            // Formatted main file; unchanged when there are errors
+	Files   map[string]string `json:"files,omitempty"` // All formatted files of a multi-file request
+	Changed bool              `json:"changed"`
+	Errors  []Diagnostic      `json:"errors,omitempty"` // Syntax errors with their positions
+}
@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// User is the account a request acts for
+type User struct {
+	Username  string `json:"username"`
+	Name      string `json:"name,omitempty"`
+	AvatarURL string `json:"avatarUrl,omitempty"`
+}
+
+// Session is a server-side login session
+type Session struct {
+	User      User      `json:"user"`
+	CreatedAt time.Time `json:"createdAt"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// AuthStatus tells the browser how users sign in and who is signed in
+type AuthStatus struct {
+	Mode          string `json:"mode"` // "github" or "dev"
+	Authenticated bool   `json:"authenticated"`
+	User          *User  `json:"user,omitempty"`
+	LoginURL      string `json:"loginUrl,omitempty"`
+}
@@ -0,0 +1,51 @@
+package models
+
+import "time"
+
+// BenchmarkConfig declares how a challenge's Benchmark* functions are run and graded
+type BenchmarkConfig struct {
+	Pattern    string               `json:"pattern,omitempty"`   // -bench regexp, "." when empty
+	Benchtime  string               `json:"benchtime,omitempty"` // -benchtime, "100ms" when empty
+	Count      int                  `json:"count,omitempty"`     // -count, 3 when zero; the median run is used
+	CPU        int                  `json:"cpu,omitempty"`       // -cpu, 1 when zero, so results compare across hosts
+	Thresholds []BenchmarkThreshold `json:"thresholds"`
+}
+
+// BenchmarkThreshold is the performance a single benchmark must reach.
+// Zero limits are not checked. Benchmarks with a threshold make up the leaderboard score.
+type BenchmarkThreshold struct {
+	Name           string  `json:"name"` // Full benchmark name without the -cpu suffix, e.g. "BenchmarkSort/1000"
+	MaxNsPerOp     float64 `json:"max_ns_per_op,omitempty"`
+	MaxBytesPerOp  int64   `json:"max_bytes_per_op,omitempty"`
+	MaxAllocsPerOp int64   `json:"max_allocs_per_op,omitempty"`
+	Baseline       string  `json:"baseline,omitempty"`    // Benchmark to compare against, e.g. the naive version
+	MinSpeedup     float64 `json:"min_speedup,omitempty"` // Required Baseline ns/op divided by Name ns/op
+}
+
+// BenchmarkResult is the measured performance of a single benchmark
+type BenchmarkResult struct {
+	Name        string   `json:"name"`
+	Iterations  int64    `json:"iterations"`
+	NsPerOp     float64  `json:"nsPerOp"`
+	BytesPerOp  int64    `json:"bytesPerOp"`
+	AllocsPerOp int64    `json:"allocsPerOp"`
+	Graded      bool     `json:"graded"`               // A threshold applies to this benchmark
+	Violations  []string `json:"violations,omitempty"` // Thresholds that were missed
+}
+
+// BenchmarkReport is the outcome of benchmark grading
+type BenchmarkReport struct {
+	Results   []BenchmarkResult `json:"results"`
+	Baselines []BenchmarkResult `json:"baselines,omitempty"` // Baselines run with the reference sources, for speedups
+	Passed    bool              `json:"passed"`
+	Score     float64           `json:"score"` // Sum of ns/op of the graded benchmarks; lower is better
+}
+
+// PerformanceEntry is a user's best benchmark result for a challenge
+type PerformanceEntry struct {
+	Username    string            `json:"username"`
+	ChallengeID int               `json:"challengeId"`
+	Score       float64           `json:"score"`
+	Results     []BenchmarkResult `json:"results"`
+	SubmittedAt time.Time         `json:"submittedAt"`
+}
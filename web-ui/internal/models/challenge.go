@@ -6,25 +6,96 @@ import (
 
 // Challenge represents a coding challenge
 type Challenge struct {
-	ID                int    `json:"id"`
-	Title             string `json:"title"`
-	Description       string `json:"description"`
-	Difficulty        string `json:"difficulty"`
-	Template          string `json:"template"`
-	TestFile          string `json:"testFile"`
-	LearningMaterials string `json:"learningMaterials"`
-	Hints             string `json:"hints"`
+	ID                 int                   `json:"id"`
+	Title              string                `json:"title"`
+	Description        string                `json:"description"`
+	Difficulty         string                `json:"difficulty"`
+	EstimatedTime      string                `json:"estimatedTime,omitempty"`
+	Tags               []string              `json:"tags,omitempty"`
+	LearningObjectives []string              `json:"learningObjectives,omitempty"`
+	Prerequisites      []string              `json:"prerequisites,omitempty"`
+	Template           string                `json:"template"`
+	TestFile           string                `json:"testFile"`
+	HiddenTestFile     string                `json:"-"` // hidden_test.go, run on submit only and never sent to clients
+	LearningMaterials  string                `json:"learningMaterials"`
+	Hints              string                `json:"hints"`
+	Limits             ExecutionLimits       `json:"limits"`
+	RequiredOptions    RunOptions            `json:"requiredOptions"`        // Checks every run of this challenge must pass
+	Benchmark          *BenchmarkConfig      `json:"benchmark,omitempty"`    // Performance grading, if the challenge has any
+	Fuzz               *FuzzConfig           `json:"fuzz,omitempty"`         // Fuzz targets, if the challenge has any
+	FuzzFiles          map[string]string     `json:"-"`                      // fuzz/*_test.go with targets and reference implementation
+	Differential       *DifferentialConfig   `json:"differential,omitempty"` // Comparison with the reference solution, if the challenge has one
+	ReferenceFiles     map[string]string     `json:"-"`                      // reference/*.go solution and input generator
+	ModuleFile         string                `json:"-"`                      // go.mod shipped with the challenge, if any
+	ModuleSum          string                `json:"-"`                      // go.sum shipped with the challenge, if any
+	Toolchain          *ToolchainRequirement `json:"toolchain,omitempty"`    // Go version declared by the challenge go.mod
+}
+
+// ExecutionLimits bounds the resources a single sandboxed test run may use.
+// Zero values fall back to the execution service defaults.
+type ExecutionLimits struct {
+	TimeoutSeconds int  `json:"timeout_seconds,omitempty"` // Wall-clock limit for the whole run
+	CPUSeconds     int  `json:"cpu_seconds,omitempty"`     // CPU time per process (RLIMIT_CPU)
+	MemoryMB       int  `json:"memory_mb,omitempty"`       // Address space per process (RLIMIT_AS)
+	FileSizeMB     int  `json:"file_size_mb,omitempty"`    // Largest file a process may write (RLIMIT_FSIZE)
+	MaxOutputKB    int  `json:"max_output_kb,omitempty"`   // Combined stdout/stderr kept in the result
+	AllowNetwork   bool `json:"allow_network,omitempty"`   // Skip the empty network namespace
+}
+
+// RunOptions selects the extra checks performed by a test run
+type RunOptions struct {
+	Race         bool   `json:"race,omitempty"`         // Run the tests with -race; any data race fails the run
+	Vet          bool   `json:"vet,omitempty"`          // Run go vet; any diagnostic fails the run
+	Cover        bool   `json:"cover,omitempty"`        // Collect per-function coverage
+	Bench        bool   `json:"bench,omitempty"`        // Run and grade the challenge benchmarks after the tests pass
+	Fuzz         bool   `json:"fuzz,omitempty"`         // Fuzz the submission against the reference after the tests pass
+	Differential bool   `json:"differential,omitempty"` // Compare with the reference solution on random inputs after the tests pass
+	Toolchain    string `json:"toolchain,omitempty"`    // Go version to run with, e.g. "1.24"; the challenge's choice when empty
+}
+
+// Merge returns the options enabled in either o or other; o's toolchain takes precedence
+func (o RunOptions) Merge(other RunOptions) RunOptions {
+	merged := RunOptions{
+		Race:         o.Race || other.Race,
+		Vet:          o.Vet || other.Vet,
+		Cover:        o.Cover || other.Cover,
+		Bench:        o.Bench || other.Bench,
+		Fuzz:         o.Fuzz || other.Fuzz,
+		Differential: o.Differential || other.Differential,
+		Toolchain:    o.Toolchain,
+	}
+	if merged.Toolchain == "" {
+		merged.Toolchain = other.Toolchain
+	}
+	return merged
 }
 
 // Submission represents a user's submitted solution
 type Submission struct {
-	Username    string    `json:"username"`
-	ChallengeID int       `json:"challengeId"`
-	Code        string    `json:"code"`
-	SubmittedAt time.Time `json:"submittedAt"`
-	Passed      bool      `json:"passed"`
-	TestOutput  string    `json:"testOutput"`
-	ExecutionMs int64     `json:"executionMs"`
+	ID               int64               `json:"id,omitempty"`   // Assigned by the submission store
+	Kind             string              `json:"kind,omitempty"` // SubmissionKindRun or SubmissionKindSubmit
+	Username         string              `json:"username"`
+	ChallengeID      int                 `json:"challengeId"`
+	Package          string              `json:"package,omitempty"`          // Package challenges only, with PackageChallenge
+	PackageChallenge string              `json:"packageChallenge,omitempty"` // e.g. "challenge-1-basic-routing"
+	Code             string              `json:"code"`
+	Files            map[string]string   `json:"files,omitempty"` // All files of a multi-file submission, Code included
+	SubmittedAt      time.Time           `json:"submittedAt"`
+	Passed           bool                `json:"passed"`
+	TestOutput       string              `json:"testOutput"`
+	ExecutionMs      int64               `json:"executionMs"`
+	TestsPassed      int                 `json:"testsPassed"`
+	TestsTotal       int                 `json:"testsTotal"`
+	Report           *TestReport         `json:"report,omitempty"`
+	Options          RunOptions          `json:"options"`
+	VetIssues        []SourceLocation    `json:"vetIssues,omitempty"`
+	Coverage         *CoverageReport     `json:"coverage,omitempty"`
+	Benchmarks       *BenchmarkReport    `json:"benchmarks,omitempty"`
+	Fuzz             *FuzzReport         `json:"fuzz,omitempty"`
+	Differential     *DifferentialReport `json:"differential,omitempty"`
+	Hidden           *HiddenTestReport   `json:"hidden,omitempty"`
+	Analysis         *AnalysisReport     `json:"analysis,omitempty"`
+	GoVersion        string              `json:"goVersion,omitempty"` // Toolchain the submission was tested with
 }
 
 // ScoreboardEntry represents an entry in the scoreboard
@@ -32,6 +103,8 @@ type ScoreboardEntry struct {
 	Username    string    `json:"username"`
 	ChallengeID int       `json:"challengeId"`
 	SubmittedAt time.Time `json:"submittedAt"`
+	TestsPassed int       `json:"testsPassed"`
+	TestsTotal  int       `json:"testsTotal"`
 }
 
 // UserAttemptedChallenges tracks attempted challenges by username
@@ -0,0 +1,25 @@
+package models
+
+// DifferentialConfig declares how a submission is compared with the challenge's
+// private reference/ solution. The reference and its generator are never sent to clients.
+type DifferentialConfig struct {
+	Functions []string `json:"functions"`          // Template functions the reference implements
+	Cases     int      `json:"cases,omitempty"`    // Random inputs per function, 200 when zero
+	MaxSize   int      `json:"max_size,omitempty"` // Largest size passed to the generator, 50 when zero
+}
+
+// DifferentialReport is the outcome of comparing a submission with the reference
+type DifferentialReport struct {
+	Passed          bool             `json:"passed"`
+	Cases           int              `json:"cases"`                     // Inputs compared across all functions
+	Counterexamples []Counterexample `json:"counterexamples,omitempty"` // At most one per function, shrunk to a minimal input
+	Error           string           `json:"error,omitempty"`           // The comparison could not be completed
+}
+
+// Counterexample is an input for which the submission and the reference disagree
+type Counterexample struct {
+	Function string   `json:"function"`
+	Inputs   []string `json:"inputs"` // Go syntax of each argument
+	Got      string   `json:"got"`    // Go syntax of the submission's results
+	Want     string   `json:"want"`   // Go syntax of the reference's results
+}
@@ -0,0 +1,25 @@
+package models
+
+// FuzzConfig declares the native Go fuzz targets run against submissions.
+// The targets live in the challenge's fuzz/ directory together with the
+// reference implementation they compare against, and are never sent to clients.
+type FuzzConfig struct {
+	Targets  []string `json:"targets"`            // Fuzz* functions found in the fuzz/ files
+	Fuzztime string   `json:"fuzztime,omitempty"` // -fuzztime per target, "10s" when empty
+}
+
+// FuzzReport is the outcome of fuzzing a submission
+type FuzzReport struct {
+	Targets []FuzzTargetResult `json:"targets"`
+	Passed  bool               `json:"passed"`
+}
+
+// FuzzTargetResult is the outcome of a single fuzz target. A failing target
+// carries the input that crashed the submission or made it differ from the reference.
+type FuzzTargetResult struct {
+	Name    string   `json:"name"`
+	Passed  bool     `json:"passed"`
+	Inputs  []string `json:"inputs,omitempty"`  // Go literals of the failing input, one per fuzz argument
+	Message string   `json:"message,omitempty"` // Failure reported by the target, e.g. the differing results
+	Error   string   `json:"error,omitempty"`   // The target could not be fuzzed, e.g. it did not compile
+}
@@ -0,0 +1,65 @@
+package models
+
+import "time"
+
+// Kinds of recorded submissions
+const (
+	SubmissionKindRun    = "run"
+	SubmissionKindSubmit = "submit"
+)
+
+// SubmissionSummary is a submission without its code and output, as listed in a history
+type SubmissionSummary struct {
+	ID               int64     `json:"id"`
+	Kind             string    `json:"kind"`
+	Username         string    `json:"username"`
+	ChallengeID      int       `json:"challengeId,omitempty"`
+	Package          string    `json:"package,omitempty"`
+	PackageChallenge string    `json:"packageChallenge,omitempty"`
+	SubmittedAt      time.Time `json:"submittedAt"`
+	Passed           bool      `json:"passed"`
+	TestsPassed      int       `json:"testsPassed"`
+	TestsTotal       int       `json:"testsTotal"`
+	ExecutionMs      int64     `json:"executionMs"`
+	GoVersion        string    `json:"goVersion,omitempty"`
+}
+
+// Summary returns the history entry of a submission
+func (s Submission) Summary() SubmissionSummary {
+	return SubmissionSummary{
+		ID:               s.ID,
+		Kind:             s.Kind,
+		Username:         s.Username,
+		ChallengeID:      s.ChallengeID,
+		Package:          s.Package,
+		PackageChallenge: s.PackageChallenge,
+		SubmittedAt:      s.SubmittedAt,
+		Passed:           s.Passed,
+		TestsPassed:      s.TestsPassed,
+		TestsTotal:       s.TestsTotal,
+		ExecutionMs:      s.ExecutionMs,
+		GoVersion:        s.GoVersion,
+	}
+}
+
+// SubmissionHistory is one page of a user's submissions, newest first
+type SubmissionHistory struct {
+	Submissions []SubmissionSummary `json:"submissions"`
+	Total       int                 `json:"total"`
+	Page        int                 `json:"page"`
+	PageSize    int                 `json:"pageSize"`
+}
+
+// SubmissionDiff compares the files of two submissions
+type SubmissionDiff struct {
+	From  SubmissionSummary `json:"from"`
+	To    SubmissionSummary `json:"to"`
+	Files []FileDiff        `json:"files"`
+}
+
+// FileDiff is the unified diff of one file between two submissions
+type FileDiff struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`         // "added", "removed", "modified" or "unchanged"
+	Diff   string `json:"diff,omitempty"` // Unified diff with 3 lines of context
+}
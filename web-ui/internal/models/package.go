@@ -54,30 +54,38 @@ type ChallengeMetadata struct {
 	BonusPoints         []string `json:"bonus_points"`
 	Icon                string   `json:"icon,omitempty"`
 	Order               int      `json:"order"`
+
+	// Classic challenges only; missing values fall back to the built-in defaults
+	Limits     *ExecutionLimits `json:"limits,omitempty"`      // Sandbox limits of test runs
+	RunOptions *RunOptions      `json:"run_options,omitempty"` // Checks every run must pass
+	Benchmark  *BenchmarkConfig `json:"benchmark,omitempty"`   // Performance grading
 }
 
 // PackageChallenge represents a challenge specific to a package
 type PackageChallenge struct {
-	ID                  string   `json:"id"`           // e.g., "challenge-1-basic-routing"
-	PackageName         string   `json:"package_name"` // e.g., "gin"
-	Title               string   `json:"title"`
-	Description         string   `json:"description"`
-	ShortDescription    string   `json:"short_description"` // Brief description for cards
-	Difficulty          string   `json:"difficulty"`
-	LearningObjectives  []string `json:"learning_objectives"`
-	Template            string   `json:"template"`
-	TestFile            string   `json:"testFile"`
-	LearningMaterials   string   `json:"learningMaterials"`
-	Hints               string   `json:"hints"`
-	Requirements        []string `json:"requirements"`
-	BonusPoints         []string `json:"bonus_points"`
-	RealWorldConnection string   `json:"real_world_connection"`
-	EstimatedTime       string   `json:"estimated_time"`
-	Tags                []string `json:"tags"`
-	Prerequisites       []string `json:"prerequisites"`
-	Icon                string   `json:"icon,omitempty"`
-	Order               int      `json:"order"`
-	Status              string   `json:"status,omitempty"` // "available", "coming-soon", etc.
+	ID                  string                `json:"id"`           // e.g., "challenge-1-basic-routing"
+	PackageName         string                `json:"package_name"` // e.g., "gin"
+	Title               string                `json:"title"`
+	Description         string                `json:"description"`
+	ShortDescription    string                `json:"short_description"` // Brief description for cards
+	Difficulty          string                `json:"difficulty"`
+	LearningObjectives  []string              `json:"learning_objectives"`
+	Template            string                `json:"template"`
+	TestFile            string                `json:"testFile"`
+	LearningMaterials   string                `json:"learningMaterials"`
+	Hints               string                `json:"hints"`
+	Requirements        []string              `json:"requirements"`
+	BonusPoints         []string              `json:"bonus_points"`
+	RealWorldConnection string                `json:"real_world_connection"`
+	EstimatedTime       string                `json:"estimated_time"`
+	Tags                []string              `json:"tags"`
+	Prerequisites       []string              `json:"prerequisites"`
+	Icon                string                `json:"icon,omitempty"`
+	Order               int                   `json:"order"`
+	Status              string                `json:"status,omitempty"`    // "available", "coming-soon", etc.
+	ModuleFile          string                `json:"-"`                   // Pinned go.mod used by run_tests.sh
+	ModuleSum           string                `json:"-"`                   // Pinned go.sum used by run_tests.sh
+	Toolchain           *ToolchainRequirement `json:"toolchain,omitempty"` // Go version declared by the pinned go.mod
 }
 
 // PackageSubmission represents a user's submitted solution for a package challenge
@@ -0,0 +1,74 @@
+package models
+
+// Test status values used in TestCase.Status
+const (
+	TestStatusPass = "pass"
+	TestStatusFail = "fail"
+	TestStatusSkip = "skip"
+)
+
+// TestReport is the structured outcome of a `go test -json` run
+type TestReport struct {
+	Tests        []*TestCase  `json:"tests"`                  // Top-level tests with their subtests
+	BuildFailed  bool         `json:"buildFailed"`            // The package did not compile
+	BuildErrors  []BuildError `json:"buildErrors,omitempty"`  // Compiler and vet diagnostics
+	BuildOutput  string       `json:"buildOutput,omitempty"`  // Raw compiler output
+	ExtraOutput  []string     `json:"extraOutput,omitempty"`  // Package output not tied to a test (init panics, TestMain, ...)
+	Passed       int          `json:"passed"`                 // Leaf test cases that passed
+	Failed       int          `json:"failed"`                 // Leaf test cases that failed
+	Skipped      int          `json:"skipped"`                // Leaf test cases that were skipped
+	Total        int          `json:"total"`                  // All leaf test cases
+	PackageError string       `json:"packageError,omitempty"` // Panic or other failure outside of any test
+	DataRaces    int          `json:"dataRaces,omitempty"`    // Races reported by the race detector
+}
+
+// TestCase is a single test or subtest
+type TestCase struct {
+	Name      string          `json:"name"`              // Full name, e.g. "TestAdd/negative_numbers"
+	Status    string          `json:"status"`            // "pass", "fail" or "skip"
+	ElapsedMs int64           `json:"elapsedMs"`         // Time reported by the testing package
+	Output    []string        `json:"output,omitempty"`  // Log and error lines, framing lines removed
+	Failure   *SourceLocation `json:"failure,omitempty"` // First reported failure location
+	Subtests  []*TestCase     `json:"subtests,omitempty"`
+}
+
+// HiddenTestReport is the outcome of a challenge's hidden tests. Only names and
+// statuses are reported so the cases themselves stay private.
+type HiddenTestReport struct {
+	Tests       []HiddenTestCase `json:"tests"`       // Top-level hidden tests
+	BuildFailed bool             `json:"buildFailed"` // The hidden tests did not compile against the solution
+	Passed      int              `json:"passed"`
+	Failed      int              `json:"failed"`
+	Total       int              `json:"total"`
+}
+
+// HiddenTestCase is the status of a single hidden test
+type HiddenTestCase struct {
+	Name   string `json:"name"`
+	Status string `json:"status"` // "pass", "fail" or "skip"
+}
+
+// SourceLocation points at a line in a submitted or test file
+type SourceLocation struct {
+	File    string `json:"file"`
+	Line    int    `json:"line"`
+	Column  int    `json:"column,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// BuildError is a compiler diagnostic reported before any test ran
+type BuildError = SourceLocation
+
+// CoverageReport is the statement coverage of a run, as reported by `go tool cover -func`
+type CoverageReport struct {
+	Total     float64            `json:"total"` // Percentage of statements covered
+	Functions []FunctionCoverage `json:"functions"`
+}
+
+// FunctionCoverage is the statement coverage of a single function
+type FunctionCoverage struct {
+	File     string  `json:"file"`
+	Line     int     `json:"line"`
+	Function string  `json:"function"`
+	Percent  float64 `json:"percent"`
+}
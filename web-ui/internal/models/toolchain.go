@@ -0,0 +1,14 @@
+package models
+
+// ToolchainRequirement is the Go toolchain a challenge declares through the
+// go and toolchain directives of its go.mod
+type ToolchainRequirement struct {
+	Minimum string `json:"minimum,omitempty"` // Oldest Go version that can build the challenge, e.g. "1.23"
+	Target  string `json:"target,omitempty"`  // Preferred toolchain when installed, e.g. "go1.25.1"
+}
+
+// ToolchainInfo describes a Go toolchain runs can be pinned to
+type ToolchainInfo struct {
+	Version string `json:"version"` // e.g. "go1.25.1"
+	Default bool   `json:"default"` // The go command on PATH, used when nothing else is requested
+}
@@ -16,13 +16,18 @@ import (
 
 // Server represents the web server with all its dependencies
 type Server struct {
-	content           embed.FS
-	challengeService  *services.ChallengeService
-	scoreboardService *services.ScoreboardService
-	userService       *services.UserService
-	executionService  *services.ExecutionService
-	packageService    *services.PackageService
-	aiService         *services.AIService
+	content            embed.FS
+	challengeService   *services.ChallengeService
+	scoreboardService  *services.ScoreboardService
+	userService        *services.UserService
+	executionService   *services.ExecutionService
+	packageService     *services.PackageService
+	aiService          *services.AIService
+	performanceService *services.PerformanceService
+	submissionStore    services.SubmissionStore
+	authService        *services.AuthService
+	contentRoot        *services.ContentRoot
+	contentReloader    *services.ContentReloader
 }
 
 // NewServer creates a new server instance
@@ -34,15 +39,25 @@ func NewServer(
 	executionService *services.ExecutionService,
 	packageService *services.PackageService,
 	aiService *services.AIService,
+	performanceService *services.PerformanceService,
+	submissionStore services.SubmissionStore,
+	authService *services.AuthService,
+	contentRoot *services.ContentRoot,
+	contentReloader *services.ContentReloader,
 ) *Server {
 	return &Server{
-		content:           content,
-		challengeService:  challengeService,
-		scoreboardService: scoreboardService,
-		userService:       userService,
-		executionService:  executionService,
-		packageService:    packageService,
-		aiService:         aiService,
+		content:            content,
+		challengeService:   challengeService,
+		scoreboardService:  scoreboardService,
+		userService:        userService,
+		executionService:   executionService,
+		packageService:     packageService,
+		aiService:          aiService,
+		performanceService: performanceService,
+		submissionStore:    submissionStore,
+		authService:        authService,
+		contentRoot:        contentRoot,
+		contentReloader:    contentReloader,
 	}
 }
 
@@ -61,6 +76,10 @@ func (s *Server) SetupRoutes() *http.ServeMux {
 		s.executionService,
 		s.packageService,
 		s.aiService,
+		s.performanceService,
+		s.submissionStore,
+		s.authService,
+		s.contentRoot,
 	)
 
 	webHandler := handlers.NewWebHandler(
@@ -69,19 +88,36 @@ func (s *Server) SetupRoutes() *http.ServeMux {
 		s.scoreboardService,
 		s.userService,
 		s.packageService,
+		s.authService,
+		s.contentRoot,
 	)
 
+	adminHandler := handlers.NewAdminHandler(s.contentReloader)
+
 	// API routes
 	mux.HandleFunc("/api/challenges", apiHandler.GetAllChallenges)
 	mux.HandleFunc("/api/challenges/", apiHandler.GetChallengeByID)
 	mux.HandleFunc("/api/submissions", apiHandler.HandleSubmissions)
+	mux.HandleFunc("/api/submissions/", apiHandler.HandleSubmission)
 	mux.HandleFunc("/api/scoreboard/", apiHandler.GetScoreboard)
+	mux.HandleFunc("/api/performance/", apiHandler.GetPerformanceLeaderboard)
 	mux.HandleFunc("/api/run", apiHandler.RunCode)
+	mux.HandleFunc("/api/run/stream", apiHandler.RunCodeStream)
+	mux.HandleFunc("/api/queue", apiHandler.GetQueueStatus)
+	mux.HandleFunc("/api/toolchains", apiHandler.GetToolchains)
+	mux.HandleFunc("/api/format", apiHandler.FormatCode)
+	mux.HandleFunc("/api/lsp", apiHandler.LanguageServer)
 	mux.HandleFunc("/api/save-to-filesystem", apiHandler.SaveSubmissionToFilesystem)
 	mux.HandleFunc("/api/refresh-attempts", apiHandler.RefreshUserAttempts)
 	mux.HandleFunc("/api/git-username", apiHandler.GetGitUsername)
 	mux.HandleFunc("/api/main-scoreboard-rank", apiHandler.GetMainScoreboardRank)
 	mux.HandleFunc("/api/main-leaderboard", apiHandler.GetMainLeaderboard)
+	mux.HandleFunc("/api/auth/me", apiHandler.GetAuthStatus)
+
+	// GitHub login
+	mux.HandleFunc("/auth/login", apiHandler.Login)
+	mux.HandleFunc("/auth/callback", apiHandler.AuthCallback)
+	mux.HandleFunc("/auth/logout", apiHandler.Logout)
 
 	// Package challenge API routes
 	mux.HandleFunc("/api/package-leaderboard", apiHandler.GetPackageLeaderboard)
@@ -94,6 +130,9 @@ func (s *Server) SetupRoutes() *http.ServeMux {
 	mux.HandleFunc("/api/ai/code-hint", apiHandler.AICodeHint)
 	mux.HandleFunc("/api/ai/debug", apiHandler.AIDebugResponse)
 
+	// Admin routes
+	mux.HandleFunc("/api/admin/reload", adminHandler.Reload)
+
 	// GitHub webhook route
 	mux.HandleFunc("/webhook/github", apiHandler.GitHubWebhookHandler)
 
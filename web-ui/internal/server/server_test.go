@@ -0,0 +1,267 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"web-ui/internal/models"
+	"web-ui/internal/services"
+)
+
+// stressContent is a minimal repository: one classic challenge and one package
+var stressContent = map[string]string{
+	"challenge-1/README.md":     "# Challenge 1: Sum\n",
+	"challenge-1/go.mod":        "module challenge-1\n\ngo 1.21\n",
+	"challenge-1/metadata.json": `{"title": "Sum", "difficulty": "Beginner", "tags": ["basics"]}`,
+	"challenge-1/SCOREBOARD.md": "# Scoreboard for challenge-1\n| Username   | Passed Tests | Total Tests |\n|------------|--------------|-------------|\n| alice | 1 | 1 |\n",
+	"challenge-1/solution-template.go": `package main
+
+func main() {}
+
+// Sum returns the sum of a and b.
+func Sum(a, b int) int {
+	return 0
+}
+`,
+	"challenge-1/solution-template_test.go": `package main
+
+import "testing"
+
+func TestSum(t *testing.T) {
+	if got := Sum(2, 3); got != 5 {
+		t.Errorf("Sum(2, 3) = %d, want 5", got)
+	}
+}
+`,
+	"packages/demo/package.json":                            `{"name": "demo", "display_name": "Demo", "learning_path": ["challenge-1-basics"]}`,
+	"packages/demo/challenge-1-basics/README.md":            "# Basics\n",
+	"packages/demo/challenge-1-basics/metadata.json":        `{"title": "Basics", "difficulty": "Beginner"}`,
+	"packages/demo/challenge-1-basics/SCOREBOARD.md":        "# Scoreboard for demo challenge-1-basics\n",
+	"packages/demo/challenge-1-basics/solution-template.go": "package main\n",
+}
+
+const solvedSum = `package main
+
+func main() {}
+
+// Sum returns the sum of a and b.
+func Sum(a, b int) int {
+	return a + b
+}
+`
+
+// TestMain lets the test binary act as the sandbox launcher, as main does
+func TestMain(m *testing.M) {
+	services.RunSandboxLauncherIfRequested()
+	os.Exit(m.Run())
+}
+
+// stressServer is a test server with the services the test drives directly
+type stressServer struct {
+	*httptest.Server
+	root        string
+	scoreboards *services.ScoreboardService
+	packages    *services.PackageService
+	reloader    *services.ContentReloader
+}
+
+// newStressServer serves a copy of stressContent with every service the server runs with
+func newStressServer(t *testing.T) *stressServer {
+	t.Helper()
+	root := t.TempDir()
+	for name, content := range stressContent {
+		file := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("DATA_DIR", t.TempDir())
+	t.Setenv("AUTH_MODE", services.AuthModeDev)
+	t.Setenv("ADMIN_TOKEN", "stress")
+	t.Setenv("EXECUTION_MODCACHE", t.TempDir())
+	t.Setenv("EXECUTION_BUILDCACHE", t.TempDir())
+	t.Setenv("SANDBOX_ALLOW_UNISOLATED", "true") // Also run where namespaces are unavailable
+
+	contentRoot := services.NewContentRoot(root)
+	challengeService := services.NewChallengeService(contentRoot)
+	if err := challengeService.LoadChallenges(); err != nil {
+		t.Fatal(err)
+	}
+	scoreboardService := services.NewScoreboardService(contentRoot)
+	if err := scoreboardService.LoadScoreboards(challengeService.GetChallenges()); err != nil {
+		t.Fatal(err)
+	}
+	packageService := services.NewPackageService(contentRoot)
+	packageService.GetPackages()
+	submissionStore := services.NewFileSubmissionStore()
+	if err := submissionStore.Load(); err != nil {
+		t.Fatal(err)
+	}
+	userService := services.NewUserService(contentRoot)
+	reloader := services.NewContentReloader(contentRoot, challengeService, scoreboardService, packageService, userService)
+	// Runs start from the warmed build cache, as they do in the server
+	executionService := services.NewExecutionService(contentRoot)
+	executionService.WarmModuleCache(context.Background(), services.ModuleSourcesFor(challengeService.GetChallenges(), packageService))
+
+	srv := NewServer(
+		embed.FS{},
+		challengeService,
+		scoreboardService,
+		userService,
+		executionService,
+		packageService,
+		services.NewAIService(),
+		services.NewPerformanceService(),
+		submissionStore,
+		services.NewAuthService(),
+		contentRoot,
+		reloader,
+	)
+	server := httptest.NewServer(srv.SetupRoutes())
+	t.Cleanup(server.Close)
+	return &stressServer{server, root, scoreboardService, packageService, reloader}
+}
+
+// TestConcurrentRequests hammers the API from many goroutines while content
+// is reloaded and scoreboards change. Run it with -race.
+func TestConcurrentRequests(t *testing.T) {
+	if testing.Short() {
+		t.Skip("stress test")
+	}
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go is not installed")
+	}
+	server := newStressServer(t)
+
+	get := func(path string) error {
+		resp, err := http.Get(server.URL + path)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		io.Copy(io.Discard, resp.Body)
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("GET %s: %s", path, resp.Status)
+		}
+		return nil
+	}
+	submit := func(username string) error {
+		body, _ := json.Marshal(map[string]interface{}{"challengeId": 1, "username": username, "code": solvedSum})
+		resp, err := http.Post(server.URL+"/api/submissions", "application/json", bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		var submission models.Submission
+		if err := json.NewDecoder(resp.Body).Decode(&submission); err != nil {
+			return fmt.Errorf("submit as %s: %s: %v", username, resp.Status, err)
+		}
+		if !submission.Passed {
+			return fmt.Errorf("submit as %s did not pass:\n%s", username, submission.TestOutput)
+		}
+		return nil
+	}
+
+	const readers, rounds, submitters = 8, 25, 4
+	errs := make(chan error, readers*rounds+submitters+2)
+	var wg sync.WaitGroup
+
+	// Readers of every cached view. Packages are read from the service, as the
+	// leaderboards that show them also call GitHub for the sponsors.
+	for i := 0; i < readers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < rounds; j++ {
+				for _, path := range []string{"/api/challenges", "/api/challenges/1", "/api/scoreboard/1"} {
+					if err := get(path); err != nil {
+						errs <- err
+					}
+				}
+				for name, pkg := range server.packages.GetPackages() {
+					if pkg.Name != name || len(pkg.LearningPath) != 1 {
+						errs <- fmt.Errorf("package %s is incomplete: %+v", name, pkg)
+					}
+				}
+			}
+		}()
+	}
+
+	// Parallel submits, each adding a scoreboard entry
+	for i := 0; i < submitters; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if err := submit(fmt.Sprintf("user%d", i)); err != nil {
+				errs <- err
+			}
+		}(i)
+	}
+
+	// Direct scoreboard writes, content edits with polled reloads, and forced full reloads
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for j := 0; j < rounds; j++ {
+			server.scoreboards.AddSubmission(models.Submission{Username: fmt.Sprintf("direct%d", j), ChallengeID: 1, SubmittedAt: time.Now(), TestsPassed: 1, TestsTotal: 1})
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for j := 0; j < rounds; j++ {
+			title := fmt.Sprintf(`{"title": "Sum %d", "difficulty": "Beginner"}`, j)
+			os.WriteFile(filepath.Join(server.root, "challenge-1", "metadata.json"), []byte(title), 0644)
+			os.WriteFile(filepath.Join(server.root, "packages", "demo", "package.json"), []byte(`{"name": "demo", "display_name": "Demo `+fmt.Sprint(j)+`", "learning_path": ["challenge-1-basics"]}`), 0644)
+			server.reloader.Poll()
+			if j%5 == 0 {
+				req, _ := http.NewRequest("POST", server.URL+"/api/admin/reload", nil)
+				req.Header.Set("Authorization", "Bearer stress")
+				if resp, err := http.DefaultClient.Do(req); err != nil {
+					errs <- err
+				} else {
+					resp.Body.Close()
+				}
+			}
+		}
+	}()
+
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+
+	// Reloads keep the entries added since SCOREBOARD.md was loaded
+	scoreboard, _ := server.scoreboards.GetScoreboard(1)
+	usernames := make(map[string]bool, len(scoreboard))
+	for _, entry := range scoreboard {
+		usernames[entry.Username] = true
+	}
+	want := []string{"alice"}
+	for i := 0; i < submitters; i++ {
+		want = append(want, fmt.Sprintf("user%d", i))
+	}
+	for j := 0; j < rounds; j++ {
+		want = append(want, fmt.Sprintf("direct%d", j))
+	}
+	for _, username := range want {
+		if !usernames[username] {
+			t.Errorf("scoreboard of challenge 1 lost %s", username)
+		}
+	}
+}
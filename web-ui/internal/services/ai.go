@@ -395,7 +395,23 @@ BEGIN_CODE
 %s
 END_CODE
 
-Focus on: (1) correctness and edge cases, (2) Go idioms, (3) performance, (4) readability, (5) interviewer follow-ups.`, challenge.Title, context, code)
+STATIC ANALYSIS (deterministic findings from gofmt, goimports and the bundled analyzers; cite them by line instead of guessing at style issues):
+%s
+
+Focus on: (1) correctness and edge cases, (2) Go idioms, (3) performance, (4) readability, (5) interviewer follow-ups.`, challenge.Title, context, code, staticAnalysisSummary(code))
+}
+
+// staticAnalysisSummary lists the analysis findings of code for the review prompt
+func staticAnalysisSummary(code string) string {
+	report := AnalyzeFiles(map[string]string{MainSolutionFile: code})
+	if len(report.Diagnostics) == 0 {
+		return "none"
+	}
+	var summary strings.Builder
+	for _, diagnostic := range report.Diagnostics {
+		fmt.Fprintf(&summary, "- line %d [%s, %s]: %s\n", diagnostic.Line, diagnostic.Analyzer, diagnostic.Severity, diagnostic.Message)
+	}
+	return strings.TrimSuffix(summary.String(), "\n")
 }
 
 // buildQuestionPrompt creates the prompt for generating interview questions
@@ -0,0 +1,496 @@
+package services
+
+import (
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/importer"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"go/types"
+	"os/exec"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"web-ui/internal/models"
+)
+
+// Analyzers that run outside the bundled go/analysis style checks
+const (
+	analyzerSyntax    = "syntax"
+	analyzerGofmt     = "gofmt"
+	analyzerGoimports = "goimports"
+	analyzerVet       = "vet"
+)
+
+// Most syntax errors reported per file; later ones are usually follow-on errors
+const maxSyntaxErrors = 10
+
+// Type checker messages that goimports can fix
+var (
+	unusedImportRe  = regexp.MustCompile(`^"([^"]+)" imported (?:as \S+ )?and not used`)
+	undefinedNameRe = regexp.MustCompile(`^undefined: (\w+)$`)
+	couldNotImport  = regexp.MustCompile(`^could not import `)
+	majorVersionRe  = regexp.MustCompile(`^v\d+$`)
+)
+
+// sharedImporter loads packages for type checking from their export data. It is
+// shared so each standard library package is loaded once per process.
+var sharedImporter = &lockedImporter{importer: importer.ForCompiler(token.NewFileSet(), "gc", nil)}
+
+// lockedImporter serializes imports, go/importer is not safe for concurrent use
+type lockedImporter struct {
+	mutex    sync.Mutex
+	importer types.Importer
+}
+
+// Import implements types.Importer
+func (li *lockedImporter) Import(path string) (*types.Package, error) {
+	li.mutex.Lock()
+	defer li.mutex.Unlock()
+	return li.importer.Import(path)
+}
+
+// Standard library packages by name, listed once with `go list std`
+var (
+	stdlibOnce     sync.Once
+	stdlibPackages map[string][]string
+)
+
+// AnalyzeFiles runs the in-process static analysis of a submission: gofmt,
+// goimports and the bundled analyzers. go vet needs a workspace and is added
+// by the execution service.
+func AnalyzeFiles(files map[string]string) *models.AnalysisReport {
+	report := &models.AnalysisReport{Diagnostics: []models.Diagnostic{}}
+
+	fset := token.NewFileSet()
+	parsed, syntaxErrors := parseFiles(fset, files)
+	if len(syntaxErrors) > 0 {
+		report.Diagnostics = syntaxErrors
+		report.Skipped = append([]string{analyzerGofmt, analyzerGoimports}, bundledAnalyzerNames()...)
+		return report
+	}
+
+	for _, name := range SortedFileNames(files) {
+		formatted, err := format.Source([]byte(files[name]))
+		if err == nil && string(formatted) != files[name] {
+			position := token.Position{Filename: name, Line: firstDifferentLine(files[name], string(formatted))}
+			report.Diagnostics = append(report.Diagnostics, newDiagnostic(position, analyzerGofmt, models.SeverityInfo, "file is not gofmt-formatted"))
+		}
+	}
+
+	pkg, info, typeErrors := typeCheck(fset, parsed)
+	fixes, complete := importFixes(fset, parsed, typeErrors)
+	for _, file := range parsed {
+		fix := fixes[file]
+		if fix == nil {
+			continue
+		}
+		for _, spec := range fix.unused {
+			report.Diagnostics = append(report.Diagnostics, newDiagnostic(fset.Position(spec.Pos()), analyzerGoimports, models.SeverityError,
+				fmt.Sprintf("%s imported and not used", spec.Path.Value)))
+		}
+		for _, importPath := range sortedKeys(fix.missing) {
+			report.Diagnostics = append(report.Diagnostics, newDiagnostic(fset.Position(fix.missing[importPath]), analyzerGoimports, models.SeverityError,
+				fmt.Sprintf("%s is not imported: add import %q", path.Base(importPath), importPath)))
+		}
+	}
+
+	// Analyzers need complete type information to avoid false positives
+	if complete {
+		pass := &analysisPass{fset: fset, files: parsed, pkg: pkg, info: info}
+		for _, analyzer := range bundledAnalyzers {
+			pass.analyzer = analyzer.name
+			analyzer.run(pass)
+		}
+		report.Diagnostics = append(report.Diagnostics, pass.diagnostics...)
+	} else {
+		report.Skipped = bundledAnalyzerNames()
+	}
+
+	if formatted, errs := FormatFiles(files); len(errs) == 0 {
+		for name, content := range formatted {
+			if content != files[name] {
+				if report.Formatted == nil {
+					report.Formatted = make(map[string]string)
+				}
+				report.Formatted[name] = content
+			}
+		}
+	}
+
+	sortDiagnostics(report.Diagnostics)
+	return report
+}
+
+// FormatFiles formats the files of a package like gofmt and goimports: unused
+// imports are removed and missing standard library imports added. Files are
+// returned unchanged when the package has syntax errors, which are reported instead.
+func FormatFiles(files map[string]string) (map[string]string, []models.Diagnostic) {
+	fset := token.NewFileSet()
+	if _, syntaxErrors := parseFiles(fset, files); len(syntaxErrors) > 0 {
+		return files, syntaxErrors
+	}
+
+	formatted := make(map[string]string, len(files))
+	for name, content := range files {
+		source, err := format.Source([]byte(content))
+		if err != nil {
+			return files, []models.Diagnostic{newDiagnostic(token.Position{Filename: name, Line: 1}, analyzerSyntax, models.SeverityError, err.Error())}
+		}
+		formatted[name] = string(source)
+	}
+
+	// Imports are fixed on the gofmt output, where every import spec is on its own line
+	fset = token.NewFileSet()
+	parsed, _ := parseFiles(fset, formatted)
+	_, _, typeErrors := typeCheck(fset, parsed)
+	fixes, _ := importFixes(fset, parsed, typeErrors)
+	for _, file := range parsed {
+		fix := fixes[file]
+		if fix == nil {
+			continue
+		}
+		name := fset.Position(file.Package).Filename
+		if source, err := format.Source([]byte(applyImportFix(fset, file, formatted[name], fix))); err == nil {
+			formatted[name] = string(source)
+		}
+	}
+	return formatted, nil
+}
+
+// parseFiles parses the files in name order, returning their syntax errors
+func parseFiles(fset *token.FileSet, files map[string]string) ([]*ast.File, []models.Diagnostic) {
+	var parsed []*ast.File
+	var syntaxErrors []models.Diagnostic
+	for _, name := range SortedFileNames(files) {
+		file, err := parser.ParseFile(fset, name, files[name], parser.ParseComments)
+		if err != nil {
+			if list, ok := err.(scanner.ErrorList); ok {
+				for i, e := range list {
+					if i == maxSyntaxErrors {
+						break
+					}
+					syntaxErrors = append(syntaxErrors, newDiagnostic(e.Pos, analyzerSyntax, models.SeverityError, e.Msg))
+				}
+			} else {
+				syntaxErrors = append(syntaxErrors, newDiagnostic(token.Position{Filename: name, Line: 1}, analyzerSyntax, models.SeverityError, err.Error()))
+			}
+			continue
+		}
+		parsed = append(parsed, file)
+	}
+	return parsed, syntaxErrors
+}
+
+// typeCheck type-checks the files of a package, collecting every error
+func typeCheck(fset *token.FileSet, files []*ast.File) (*types.Package, *types.Info, []types.Error) {
+	var typeErrors []types.Error
+	config := types.Config{
+		Importer: sharedImporter,
+		Error: func(err error) {
+			if typeErr, ok := err.(types.Error); ok {
+				typeErrors = append(typeErrors, typeErr)
+			}
+		},
+	}
+	info := &types.Info{
+		Types:      make(map[ast.Expr]types.TypeAndValue),
+		Defs:       make(map[*ast.Ident]types.Object),
+		Uses:       make(map[*ast.Ident]types.Object),
+		Selections: make(map[*ast.SelectorExpr]*types.Selection),
+		Scopes:     make(map[ast.Node]*types.Scope),
+	}
+	name := "main"
+	if len(files) > 0 {
+		name = files[0].Name.Name
+	}
+	pkg, _ := config.Check(name, fset, files, info)
+	return pkg, info, typeErrors
+}
+
+// importFix is what goimports changes in one file
+type importFix struct {
+	unused  []*ast.ImportSpec
+	missing map[string]token.Pos // Import path to its first use
+}
+
+// importFixes derives goimports fixes from the type errors of a package. It
+// also reports whether the type information is otherwise complete, i.e. every
+// error is an import problem.
+func importFixes(fset *token.FileSet, files []*ast.File, typeErrors []types.Error) (map[*ast.File]*importFix, bool) {
+	byName := make(map[string]*ast.File, len(files))
+	for _, file := range files {
+		byName[fset.Position(file.Package).Filename] = file
+	}
+
+	fixes := make(map[*ast.File]*importFix)
+	fixFor := func(file *ast.File) *importFix {
+		if fixes[file] == nil {
+			fixes[file] = &importFix{missing: make(map[string]token.Pos)}
+		}
+		return fixes[file]
+	}
+
+	complete := true
+	for _, typeErr := range typeErrors {
+		file := byName[fset.Position(typeErr.Pos).Filename]
+		if file == nil {
+			complete = false
+			continue
+		}
+
+		if match := unusedImportRe.FindStringSubmatch(typeErr.Msg); match != nil {
+			if spec := importSpecAt(file, typeErr.Pos); spec != nil {
+				fixFor(file).unused = append(fixFor(file).unused, spec)
+				continue
+			}
+		}
+		if match := undefinedNameRe.FindStringSubmatch(typeErr.Msg); match != nil {
+			if importPath := resolveStandardImport(match[1], selectorsOf(file, match[1])); importPath != "" {
+				if pos, ok := fixFor(file).missing[importPath]; !ok || typeErr.Pos < pos {
+					fixFor(file).missing[importPath] = typeErr.Pos
+				}
+				continue
+			}
+		}
+		if couldNotImport.MatchString(typeErr.Msg) {
+			// Third-party packages are not type-checked; their uses are left alone
+			continue
+		}
+		complete = false
+	}
+	return fixes, complete
+}
+
+// importSpecAt returns the import spec at pos
+func importSpecAt(file *ast.File, pos token.Pos) *ast.ImportSpec {
+	for _, spec := range file.Imports {
+		if spec.Pos() <= pos && pos <= spec.End() {
+			return spec
+		}
+	}
+	return nil
+}
+
+// selectorsOf returns the selectors used on name in file, e.g. ToUpper for strings.ToUpper
+func selectorsOf(file *ast.File, name string) []string {
+	var selectors []string
+	ast.Inspect(file, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok {
+			if ident, ok := sel.X.(*ast.Ident); ok && ident.Name == name {
+				selectors = append(selectors, sel.Sel.Name)
+			}
+		}
+		return true
+	})
+	return selectors
+}
+
+// resolveStandardImport returns the standard library package named name that
+// declares all selectors, preferring the shortest import path
+func resolveStandardImport(name string, selectors []string) string {
+	if len(selectors) == 0 {
+		return ""
+	}
+	for _, importPath := range standardPackages()[name] {
+		pkg, err := sharedImporter.Import(importPath)
+		if err != nil || pkg.Name() != name {
+			continue
+		}
+		declaresAll := true
+		for _, selector := range selectors {
+			if obj := pkg.Scope().Lookup(selector); obj == nil || !obj.Exported() {
+				declaresAll = false
+				break
+			}
+		}
+		if declaresAll {
+			return importPath
+		}
+	}
+	return ""
+}
+
+// standardPackages returns the importable standard library packages by name
+func standardPackages() map[string][]string {
+	stdlibOnce.Do(func() {
+		stdlibPackages = make(map[string][]string)
+		output, err := exec.Command("go", "list", "std").Output()
+		if err != nil {
+			fmt.Printf("Warning: could not list the standard library for import fixes: %v\n", err)
+			return
+		}
+		for _, importPath := range strings.Fields(string(output)) {
+			if strings.HasPrefix(importPath, "vendor/") || strings.Contains("/"+importPath+"/", "/internal/") {
+				continue
+			}
+			name := path.Base(importPath)
+			if majorVersionRe.MatchString(name) {
+				name = path.Base(path.Dir(importPath)) // math/rand/v2 is package rand
+			}
+			stdlibPackages[name] = append(stdlibPackages[name], importPath)
+		}
+		for _, paths := range stdlibPackages {
+			sort.SliceStable(paths, func(i, j int) bool { return len(paths[i]) < len(paths[j]) })
+		}
+	})
+	return stdlibPackages
+}
+
+// applyImportFix removes and adds import lines of a gofmt-formatted file
+func applyImportFix(fset *token.FileSet, file *ast.File, source string, fix *importFix) string {
+	type edit struct {
+		start, end int
+		text       string
+	}
+	var edits []edit
+	offset := func(pos token.Pos) int { return fset.Position(pos).Offset }
+	lineStart := func(off int) int { return strings.LastIndex(source[:off], "\n") + 1 }
+	lineEnd := func(off int) int {
+		if i := strings.Index(source[off:], "\n"); i >= 0 {
+			return off + i + 1
+		}
+		return len(source)
+	}
+
+	unused := make(map[ast.Spec]bool, len(fix.unused))
+	for _, spec := range fix.unused {
+		unused[spec] = true
+	}
+	var groupedImports, singleImport *ast.GenDecl
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.IMPORT {
+			continue
+		}
+
+		kept := 0
+		for _, spec := range gen.Specs {
+			if !unused[spec] {
+				kept++
+			}
+		}
+		if kept == 0 {
+			start := gen.Pos()
+			if gen.Doc != nil {
+				start = gen.Doc.Pos()
+			}
+			edits = append(edits, edit{lineStart(offset(start)), lineEnd(offset(gen.End())), ""})
+			continue
+		}
+		if gen.Lparen.IsValid() && groupedImports == nil {
+			groupedImports = gen
+		} else if !gen.Lparen.IsValid() && singleImport == nil {
+			singleImport = gen
+		}
+		for _, spec := range gen.Specs {
+			if !unused[spec] {
+				continue
+			}
+			importSpec := spec.(*ast.ImportSpec)
+			start, end := importSpec.Pos(), importSpec.End()
+			if importSpec.Doc != nil {
+				start = importSpec.Doc.Pos()
+			}
+			if importSpec.Comment != nil {
+				end = importSpec.Comment.End()
+			}
+			edits = append(edits, edit{lineStart(offset(start)), lineEnd(offset(end)), ""})
+		}
+	}
+
+	if len(fix.missing) > 0 {
+		var lines strings.Builder
+		for _, importPath := range sortedKeys(fix.missing) {
+			lines.WriteString("\t" + strconv.Quote(importPath) + "\n")
+		}
+		if groupedImports != nil {
+			at := lineEnd(offset(groupedImports.Lparen))
+			edits = append(edits, edit{at, at, lines.String()})
+		} else if singleImport != nil {
+			// import "fmt" becomes a group so that gofmt sorts the added paths into it
+			spec := singleImport.Specs[0]
+			start, end := offset(singleImport.Pos()), offset(spec.End())
+			existing := source[offset(spec.Pos()):end]
+			edits = append(edits, edit{start, end, "import (\n\t" + existing + "\n" + lines.String() + ")"})
+		} else {
+			at := lineEnd(offset(file.Name.End()))
+			edits = append(edits, edit{at, at, "\nimport (\n" + lines.String() + ")\n"})
+		}
+	}
+
+	// Apply from the end so earlier offsets stay valid; removals before insertions at the same offset
+	sort.SliceStable(edits, func(i, j int) bool {
+		if edits[i].start != edits[j].start {
+			return edits[i].start > edits[j].start
+		}
+		return edits[i].end > edits[j].end
+	})
+	for _, e := range edits {
+		source = source[:e.start] + e.text + source[e.end:]
+	}
+	return source
+}
+
+// MergeVetDiagnostics adds go vet findings in the analyzed files to a report,
+// skipping lines a bundled analyzer already reported
+func MergeVetDiagnostics(report *models.AnalysisReport, issues []models.SourceLocation, files map[string]string) {
+	reported := make(map[string]bool)
+	for _, diagnostic := range report.Diagnostics {
+		reported[fmt.Sprintf("%s:%d", diagnostic.File, diagnostic.Line)] = true
+	}
+	for _, issue := range issues {
+		if _, ok := files[issue.File]; !ok || reported[fmt.Sprintf("%s:%d", issue.File, issue.Line)] {
+			continue
+		}
+		report.Diagnostics = append(report.Diagnostics, models.Diagnostic{SourceLocation: issue, Analyzer: analyzerVet, Severity: models.SeverityWarning})
+	}
+	sortDiagnostics(report.Diagnostics)
+}
+
+func newDiagnostic(position token.Position, analyzer, severity, message string) models.Diagnostic {
+	return models.Diagnostic{
+		SourceLocation: models.SourceLocation{File: position.Filename, Line: position.Line, Column: position.Column, Message: message},
+		Analyzer:       analyzer,
+		Severity:       severity,
+	}
+}
+
+func sortDiagnostics(diagnostics []models.Diagnostic) {
+	sort.SliceStable(diagnostics, func(i, j int) bool {
+		a, b := diagnostics[i], diagnostics[j]
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+}
+
+// firstDifferentLine returns the 1-based number of the first line that differs between a and b
+func firstDifferentLine(a, b string) int {
+	linesA, linesB := strings.Split(a, "\n"), strings.Split(b, "\n")
+	for i := range linesA {
+		if i >= len(linesB) || linesA[i] != linesB[i] {
+			return i + 1
+		}
+	}
+	return len(linesA)
+}
+
+func sortedKeys(m map[string]token.Pos) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
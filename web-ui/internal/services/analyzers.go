@@ -0,0 +1,541 @@
+package services
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+
+	"web-ui/internal/models"
+)
+
+// analysisPass is the type-checked submission a bundled analyzer inspects
+type analysisPass struct {
+	fset        *token.FileSet
+	files       []*ast.File
+	pkg         *types.Package
+	info        *types.Info
+	analyzer    string // Name of the running analyzer
+	diagnostics []models.Diagnostic
+}
+
+// reportf records a finding of the running analyzer
+func (pass *analysisPass) reportf(pos token.Pos, format string, args ...interface{}) {
+	pass.diagnostics = append(pass.diagnostics, newDiagnostic(pass.fset.Position(pos), pass.analyzer, models.SeverityWarning, fmt.Sprintf(format, args...)))
+}
+
+// bundledAnalyzer is a go/analysis style check of the type-checked submission
+type bundledAnalyzer struct {
+	name string
+	run  func(pass *analysisPass)
+}
+
+// bundledAnalyzers mirror the shadow, errcheck, ineffassign and copylocks tools
+var bundledAnalyzers = []bundledAnalyzer{
+	{"shadow", runShadow},
+	{"errcheck", runErrcheck},
+	{"ineffassign", runIneffassign},
+	{"copylocks", runCopylocks},
+}
+
+func bundledAnalyzerNames() []string {
+	names := make([]string, len(bundledAnalyzers))
+	for i, analyzer := range bundledAnalyzers {
+		names[i] = analyzer.name
+	}
+	return names
+}
+
+// runShadow reports variables declared with := or var that shadow a variable
+// of the same type from an enclosing function scope which is used afterwards
+func runShadow(pass *analysisPass) {
+	lastUse := make(map[types.Object]token.Pos)
+	for ident, obj := range pass.info.Uses {
+		if ident.Pos() > lastUse[obj] {
+			lastUse[obj] = ident.Pos()
+		}
+	}
+
+	check := func(ident *ast.Ident) {
+		obj, ok := pass.info.Defs[ident].(*types.Var)
+		if !ok || ident.Name == "_" || obj.Parent() == nil || obj.Parent().Parent() == nil {
+			return
+		}
+		_, shadowed := obj.Parent().Parent().LookupParent(ident.Name, ident.Pos())
+		outer, ok := shadowed.(*types.Var)
+		if !ok || outer.Parent() == pass.pkg.Scope() || outer.Parent() == types.Universe {
+			return
+		}
+		if !types.Identical(outer.Type(), obj.Type()) || outer.Type() == types.Typ[types.Invalid] {
+			return
+		}
+		// Shadowing is only confusing when the outer variable is used again
+		if lastUse[outer] <= ident.Pos() {
+			return
+		}
+		pass.reportf(ident.Pos(), "declaration of %q shadows declaration at line %d", ident.Name, pass.fset.Position(outer.Pos()).Line)
+	}
+
+	for _, file := range pass.files {
+		ast.Inspect(file, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.AssignStmt:
+				if n.Tok != token.DEFINE {
+					return true
+				}
+				for i, lhs := range n.Lhs {
+					ident, ok := lhs.(*ast.Ident)
+					if !ok {
+						continue
+					}
+					// x := x copies on purpose, e.g. for a closure
+					if len(n.Lhs) == len(n.Rhs) {
+						if rhs, ok := n.Rhs[i].(*ast.Ident); ok && rhs.Name == ident.Name {
+							continue
+						}
+					}
+					check(ident)
+				}
+			case *ast.GenDecl:
+				if n.Tok != token.VAR {
+					return true
+				}
+				for _, spec := range n.Specs {
+					for _, name := range spec.(*ast.ValueSpec).Names {
+						check(name)
+					}
+				}
+			}
+			return true
+		})
+	}
+}
+
+// errorType is the predeclared error interface
+var errorType = types.Universe.Lookup("error").Type()
+
+// Calls whose error result is conventionally ignored
+var uncheckedErrorAllowed = map[string]bool{
+	"fmt.Print":                      true,
+	"fmt.Printf":                     true,
+	"fmt.Println":                    true,
+	"fmt.Fprint":                     true,
+	"fmt.Fprintf":                    true,
+	"fmt.Fprintln":                   true,
+	"(*bytes.Buffer).Write":          true,
+	"(*bytes.Buffer).WriteByte":      true,
+	"(*bytes.Buffer).WriteRune":      true,
+	"(*bytes.Buffer).WriteString":    true,
+	"(*strings.Builder).Write":       true,
+	"(*strings.Builder).WriteByte":   true,
+	"(*strings.Builder).WriteRune":   true,
+	"(*strings.Builder).WriteString": true,
+	"math/rand.Read":                 true,
+	"(*math/rand.Rand).Read":         true,
+}
+
+// runErrcheck reports calls whose error result is silently dropped.
+// Assigning it to _ is an explicit decision and is not reported.
+func runErrcheck(pass *analysisPass) {
+	for _, file := range pass.files {
+		ast.Inspect(file, func(n ast.Node) bool {
+			stmt, ok := n.(*ast.ExprStmt)
+			if !ok {
+				return true
+			}
+			call, ok := unparen(stmt.X).(*ast.CallExpr)
+			if !ok || !returnsError(pass.info, call) {
+				return true
+			}
+			if name := calleeName(pass.info, call); !uncheckedErrorAllowed[name] {
+				pass.reportf(call.Pos(), "error returned by %s is not checked", name)
+			}
+			return true
+		})
+	}
+}
+
+// returnsError reports whether the last result of a call is an error
+func returnsError(info *types.Info, call *ast.CallExpr) bool {
+	tv, ok := info.Types[call.Fun]
+	if !ok || tv.IsType() || tv.IsBuiltin() {
+		return false
+	}
+	signature, ok := tv.Type.Underlying().(*types.Signature)
+	if !ok {
+		return false
+	}
+	results := signature.Results()
+	return results.Len() > 0 && types.Identical(results.At(results.Len()-1).Type(), errorType)
+}
+
+// calleeName returns the qualified name of the called function, e.g. "(*os.File).Close"
+func calleeName(info *types.Info, call *ast.CallExpr) string {
+	var obj types.Object
+	switch fun := unparen(call.Fun).(type) {
+	case *ast.Ident:
+		obj = info.Uses[fun]
+	case *ast.SelectorExpr:
+		obj = info.Uses[fun.Sel]
+	}
+	if fn, ok := obj.(*types.Func); ok {
+		return fn.FullName()
+	}
+	return types.ExprString(call.Fun)
+}
+
+// runIneffassign reports assignments to local variables whose value is never
+// read: the variable is assigned again before any use, or not used at all
+// afterwards. Variables captured by closures or whose address is taken are
+// skipped, as are functions with goto.
+func runIneffassign(pass *analysisPass) {
+	for _, file := range pass.files {
+		ast.Inspect(file, func(n ast.Node) bool {
+			switch fn := n.(type) {
+			case *ast.FuncDecl:
+				if fn.Body != nil {
+					checkIneffectualAssignments(pass, fn.Type, fn.Body)
+				}
+			case *ast.FuncLit:
+				checkIneffectualAssignments(pass, fn.Type, fn.Body)
+			}
+			return true
+		})
+	}
+}
+
+// checkIneffectualAssignments runs the ineffassign check on one function body
+func checkIneffectualAssignments(pass *analysisPass, funcType *ast.FuncType, body *ast.BlockStmt) {
+	variableOf := func(ident *ast.Ident) *types.Var {
+		obj := pass.info.Uses[ident]
+		if obj == nil {
+			obj = pass.info.Defs[ident]
+		}
+		v, _ := obj.(*types.Var)
+		return v
+	}
+
+	mentions := make(map[*types.Var][]token.Pos)
+	skipped := make(map[*types.Var]bool)
+	hasGoto := false
+	inspectBody(body, func(n ast.Node) {
+		switch n := n.(type) {
+		case *ast.Ident:
+			if v := variableOf(n); v != nil {
+				mentions[v] = append(mentions[v], n.Pos())
+			}
+		case *ast.FuncLit:
+			// Closures may read the variable at any time
+			ast.Inspect(n, func(inner ast.Node) bool {
+				if ident, ok := inner.(*ast.Ident); ok {
+					if v := variableOf(ident); v != nil {
+						skipped[v] = true
+					}
+				}
+				return true
+			})
+		case *ast.UnaryExpr:
+			if ident, ok := unparen(n.X).(*ast.Ident); ok && n.Op == token.AND {
+				if v := variableOf(ident); v != nil {
+					skipped[v] = true
+				}
+			}
+		case *ast.SelectorExpr:
+			// x.M() takes x's address implicitly when M has a pointer receiver
+			if ident, ok := unparen(n.X).(*ast.Ident); ok {
+				if selection, ok := pass.info.Selections[n]; ok && selection.Kind() == types.MethodVal {
+					if _, isPointer := selection.Recv().(*types.Pointer); !isPointer {
+						if v := variableOf(ident); v != nil {
+							skipped[v] = true
+						}
+					}
+				}
+			}
+		case *ast.BranchStmt:
+			if n.Tok == token.GOTO {
+				hasGoto = true
+			}
+		}
+	})
+	if hasGoto {
+		return
+	}
+	// Named results are read by every return
+	if funcType.Results != nil {
+		for _, field := range funcType.Results.List {
+			for _, name := range field.Names {
+				if v := variableOf(name); v != nil {
+					skipped[v] = true
+				}
+			}
+		}
+	}
+
+	mentionedIn := func(node ast.Node, v *types.Var) int {
+		count := 0
+		inspectBody(node, func(n ast.Node) {
+			if ident, ok := n.(*ast.Ident); ok && variableOf(ident) == v {
+				count++
+			}
+		})
+		return count
+	}
+	// overwrites reports whether stmt assigns v without reading it first
+	overwrites := func(stmt ast.Stmt, v *types.Var) bool {
+		assign, ok := stmt.(*ast.AssignStmt)
+		if !ok || (assign.Tok != token.ASSIGN && assign.Tok != token.DEFINE) || mentionedIn(assign, v) != 1 {
+			return false
+		}
+		for _, lhs := range assign.Lhs {
+			if ident, ok := lhs.(*ast.Ident); ok && variableOf(ident) == v {
+				return true
+			}
+		}
+		return false
+	}
+	ineffectual := func(v *types.Var, stmt ast.Stmt, rest []ast.Stmt, inLoop bool) bool {
+		for i, next := range rest {
+			if mentionedIn(next, v) == 0 {
+				continue
+			}
+			// break and continue could reach a read elsewhere in the loop
+			for _, between := range rest[:i] {
+				if containsBranch(between) {
+					return false
+				}
+			}
+			return overwrites(next, v)
+		}
+		if inLoop {
+			return false
+		}
+		for _, pos := range mentions[v] {
+			if pos > stmt.End() {
+				return false
+			}
+		}
+		return true
+	}
+
+	var walk func(stmts []ast.Stmt, inLoop bool)
+	walk = func(stmts []ast.Stmt, inLoop bool) {
+		for i, stmt := range stmts {
+			for _, ident := range assignedIdents(stmt) {
+				v := variableOf(ident)
+				if v == nil || skipped[v] || v.Parent() == pass.pkg.Scope() || v.Pos() < funcType.Pos() {
+					continue
+				}
+				if ineffectual(v, stmt, stmts[i+1:], inLoop) {
+					pass.reportf(ident.Pos(), "ineffectual assignment to %s", ident.Name)
+				}
+			}
+
+			switch s := stmt.(type) {
+			case *ast.BlockStmt:
+				walk(s.List, inLoop)
+			case *ast.IfStmt:
+				walk(s.Body.List, inLoop)
+				if s.Else != nil {
+					walk([]ast.Stmt{s.Else}, inLoop)
+				}
+			case *ast.ForStmt:
+				walk(s.Body.List, true)
+			case *ast.RangeStmt:
+				walk(s.Body.List, true)
+			case *ast.SwitchStmt:
+				walkClauses(s.Body, inLoop, walk)
+			case *ast.TypeSwitchStmt:
+				walkClauses(s.Body, inLoop, walk)
+			case *ast.SelectStmt:
+				walkClauses(s.Body, inLoop, walk)
+			case *ast.LabeledStmt:
+				walk([]ast.Stmt{s.Stmt}, inLoop)
+			}
+		}
+	}
+	walk(body.List, false)
+}
+
+// walkClauses walks the bodies of switch and select clauses
+func walkClauses(body *ast.BlockStmt, inLoop bool, walk func([]ast.Stmt, bool)) {
+	for _, clause := range body.List {
+		switch c := clause.(type) {
+		case *ast.CaseClause:
+			walk(c.Body, inLoop)
+		case *ast.CommClause:
+			walk(c.Body, inLoop)
+		}
+	}
+}
+
+// assignedIdents returns the variables a statement assigns to
+func assignedIdents(stmt ast.Stmt) []*ast.Ident {
+	var idents []*ast.Ident
+	switch s := stmt.(type) {
+	case *ast.AssignStmt:
+		for _, lhs := range s.Lhs {
+			if ident, ok := lhs.(*ast.Ident); ok && ident.Name != "_" {
+				idents = append(idents, ident)
+			}
+		}
+	case *ast.IncDecStmt:
+		if ident, ok := unparen(s.X).(*ast.Ident); ok {
+			idents = append(idents, ident)
+		}
+	}
+	return idents
+}
+
+// containsBranch reports whether a statement contains break, continue or goto
+func containsBranch(stmt ast.Stmt) bool {
+	found := false
+	inspectBody(stmt, func(n ast.Node) {
+		if branch, ok := n.(*ast.BranchStmt); ok && branch.Tok != token.FALLTHROUGH {
+			found = true
+		}
+	})
+	return found
+}
+
+// inspectBody calls fn for every node of root, not descending into function literals
+func inspectBody(root ast.Node, fn func(ast.Node)) {
+	ast.Inspect(root, func(n ast.Node) bool {
+		if n == nil {
+			return false
+		}
+		fn(n)
+		_, isFuncLit := n.(*ast.FuncLit)
+		return !isFuncLit || n == root
+	})
+}
+
+// lockerType is sync.Locker: a type whose pointer implements it but whose value
+// does not must not be copied
+var lockerType = func() *types.Interface {
+	signature := types.NewSignatureType(nil, nil, nil, nil, nil, false)
+	methods := []*types.Func{
+		types.NewFunc(token.NoPos, nil, "Lock", signature),
+		types.NewFunc(token.NoPos, nil, "Unlock", signature),
+	}
+	return types.NewInterfaceType(methods, nil).Complete()
+}()
+
+// runCopylocks reports values containing a sync.Mutex or similar lock that are
+// copied: passed or returned by value, assigned, or used as a range variable
+func runCopylocks(pass *analysisPass) {
+	checkFields := func(name string, fields *ast.FieldList) {
+		if fields == nil {
+			return
+		}
+		for _, field := range fields.List {
+			if path := lockPath(pass.info.TypeOf(field.Type)); path != "" {
+				pass.reportf(field.Type.Pos(), "%s passes lock by value: %s", name, path)
+			}
+		}
+	}
+
+	for _, file := range pass.files {
+		ast.Inspect(file, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.FuncDecl:
+				checkFields(n.Name.Name, n.Recv)
+				checkFields(n.Name.Name, n.Type.Params)
+			case *ast.FuncLit:
+				checkFields("func", n.Type.Params)
+			case *ast.AssignStmt:
+				for i, rhs := range n.Rhs {
+					if path := copiedLockPath(pass.info, rhs); path != "" && len(n.Lhs) == len(n.Rhs) {
+						pass.reportf(rhs.Pos(), "assignment copies lock value to %s: %s", types.ExprString(n.Lhs[i]), path)
+					}
+				}
+			case *ast.ValueSpec:
+				for i, value := range n.Values {
+					if path := copiedLockPath(pass.info, value); path != "" && len(n.Names) == len(n.Values) {
+						pass.reportf(value.Pos(), "variable declaration copies lock value to %s: %s", n.Names[i].Name, path)
+					}
+				}
+			case *ast.RangeStmt:
+				if n.Value != nil {
+					if path := lockPath(pass.info.TypeOf(n.Value)); path != "" {
+						pass.reportf(n.Value.Pos(), "range var %s copies lock: %s", types.ExprString(n.Value), path)
+					}
+				}
+			case *ast.ReturnStmt:
+				for _, result := range n.Results {
+					if path := copiedLockPath(pass.info, result); path != "" {
+						pass.reportf(result.Pos(), "return copies lock value: %s", path)
+					}
+				}
+			case *ast.CallExpr:
+				if tv, ok := pass.info.Types[n.Fun]; ok && (tv.IsType() || tv.IsBuiltin()) {
+					return true
+				}
+				for _, arg := range n.Args {
+					if path := copiedLockPath(pass.info, arg); path != "" {
+						pass.reportf(arg.Pos(), "call of %s copies lock value: %s", types.ExprString(n.Fun), path)
+					}
+				}
+			}
+			return true
+		})
+	}
+}
+
+// copiedLockPath returns the lock an expression copies. Composite literals and
+// call results are new values, not copies.
+func copiedLockPath(info *types.Info, expr ast.Expr) string {
+	switch unparen(expr).(type) {
+	case *ast.CompositeLit, *ast.CallExpr, *ast.FuncLit, *ast.BasicLit:
+		return ""
+	}
+	return lockPath(info.TypeOf(expr))
+}
+
+// lockPath describes the lock contained in a type, e.g. "main.Counter contains sync.Mutex", or ""
+func lockPath(typ types.Type) string {
+	return lockPathSeen(typ, make(map[types.Type]bool))
+}
+
+func lockPathSeen(typ types.Type, seen map[types.Type]bool) string {
+	if typ == nil || seen[typ] {
+		return ""
+	}
+	seen[typ] = true
+	for {
+		array, ok := typ.Underlying().(*types.Array)
+		if !ok {
+			break
+		}
+		typ = array.Elem()
+	}
+	if _, ok := typ.(*types.TypeParam); ok || typ == types.Typ[types.Invalid] {
+		return ""
+	}
+
+	qualifier := func(pkg *types.Package) string { return pkg.Name() }
+	if types.Implements(types.NewPointer(typ), lockerType) && !types.Implements(typ, lockerType) {
+		return types.TypeString(typ, qualifier)
+	}
+
+	structType, ok := typ.Underlying().(*types.Struct)
+	if !ok {
+		return ""
+	}
+	for i := 0; i < structType.NumFields(); i++ {
+		if path := lockPathSeen(structType.Field(i).Type(), seen); path != "" {
+			return types.TypeString(typ, qualifier) + " contains " + path
+		}
+	}
+	return ""
+}
+
+// unparen removes any parentheses around an expression
+func unparen(expr ast.Expr) ast.Expr {
+	for {
+		paren, ok := expr.(*ast.ParenExpr)
+		if !ok {
+			return expr
+		}
+		expr = paren.X
+	}
+}
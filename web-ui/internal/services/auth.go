@@ -0,0 +1,513 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"web-ui/internal/models"
+)
+
+// How users are identified
+const (
+	// AuthModeGitHub signs users in with GitHub OAuth into server-side sessions
+	AuthModeGitHub = "github"
+	// AuthModeDev trusts the username the browser sends, from this machine only
+	AuthModeDev = "dev"
+)
+
+const (
+	sessionCookieName     = "session"
+	oauthStateCookieName  = "oauth_state"
+	devUsernameCookieName = "username"
+	sessionLifetime       = 30 * 24 * time.Hour
+	oauthStateLifetime    = 10 * time.Minute
+)
+
+// AuthService identifies the user behind a request. With GitHub OAuth
+// configured users sign in with GitHub and get a server-side session behind a
+// signed, HttpOnly cookie. Without it the server runs in dev mode and trusts the
+// plain username cookie, but only from loopback clients.
+type AuthService struct {
+	mode         string
+	clientID     string
+	clientSecret string
+	oauthURL     string // Base of the authorize and token endpoints
+	apiURL       string // Base of the user API
+	redirectURL  string // Callback URL registered with the OAuth app, derived from the request if empty
+	secret       []byte
+	client       *http.Client
+	path         string
+
+	mutex    sync.Mutex
+	sessions map[string]*models.Session
+}
+
+// NewAuthService creates an auth service from the environment. AUTH_MODE
+// selects "github" or "dev"; it defaults to github when GITHUB_CLIENT_ID is set.
+func NewAuthService() *AuthService {
+	mode := strings.ToLower(os.Getenv("AUTH_MODE"))
+	if mode == "" {
+		mode = AuthModeDev
+		if os.Getenv("GITHUB_CLIENT_ID") != "" {
+			mode = AuthModeGitHub
+		}
+	}
+	oauthURL := os.Getenv("GITHUB_OAUTH_URL")
+	if oauthURL == "" {
+		oauthURL = "https://github.com"
+	}
+	apiURL := os.Getenv("GITHUB_API_URL")
+	if apiURL == "" {
+		apiURL = "https://api.github.com"
+	}
+	return &AuthService{
+		mode:         mode,
+		clientID:     os.Getenv("GITHUB_CLIENT_ID"),
+		clientSecret: os.Getenv("GITHUB_CLIENT_SECRET"),
+		oauthURL:     strings.TrimSuffix(oauthURL, "/"),
+		apiURL:       strings.TrimSuffix(apiURL, "/"),
+		redirectURL:  os.Getenv("GITHUB_OAUTH_REDIRECT_URL"),
+		client:       &http.Client{Timeout: 10 * time.Second},
+		path:         filepath.Join(DataDir(), "sessions.json"),
+		sessions:     make(map[string]*models.Session),
+	}
+}
+
+// LoadSessions checks the configuration and loads the signing secret and the
+// sessions that have not expired yet
+func (as *AuthService) LoadSessions() error {
+	switch as.mode {
+	case AuthModeDev:
+		return nil
+	case AuthModeGitHub:
+		if as.clientID == "" || as.clientSecret == "" {
+			return fmt.Errorf("GitHub login needs GITHUB_CLIENT_ID and GITHUB_CLIENT_SECRET")
+		}
+	default:
+		return fmt.Errorf("unknown AUTH_MODE %q, expected %q or %q", as.mode, AuthModeGitHub, AuthModeDev)
+	}
+
+	secret, err := loadSessionSecret()
+	if err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(as.path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("could not read %s: %v", as.path, err)
+	}
+	sessions := make(map[string]*models.Session)
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &sessions); err != nil {
+			return fmt.Errorf("could not parse %s: %v", as.path, err)
+		}
+	}
+
+	as.mutex.Lock()
+	defer as.mutex.Unlock()
+	as.secret = secret
+	as.sessions = sessions
+	as.pruneSessions()
+	return nil
+}
+
+// loadSessionSecret returns SESSION_SECRET, or a random secret kept in the data
+// directory so sessions survive restarts
+func loadSessionSecret() ([]byte, error) {
+	if secret := os.Getenv("SESSION_SECRET"); secret != "" {
+		if len(secret) < 32 {
+			fmt.Printf("Warning: SESSION_SECRET is shorter than 32 characters\n")
+		}
+		return []byte(secret), nil
+	}
+
+	path := filepath.Join(DataDir(), "session-secret")
+	if data, err := os.ReadFile(path); err == nil && len(data) > 0 {
+		return data, nil
+	}
+	secret := []byte(randomToken())
+	if err := writeFileAtomic(path, secret); err != nil {
+		return nil, fmt.Errorf("could not store session secret: %v", err)
+	}
+	return secret, nil
+}
+
+// Mode returns AuthModeGitHub or AuthModeDev
+func (as *AuthService) Mode() string {
+	return as.mode
+}
+
+// CurrentUser returns the signed-in user, or nil for anonymous requests
+func (as *AuthService) CurrentUser(r *http.Request) *models.User {
+	if as.mode == AuthModeDev {
+		cookie, err := r.Cookie(devUsernameCookieName)
+		if err != nil || ValidateUsername(cookie.Value) != nil || !isLocalRequest(r) {
+			return nil
+		}
+		return &models.User{Username: cookie.Value}
+	}
+
+	cookie, err := r.Cookie(sessionCookieName)
+	if err != nil {
+		return nil
+	}
+	id, ok := as.verify(cookie.Value)
+	if !ok {
+		return nil
+	}
+
+	as.mutex.Lock()
+	defer as.mutex.Unlock()
+	session, ok := as.sessions[id]
+	if !ok {
+		return nil
+	}
+	if time.Now().After(session.ExpiresAt) {
+		delete(as.sessions, id)
+		return nil
+	}
+	user := session.User
+	return &user
+}
+
+// Username returns who a request acts for, or "" if nobody is signed in. In dev
+// mode a valid username claimed in the request body wins over the cookie; with
+// GitHub login the claim is ignored.
+func (as *AuthService) Username(r *http.Request, claimed string) string {
+	if as.mode == AuthModeDev && ValidateUsername(claimed) == nil && isLocalRequest(r) {
+		return claimed
+	}
+	if user := as.CurrentUser(r); user != nil {
+		return user.Username
+	}
+	return ""
+}
+
+// RememberDevUsername keeps the username cookie the browser set in dev mode
+// for 30 days; it does nothing with GitHub login
+func (as *AuthService) RememberDevUsername(w http.ResponseWriter, r *http.Request, username string) {
+	if as.mode != AuthModeDev || username == "" || !isLocalRequest(r) {
+		return
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     devUsernameCookieName,
+		Value:    username,
+		Expires:  time.Now().Add(30 * 24 * time.Hour),
+		Path:     "/",
+		HttpOnly: false, // The dev mode page reads and sets it
+	})
+}
+
+// Status reports the auth mode and the signed-in user
+func (as *AuthService) Status(r *http.Request) models.AuthStatus {
+	status := models.AuthStatus{Mode: as.mode}
+	if as.mode == AuthModeGitHub {
+		status.LoginURL = "/auth/login"
+	}
+	if user := as.CurrentUser(r); user != nil {
+		status.Authenticated = true
+		status.User = user
+	}
+	return status
+}
+
+// StartLogin redirects to the GitHub authorization page. The state is kept in
+// a short-lived signed cookie together with the local path to return to.
+func (as *AuthService) StartLogin(w http.ResponseWriter, r *http.Request, next string) {
+	state := randomToken()
+	http.SetCookie(w, &http.Cookie{
+		Name:     oauthStateCookieName,
+		Value:    as.sign(state + "." + base64.RawURLEncoding.EncodeToString([]byte(safeRedirectPath(next)))),
+		Path:     "/auth/",
+		MaxAge:   int(oauthStateLifetime / time.Second),
+		HttpOnly: true,
+		Secure:   isSecureRequest(r),
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	query := url.Values{
+		"client_id":    {as.clientID},
+		"redirect_uri": {as.callbackURL(r)},
+		"scope":        {"read:user"},
+		"state":        {state},
+	}
+	http.Redirect(w, r, as.oauthURL+"/login/oauth/authorize?"+query.Encode(), http.StatusFound)
+}
+
+// CompleteLogin handles the redirect back from GitHub: it checks the state,
+// exchanges the code for a token, looks up the user and starts a session.
+// It returns the local path to continue at.
+func (as *AuthService) CompleteLogin(w http.ResponseWriter, r *http.Request) (string, error) {
+	cookie, err := r.Cookie(oauthStateCookieName)
+	if err != nil {
+		return "", fmt.Errorf("login expired, please sign in again")
+	}
+	http.SetCookie(w, &http.Cookie{Name: oauthStateCookieName, Path: "/auth/", MaxAge: -1})
+
+	value, ok := as.verify(cookie.Value)
+	state, encodedNext, _ := strings.Cut(value, ".")
+	query := r.URL.Query()
+	if !ok || subtle.ConstantTimeCompare([]byte(state), []byte(query.Get("state"))) != 1 {
+		return "", fmt.Errorf("login state mismatch, please sign in again")
+	}
+	if errorCode := query.Get("error"); errorCode != "" {
+		return "", fmt.Errorf("GitHub login failed: %s", firstNonEmpty(query.Get("error_description"), errorCode))
+	}
+	code := query.Get("code")
+	if code == "" {
+		return "", fmt.Errorf("GitHub did not return an authorization code")
+	}
+
+	token, err := as.exchangeCode(r.Context(), code, as.callbackURL(r))
+	if err != nil {
+		return "", err
+	}
+	user, err := as.fetchUser(r.Context(), token)
+	if err != nil {
+		return "", err
+	}
+	if err := as.startSession(w, r, user); err != nil {
+		return "", err
+	}
+
+	next, err := base64.RawURLEncoding.DecodeString(encodedNext)
+	if err != nil {
+		return "/", nil
+	}
+	return safeRedirectPath(string(next)), nil
+}
+
+// exchangeCode trades an authorization code for an access token
+func (as *AuthService) exchangeCode(ctx context.Context, code, redirectURL string) (string, error) {
+	form := url.Values{
+		"client_id":     {as.clientID},
+		"client_secret": {as.clientSecret},
+		"code":          {code},
+		"redirect_uri":  {redirectURL},
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", as.oauthURL+"/login/oauth/access_token", strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	var response struct {
+		AccessToken      string `json:"access_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := as.getJSON(req, &response); err != nil {
+		return "", fmt.Errorf("could not exchange the GitHub authorization code: %v", err)
+	}
+	if response.AccessToken == "" {
+		return "", fmt.Errorf("GitHub did not issue an access token: %s", firstNonEmpty(response.ErrorDescription, response.Error, "no reason given"))
+	}
+	return response.AccessToken, nil
+}
+
+// fetchUser looks up the account an access token belongs to
+func (as *AuthService) fetchUser(ctx context.Context, token string) (models.User, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", as.apiURL+"/user", nil)
+	if err != nil {
+		return models.User{}, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	var response struct {
+		Login     string `json:"login"`
+		Name      string `json:"name"`
+		AvatarURL string `json:"avatar_url"`
+	}
+	if err := as.getJSON(req, &response); err != nil {
+		return models.User{}, fmt.Errorf("could not fetch the GitHub user: %v", err)
+	}
+	if err := ValidateUsername(response.Login); err != nil {
+		return models.User{}, fmt.Errorf("GitHub returned an invalid login: %v", err)
+	}
+	return models.User{Username: response.Login, Name: response.Name, AvatarURL: response.AvatarURL}, nil
+}
+
+// getJSON sends a request and decodes a successful JSON response
+func (as *AuthService) getJSON(req *http.Request, out interface{}) error {
+	resp, err := as.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s returned %s", req.URL.Host, resp.Status)
+	}
+	return json.Unmarshal(body, out)
+}
+
+// startSession stores a new session for user and sets its cookie
+func (as *AuthService) startSession(w http.ResponseWriter, r *http.Request, user models.User) error {
+	id := randomToken()
+	now := time.Now()
+	session := &models.Session{User: user, CreatedAt: now, ExpiresAt: now.Add(sessionLifetime)}
+
+	as.mutex.Lock()
+	as.pruneSessions()
+	as.sessions[id] = session
+	err := as.saveSessions()
+	as.mutex.Unlock()
+	if err != nil {
+		return fmt.Errorf("could not store session: %v", err)
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookieName,
+		Value:    as.sign(id),
+		Path:     "/",
+		Expires:  session.ExpiresAt,
+		MaxAge:   int(sessionLifetime / time.Second),
+		HttpOnly: true,
+		Secure:   isSecureRequest(r),
+		SameSite: http.SameSiteLaxMode,
+	})
+	return nil
+}
+
+// Logout ends the request's session, or forgets the dev mode username
+func (as *AuthService) Logout(w http.ResponseWriter, r *http.Request) {
+	if as.mode == AuthModeDev {
+		http.SetCookie(w, &http.Cookie{Name: devUsernameCookieName, Path: "/", MaxAge: -1})
+		return
+	}
+
+	if cookie, err := r.Cookie(sessionCookieName); err == nil {
+		if id, ok := as.verify(cookie.Value); ok {
+			as.mutex.Lock()
+			if _, exists := as.sessions[id]; exists {
+				delete(as.sessions, id)
+				if err := as.saveSessions(); err != nil {
+					fmt.Printf("Warning: could not store sessions: %v\n", err)
+				}
+			}
+			as.mutex.Unlock()
+		}
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookieName,
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   isSecureRequest(r),
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// pruneSessions drops expired sessions. Must be called with the mutex held.
+func (as *AuthService) pruneSessions() {
+	now := time.Now()
+	for id, session := range as.sessions {
+		if now.After(session.ExpiresAt) {
+			delete(as.sessions, id)
+		}
+	}
+}
+
+// saveSessions writes the sessions to the data directory. Must be called with the mutex held.
+func (as *AuthService) saveSessions() error {
+	data, err := json.Marshal(as.sessions)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(as.path, data)
+}
+
+// callbackURL is the redirect URI sent to GitHub
+func (as *AuthService) callbackURL(r *http.Request) string {
+	if as.redirectURL != "" {
+		return as.redirectURL
+	}
+	scheme := "http"
+	if isSecureRequest(r) {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host + "/auth/callback"
+}
+
+// sign appends an HMAC of value so cookies cannot be forged
+func (as *AuthService) sign(value string) string {
+	mac := hmac.New(sha256.New, as.secret)
+	mac.Write([]byte(value))
+	return value + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// verify returns the value of a signed cookie if its signature holds
+func (as *AuthService) verify(signed string) (string, bool) {
+	i := strings.LastIndex(signed, ".")
+	if i < 0 || len(as.secret) == 0 {
+		return "", false
+	}
+	value := signed[:i]
+	return value, hmac.Equal([]byte(as.sign(value)), []byte(signed))
+}
+
+// randomToken returns 32 random bytes in hex
+func randomToken() string {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		panic(fmt.Sprintf("crypto/rand failed: %v", err))
+	}
+	return hex.EncodeToString(buf)
+}
+
+// safeRedirectPath keeps redirects after login on this site
+func safeRedirectPath(path string) string {
+	if !strings.HasPrefix(path, "/") || strings.HasPrefix(path, "//") || strings.HasPrefix(path, "/\\") {
+		return "/"
+	}
+	return path
+}
+
+// isSecureRequest reports whether the browser reached the server over HTTPS
+func isSecureRequest(r *http.Request) bool {
+	return r.TLS != nil || strings.EqualFold(r.Header.Get("X-Forwarded-Proto"), "https")
+}
+
+// isLocalRequest reports whether a request comes straight from this machine
+func isLocalRequest(r *http.Request) bool {
+	if r.Header.Get("X-Forwarded-For") != "" || r.Header.Get("Forwarded") != "" {
+		return false
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return false
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// firstNonEmpty returns the first non-empty string
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
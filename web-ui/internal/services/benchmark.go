@@ -0,0 +1,265 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"web-ui/internal/models"
+)
+
+// "BenchmarkSort/1000-4   	    6823	     14848 ns/op	    8192 B/op	       1 allocs/op"
+var benchmarkLineRe = regexp.MustCompile(`^(Benchmark\S*)\s+(\d+)\s+([\d.]+) ns/op(?:\s+(\d+) B/op)?(?:\s+(\d+) allocs/op)?`)
+
+// benchmarkArgs builds the go test invocation running the benchmarks that match pattern
+func benchmarkArgs(config *models.BenchmarkConfig, pattern string) []string {
+	_, benchtime, count, cpu := benchmarkSettings(config)
+	return []string{
+		"go", "test", "-json", "-run", "^$", "-bench", pattern, "-benchmem",
+		"-benchtime", benchtime, "-count", strconv.Itoa(count), "-cpu", strconv.Itoa(cpu),
+	}
+}
+
+// benchmarkSettings returns the config values with defaults applied
+func benchmarkSettings(config *models.BenchmarkConfig) (string, string, int, int) {
+	pattern, benchtime, count, cpu := config.Pattern, config.Benchtime, config.Count, config.CPU
+	if pattern == "" {
+		pattern = "."
+	}
+	if benchtime == "" {
+		benchtime = "100ms"
+	}
+	if count <= 0 {
+		count = 3
+	}
+	if cpu <= 0 {
+		cpu = 1
+	}
+	return pattern, benchtime, count, cpu
+}
+
+// baselinePattern returns a -bench pattern for the baselines the thresholds
+// compare with, or "" if none does. It matches top-level benchmarks only, as
+// those without sub-benchmarks report nothing under a two-level pattern.
+func baselinePattern(config *models.BenchmarkConfig) string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, threshold := range config.Thresholds {
+		if threshold.Baseline == "" || threshold.MinSpeedup <= 0 {
+			continue
+		}
+		name, _, _ := strings.Cut(threshold.Baseline, "/")
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, regexp.QuoteMeta(name))
+		}
+	}
+	if len(names) == 0 {
+		return ""
+	}
+	return "^(" + strings.Join(names, "|") + ")$"
+}
+
+// runBenchmarks runs the challenge benchmarks in a prepared workspace and
+// grades them. Speedups are measured against the baselines run with the
+// challenge's reference sources in place of the submission, which the
+// submission cannot slow down.
+func (es *ExecutionService) runBenchmarks(ctx context.Context, tempDir, workDir string, spec workspaceSpec, limits models.ExecutionLimits) (*models.BenchmarkReport, string) {
+	config := spec.Benchmark
+	pattern, _, count, cpu := benchmarkSettings(config)
+	run := es.sandbox.Run(ctx, SandboxCommand{
+		Dir:    workDir,
+		Args:   benchmarkArgs(config, pattern),
+		Env:    es.moduleCache.Env(),
+		GoRoot: spec.GoRoot,
+		Limits: limits,
+	})
+	_, output := parseTestJSON(run.Output)
+	results := parseBenchmarkJSON(run.Output, cpu, count)
+
+	var baselines []models.BenchmarkResult
+	if pattern := baselinePattern(config); pattern != "" {
+		var baselineOutput string
+		baselines, baselineOutput = es.runBaselines(ctx, tempDir, workDir, spec, pattern, limits)
+		output += "\n# reference baselines\n" + baselineOutput
+	}
+
+	report := gradeBenchmarks(results, baselines, config)
+	if run.Err != nil || run.ExitCode != 0 || run.Termination != "" {
+		report.Passed = false
+		if run.TerminationReason != "" {
+			return report, output + "\n[sandbox] " + run.TerminationReason
+		}
+	}
+	return report, output
+}
+
+// runBaselines runs the baseline benchmarks matching pattern with the
+// submitted sources replaced by the reference ones through a -overlay
+func (es *ExecutionService) runBaselines(ctx context.Context, tempDir, workDir string, spec workspaceSpec, pattern string, limits models.ExecutionLimits) ([]models.BenchmarkResult, string) {
+	if len(spec.BaselineFiles) == 0 {
+		return nil, "no reference sources to run the baselines with"
+	}
+	var removed []string
+	for name := range spec.Sources {
+		if _, replaced := spec.BaselineFiles[name]; !replaced {
+			removed = append(removed, name)
+		}
+	}
+	overlay, err := writeOverlay(filepath.Join(tempDir, "baseline"), workDir, spec.BaselineFiles, removed...)
+	if err != nil {
+		fmt.Printf("Warning: could not prepare the reference baselines: %v\n", err)
+		return nil, "could not prepare the reference sources"
+	}
+
+	_, _, count, cpu := benchmarkSettings(spec.Benchmark)
+	args := benchmarkArgs(spec.Benchmark, pattern)
+	args = append(args[:2:2], append([]string{"-overlay", overlay}, args[2:]...)...)
+	run := es.sandbox.Run(ctx, SandboxCommand{
+		Dir:    workDir,
+		Args:   args,
+		Env:    es.moduleCache.Env(),
+		GoRoot: spec.GoRoot,
+		Limits: limits,
+	})
+	_, output := parseTestJSON(run.Output)
+	if run.TerminationReason != "" {
+		output += "\n[sandbox] " + run.TerminationReason
+	}
+	return parseBenchmarkJSON(run.Output, cpu, count), output
+}
+
+// parseBenchmarkJSON extracts the results from go test -json output and keeps
+// the median ns/op run of each benchmark. Submitted code can print lines that
+// look like results, so a result only counts in the output of the benchmark
+// it names, and a benchmark with more results than runs is marked as tampered.
+func parseBenchmarkJSON(output string, cpu, count int) []models.BenchmarkResult {
+	suffix := ""
+	if cpu != 1 {
+		suffix = fmt.Sprintf("-%d", cpu)
+	}
+
+	// Result lines may be written in parts, so join each benchmark's output first
+	outputs := make(map[string]*strings.Builder)
+	var benchmarks []string
+	for _, line := range strings.Split(output, "\n") {
+		var event testEvent
+		if !strings.HasPrefix(line, "{") || json.Unmarshal([]byte(line), &event) != nil {
+			continue
+		}
+		if event.Action != "output" || !strings.HasPrefix(event.Test, "Benchmark") {
+			continue
+		}
+		if outputs[event.Test] == nil {
+			outputs[event.Test] = &strings.Builder{}
+			benchmarks = append(benchmarks, event.Test)
+		}
+		outputs[event.Test].WriteString(event.Output)
+	}
+
+	results := make([]models.BenchmarkResult, 0, len(benchmarks))
+	for _, name := range benchmarks {
+		var samples []models.BenchmarkResult
+		for _, line := range strings.Split(outputs[name].String(), "\n") {
+			match := benchmarkLineRe.FindStringSubmatch(strings.TrimSpace(line))
+			if match == nil || strings.TrimSuffix(match[1], suffix) != name {
+				continue
+			}
+			iterations, _ := strconv.ParseInt(match[2], 10, 64)
+			nsPerOp, _ := strconv.ParseFloat(match[3], 64)
+			bytesPerOp, _ := strconv.ParseInt(match[4], 10, 64)
+			allocsPerOp, _ := strconv.ParseInt(match[5], 10, 64)
+			samples = append(samples, models.BenchmarkResult{
+				Name:        name,
+				Iterations:  iterations,
+				NsPerOp:     nsPerOp,
+				BytesPerOp:  bytesPerOp,
+				AllocsPerOp: allocsPerOp,
+			})
+		}
+		if len(samples) == 0 {
+			// Benchmarks with sub-benchmarks report no results of their own
+			continue
+		}
+
+		sort.Slice(samples, func(i, j int) bool { return samples[i].NsPerOp < samples[j].NsPerOp })
+		result := samples[len(samples)/2]
+		if len(samples) > count {
+			result.Violations = append(result.Violations, "the benchmark printed output that looks like benchmark results")
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// gradeBenchmarks checks results against the thresholds and computes the
+// leaderboard score. Speedups are measured against the reference baselines.
+func gradeBenchmarks(results, baselines []models.BenchmarkResult, config *models.BenchmarkConfig) *models.BenchmarkReport {
+	report := &models.BenchmarkReport{Results: results, Passed: true}
+
+	byName := make(map[string]*models.BenchmarkResult, len(results))
+	for i := range results {
+		byName[results[i].Name] = &results[i]
+		if len(results[i].Violations) > 0 {
+			report.Passed = false
+		}
+	}
+	baselineByName := make(map[string]*models.BenchmarkResult, len(baselines))
+	for i := range baselines {
+		baselineByName[baselines[i].Name] = &baselines[i]
+	}
+	reported := make(map[string]bool)
+
+	var missing []models.BenchmarkResult
+	for _, threshold := range config.Thresholds {
+		result, ok := byName[threshold.Name]
+		if !ok {
+			// A graded benchmark that did not run cannot pass
+			report.Passed = false
+			missing = append(missing, models.BenchmarkResult{
+				Name:       threshold.Name,
+				Graded:     true,
+				Violations: []string{"benchmark did not run"},
+			})
+			continue
+		}
+
+		result.Graded = true
+		report.Score += result.NsPerOp
+		if threshold.MaxNsPerOp > 0 && result.NsPerOp > threshold.MaxNsPerOp {
+			result.Violations = append(result.Violations, fmt.Sprintf("%.1f ns/op exceeds the limit of %.1f ns/op", result.NsPerOp, threshold.MaxNsPerOp))
+		}
+		if threshold.MaxBytesPerOp > 0 && result.BytesPerOp > threshold.MaxBytesPerOp {
+			result.Violations = append(result.Violations, fmt.Sprintf("%d B/op exceeds the limit of %d B/op", result.BytesPerOp, threshold.MaxBytesPerOp))
+		}
+		if threshold.MaxAllocsPerOp > 0 && result.AllocsPerOp > threshold.MaxAllocsPerOp {
+			result.Violations = append(result.Violations, fmt.Sprintf("%d allocs/op exceeds the limit of %d allocs/op", result.AllocsPerOp, threshold.MaxAllocsPerOp))
+		}
+		if threshold.Baseline != "" && threshold.MinSpeedup > 0 {
+			baseline, ok := baselineByName[threshold.Baseline]
+			if ok && !reported[threshold.Baseline] {
+				reported[threshold.Baseline] = true
+				report.Baselines = append(report.Baselines, *baseline)
+			}
+			switch {
+			case !ok || len(baseline.Violations) > 0:
+				result.Violations = append(result.Violations, fmt.Sprintf("reference baseline %s did not run", threshold.Baseline))
+			case result.NsPerOp <= 0:
+				// Too fast to measure is fast enough
+			case baseline.NsPerOp/result.NsPerOp < threshold.MinSpeedup:
+				result.Violations = append(result.Violations, fmt.Sprintf("%.1fx faster than the reference %s, at least %.1fx required",
+					baseline.NsPerOp/result.NsPerOp, threshold.Baseline, threshold.MinSpeedup))
+			}
+		}
+		if len(result.Violations) > 0 {
+			report.Passed = false
+		}
+	}
+	report.Results = append(report.Results, missing...)
+	return report
+}
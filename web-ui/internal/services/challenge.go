@@ -1,37 +1,45 @@
 package services
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
-	"io/ioutil"
+	"io/fs"
 	"log"
-	"path/filepath"
+	"path"
 	"regexp"
 	"strconv"
 	"strings"
+	"sync"
 
 	"web-ui/internal/models"
 )
 
 // ChallengeService handles challenge-related operations
 type ChallengeService struct {
-	challenges models.ChallengeMap
+	content *ContentRoot
+
+	mu         sync.RWMutex
+	challenges models.ChallengeMap // Replaced on reload, never modified once loaded
 }
 
-// NewChallengeService creates a new challenge service
-func NewChallengeService() *ChallengeService {
+// NewChallengeService creates a new challenge service for the challenges in content
+func NewChallengeService(content *ContentRoot) *ChallengeService {
 	return &ChallengeService{
+		content:    content,
 		challenges: make(models.ChallengeMap),
 	}
 }
 
-// LoadChallenges loads all challenges from the filesystem
+// LoadChallenges loads all challenges from the content root
 func (cs *ChallengeService) LoadChallenges() error {
 	// Find challenge directories (challenge-1, challenge-2, etc.)
-	challengeDirs, err := filepath.Glob("../challenge-*")
+	challengeDirs, err := cs.content.Glob("challenge-*")
 	if err != nil {
 		return fmt.Errorf("failed to find challenge directories: %v", err)
 	}
 
+	challenges := make(models.ChallengeMap)
 	for _, dir := range challengeDirs {
 		// Extract challenge number
 		re := regexp.MustCompile(`challenge-(\d+)`)
@@ -51,78 +59,233 @@ func (cs *ChallengeService) LoadChallenges() error {
 			continue
 		}
 
-		cs.challenges[id] = challenge
+		challenges[id] = challenge
 	}
 
-	log.Printf("Loaded %d challenges", len(cs.challenges))
+	cs.mu.Lock()
+	cs.challenges = challenges
+	cs.mu.Unlock()
+
+	log.Printf("Loaded %d challenges", len(challenges))
 	return nil
 }
 
-// loadSingleChallenge loads a single challenge from a directory
+// ReloadChallenge reloads challenge id from the content root, or drops it when
+// its directory is gone. On errors the loaded challenge is kept.
+func (cs *ChallengeService) ReloadChallenge(id int) error {
+	dir := "challenge-" + strconv.Itoa(id)
+	var challenge *models.Challenge
+	if _, err := cs.content.Stat(dir); !errors.Is(err, fs.ErrNotExist) {
+		if challenge, err = cs.loadSingleChallenge(id, dir); err != nil {
+			return fmt.Errorf("could not reload challenge %d: %v", id, err)
+		}
+	}
+
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	challenges := make(models.ChallengeMap, len(cs.challenges)+1)
+	for existingID, existing := range cs.challenges {
+		challenges[existingID] = existing
+	}
+	if challenge != nil {
+		challenges[id] = challenge
+	} else {
+		delete(challenges, id)
+	}
+	cs.challenges = challenges
+	return nil
+}
+
+// loadSingleChallenge loads a single challenge from a content directory
 func (cs *ChallengeService) loadSingleChallenge(id int, dir string) (*models.Challenge, error) {
 	// Read README.md for title and description
-	readmePath := filepath.Join(dir, "README.md")
-	readmeContent, err := ioutil.ReadFile(readmePath)
+	readmeContent, err := cs.content.ReadFile(dir, "README.md")
 	if err != nil {
 		return nil, fmt.Errorf("could not read README: %v", err)
 	}
 
-	// Extract title from README (first heading)
-	title := cs.extractTitle(string(readmeContent), id)
+	// Read metadata.json; whatever it leaves out is derived as before
+	metadata := cs.loadMetadata(id, dir)
+
+	// Extract title from README (first heading) unless the metadata names it
+	title := metadata.Title
+	if title == "" {
+		title = cs.extractTitle(string(readmeContent), id)
+	}
 
 	// Determine difficulty level
-	difficulty := cs.determineDifficulty(id)
+	difficulty := metadata.Difficulty
+	if difficulty == "" {
+		difficulty = cs.determineDifficulty(id)
+	}
+
+	// Sandbox limits and mandatory checks for test runs come from the metadata
+	// alone; limits it leaves unset use the execution defaults
+	limits := defaultExecutionLimits
+	if metadata.Limits != nil {
+		limits = mergeLimits(*metadata.Limits)
+	}
+	var requiredOptions models.RunOptions
+	if metadata.RunOptions != nil {
+		requiredOptions = *metadata.RunOptions
+	}
 
 	// Read solution template
-	templatePath := filepath.Join(dir, "solution-template.go")
-	templateContent, err := ioutil.ReadFile(templatePath)
+	templateContent, err := cs.content.ReadFile(dir, "solution-template.go")
 	if err != nil {
 		return nil, fmt.Errorf("could not read solution template: %v", err)
 	}
 
 	// Read test file
-	testPath := filepath.Join(dir, "solution-template_test.go")
-	testContent, err := ioutil.ReadFile(testPath)
+	testContent, err := cs.content.ReadFile(dir, "solution-template_test.go")
 	if err != nil {
 		log.Printf("Warning: Could not read test file for challenge %d: %v", id, err)
 	}
 
+	// Read the hidden tests, which only run on submit
+	hiddenContent, _ := cs.content.ReadFile(dir, "hidden_test.go")
+
+	// Read the fuzz targets and their reference implementation
+	fuzz, fuzzFiles := cs.loadFuzzTargets(id, path.Join(dir, "fuzz"))
+
+	// Read the private reference solution used for differential testing
+	differential, referenceFiles := cs.loadReference(id, path.Join(dir, "reference"), string(templateContent))
+
+	// Read the pinned module files if the challenge ships them
+	moduleFile, _ := cs.content.ReadFile(dir, "go.mod")
+	moduleSum, _ := cs.content.ReadFile(dir, "go.sum")
+
 	// Read learning materials if available
-	learningPath := filepath.Join(dir, "learning.md")
 	learningContent := []byte("*No learning materials available for this challenge yet.*")
-	if learningFileContent, err := ioutil.ReadFile(learningPath); err == nil {
+	if learningFileContent, err := cs.content.ReadFile(dir, "learning.md"); err == nil {
 		learningContent = learningFileContent
 	}
 
 	// Read hints if available
-	hintsPath := filepath.Join(dir, "hints.md")
 	hintsContent := []byte("*No hints available for this challenge yet.*")
-	if hintsFileContent, err := ioutil.ReadFile(hintsPath); err == nil {
+	if hintsFileContent, err := cs.content.ReadFile(dir, "hints.md"); err == nil {
 		hintsContent = hintsFileContent
 	}
 
 	// Create challenge
 	challenge := &models.Challenge{
-		ID:                id,
-		Title:             title,
-		Description:       cs.filterWebUIDescription(string(readmeContent)),
-		Difficulty:        difficulty,
-		Template:          string(templateContent),
-		TestFile:          string(testContent),
-		LearningMaterials: string(learningContent),
-		Hints:             string(hintsContent),
+		ID:                 id,
+		Title:              title,
+		Description:        cs.filterWebUIDescription(string(readmeContent)),
+		Difficulty:         difficulty,
+		EstimatedTime:      metadata.EstimatedTime,
+		Tags:               metadata.Tags,
+		LearningObjectives: metadata.LearningObjectives,
+		Prerequisites:      metadata.Prerequisites,
+		Template:           string(templateContent),
+		TestFile:           string(testContent),
+		HiddenTestFile:     string(hiddenContent),
+		LearningMaterials:  string(learningContent),
+		Hints:              string(hintsContent),
+		Limits:             limits,
+		RequiredOptions:    requiredOptions,
+		Benchmark:          metadata.Benchmark,
+		Fuzz:               fuzz,
+		FuzzFiles:          fuzzFiles,
+		Differential:       differential,
+		ReferenceFiles:     referenceFiles,
+		ModuleFile:         string(moduleFile),
+		ModuleSum:          string(moduleSum),
+		Toolchain:          ParseToolchainRequirement(string(moduleFile)),
 	}
 
 	return challenge, nil
 }
 
-// extractTitle extracts the title from README content
+// loadMetadata reads a challenge's metadata.json, the schema package challenges
+// use. A missing or invalid file gives empty metadata, so every value falls
+// back to the defaults.
+func (cs *ChallengeService) loadMetadata(id int, dir string) *models.ChallengeMetadata {
+	metadata := &models.ChallengeMetadata{}
+	content, err := cs.content.ReadFile(dir, "metadata.json")
+	if err != nil {
+		return metadata
+	}
+	if err := json.Unmarshal(content, metadata); err != nil {
+		log.Printf("Warning: Could not parse metadata.json of challenge %d: %v", id, err)
+		return &models.ChallengeMetadata{}
+	}
+
+	metadata.Title = strings.TrimSpace(metadata.Title)
+	switch metadata.Difficulty {
+	case "", "Beginner", "Intermediate", "Advanced":
+	default:
+		log.Printf("Warning: Unknown difficulty %q in metadata.json of challenge %d", metadata.Difficulty, id)
+		metadata.Difficulty = ""
+	}
+	return metadata
+}
+
+// loadFuzzTargets reads the *_test.go files of a challenge's fuzz directory and finds their Fuzz targets
+func (cs *ChallengeService) loadFuzzTargets(id int, dir string) (*models.FuzzConfig, map[string]string) {
+	paths, _ := cs.content.Glob(path.Join(dir, "*_test.go"))
+	if len(paths) == 0 {
+		return nil, nil
+	}
+
+	config := &models.FuzzConfig{}
+	files := make(map[string]string, len(paths))
+	for _, file := range paths {
+		content, err := cs.content.ReadFile(file)
+		if err != nil {
+			log.Printf("Warning: Could not read fuzz file for challenge %d: %v", id, err)
+			continue
+		}
+		targets, err := testFunctionNames(path.Base(file), string(content), "Fuzz")
+		if err != nil {
+			log.Printf("Warning: Could not parse fuzz file for challenge %d: %v", id, err)
+			continue
+		}
+		files[path.Base(file)] = string(content)
+		config.Targets = append(config.Targets, targets...)
+	}
+	if len(config.Targets) == 0 {
+		return nil, nil
+	}
+	return config, files
+}
+
+// loadReference reads a challenge's reference solution and its input generator
+func (cs *ChallengeService) loadReference(id int, dir string, template string) (*models.DifferentialConfig, map[string]string) {
+	paths, _ := cs.content.Glob(path.Join(dir, "*.go"))
+	if len(paths) == 0 {
+		return nil, nil
+	}
+
+	files := make(map[string]string, len(paths))
+	for _, file := range paths {
+		content, err := cs.content.ReadFile(file)
+		if err != nil {
+			log.Printf("Warning: Could not read reference solution for challenge %d: %v", id, err)
+			return nil, nil
+		}
+		files[path.Base(file)] = string(content)
+	}
+
+	if !HasDifferentialGenerator(files) {
+		log.Printf("Warning: Reference solution for challenge %d has no %s function in a _test.go file", id, DifferentialGenerator)
+		return nil, nil
+	}
+	functions := DifferentialFunctions(template, files)
+	if len(functions) == 0 {
+		log.Printf("Warning: Reference solution for challenge %d implements none of the template functions", id)
+		return nil, nil
+	}
+	return &models.DifferentialConfig{Functions: functions}, files
+}
+
+// extractTitle extracts the title from the first heading of README content
 func (cs *ChallengeService) extractTitle(readmeContent string, id int) string {
-	titleRe := regexp.MustCompile(`#\s+(.+)`)
+	titleRe := regexp.MustCompile(`(?m)^[ \t]*#[ \t]+(.+)$`)
 	titleMatch := titleRe.FindStringSubmatch(readmeContent)
 
 	if len(titleMatch) >= 2 {
-		title := titleMatch[1]
+		title := strings.TrimSpace(titleMatch[1])
 		// Clean up the title - remove "Challenge X: " prefix if present
 		cleanTitle := regexp.MustCompile(`^Challenge\s+\d+:\s+`).ReplaceAllString(title, "")
 		return cleanTitle
@@ -131,7 +294,8 @@ func (cs *ChallengeService) extractTitle(readmeContent string, id int) string {
 	return fmt.Sprintf("Challenge %d", id)
 }
 
-// determineDifficulty determines the difficulty level based on challenge ID
+// determineDifficulty determines the difficulty level based on challenge ID,
+// for challenges without metadata.json
 func (cs *ChallengeService) determineDifficulty(id int) string {
 	switch {
 	case id <= 3 || id == 6 || id == 18 || id == 21 || id == 22:
@@ -179,13 +343,18 @@ func (cs *ChallengeService) filterWebUIDescription(content string) string {
 	return strings.Join(filteredLines, "\n")
 }
 
-// GetChallenges returns all challenges
+// GetChallenges returns all challenges. The map is a snapshot that reloads
+// replace rather than modify, so callers may range over it but must not change it.
 func (cs *ChallengeService) GetChallenges() models.ChallengeMap {
+	cs.mu.RLock()
+	defer cs.mu.RUnlock()
 	return cs.challenges
 }
 
 // GetChallenge returns a specific challenge by ID
 func (cs *ChallengeService) GetChallenge(id int) (*models.Challenge, bool) {
+	cs.mu.RLock()
+	defer cs.mu.RUnlock()
 	challenge, exists := cs.challenges[id]
 	return challenge, exists
 }
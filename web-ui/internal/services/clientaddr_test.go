@@ -0,0 +1,111 @@
+package services
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTrustedProxies(t *testing.T) {
+	t.Setenv("TRUSTED_PROXIES", " 10.0.0.1, 192.168.0.0/16,,::1, not-an-address ")
+	proxies := TrustedProxies()
+
+	var got []string
+	for _, network := range proxies {
+		got = append(got, network.String())
+	}
+	want := []string{"10.0.0.1/32", "192.168.0.0/16", "::1/128"}
+	if len(got) != len(want) {
+		t.Fatalf("proxies = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("proxies = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestClientAddress(t *testing.T) {
+	t.Setenv("TRUSTED_PROXIES", "10.0.0.0/8, ::1")
+	trusted := TrustedProxies()
+
+	tests := []struct {
+		name      string
+		trusted   bool     // Whether the proxies are trusted at all
+		peer      string   // RemoteAddr of the request
+		forwarded []string // X-Forwarded-For headers
+		want      string
+	}{
+		{
+			name: "direct client",
+			peer: "203.0.113.7:51234", trusted: true,
+			want: "203.0.113.7",
+		},
+		{
+			name: "untrusted peer cannot claim another address",
+			peer: "203.0.113.7:51234", trusted: true, forwarded: []string{"198.51.100.1"},
+			want: "203.0.113.7",
+		},
+		{
+			name: "no trusted proxies configured",
+			peer: "10.0.0.1:80", forwarded: []string{"198.51.100.1"},
+			want: "10.0.0.1",
+		},
+		{
+			name: "trusted proxy forwards the client",
+			peer: "10.0.0.1:80", trusted: true, forwarded: []string{"198.51.100.1"},
+			want: "198.51.100.1",
+		},
+		{
+			name: "client-supplied entries before the proxy's are ignored",
+			peer: "10.0.0.1:80", trusted: true, forwarded: []string{"192.0.2.66, 198.51.100.1"},
+			want: "198.51.100.1",
+		},
+		{
+			name: "chain of trusted proxies",
+			peer: "10.0.0.1:80", trusted: true, forwarded: []string{"198.51.100.1, 10.0.0.2"},
+			want: "198.51.100.1",
+		},
+		{
+			name: "entries spread over several headers",
+			peer: "10.0.0.1:80", trusted: true, forwarded: []string{"192.0.2.66", "198.51.100.1, 10.0.0.2"},
+			want: "198.51.100.1",
+		},
+		{
+			name: "only trusted proxies forwarded",
+			peer: "10.0.0.1:80", trusted: true, forwarded: []string{"10.0.0.3, 10.0.0.2"},
+			want: "10.0.0.3",
+		},
+		{
+			name: "trusted proxy without X-Forwarded-For",
+			peer: "10.0.0.1:80", trusted: true,
+			want: "10.0.0.1",
+		},
+		{
+			name: "trusted IPv6 proxy",
+			peer: "[::1]:8080", trusted: true, forwarded: []string{"2001:db8::1"},
+			want: "2001:db8::1",
+		},
+		{
+			name: "peer without a port",
+			peer: "203.0.113.7", trusted: true, forwarded: []string{"198.51.100.1"},
+			want: "203.0.113.7",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/api/run", nil)
+			r.RemoteAddr = tt.peer
+			for _, value := range tt.forwarded {
+				r.Header.Add("X-Forwarded-For", value)
+			}
+			proxies := trusted
+			if !tt.trusted {
+				proxies = nil
+			}
+			if got := ClientAddress(r, proxies); got != tt.want {
+				t.Errorf("ClientAddress = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
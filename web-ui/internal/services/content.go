@@ -0,0 +1,154 @@
+package services
+
+import (
+	"archive/zip"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// packagesDir is the directory of the content root holding the packages
+const packagesDir = "packages"
+
+// ContentRoot is the repository content the site serves: the classic
+// challenges, the packages, and the submissions and scoreboards in them. Paths
+// are slash-separated and relative to the repository root. Content is read
+// through an fs.FS, so it can come from a checkout on disk or from a read-only
+// snapshot such as a zip archive or an embedded file system. Only a checkout
+// can save submissions.
+type ContentRoot struct {
+	fsys fs.FS
+	dir  string // Absolute repository directory, "" for snapshots
+	name string // Where the content comes from, for logs and errors
+}
+
+// NewContentRoot serves the repository checked out at dir
+func NewContentRoot(dir string) *ContentRoot {
+	if absDir, err := filepath.Abs(dir); err == nil {
+		dir = absDir
+	}
+	return &ContentRoot{fsys: os.DirFS(dir), dir: dir, name: dir}
+}
+
+// NewContentRootFS serves a read-only snapshot of the repository; name
+// describes it in logs. When the snapshot keeps the repository in a single
+// top-level directory, as GitHub archives and go:embed trees do, that
+// directory is served.
+func NewContentRootFS(fsys fs.FS, name string) *ContentRoot {
+	if !hasContent(fsys) {
+		if entries, err := fs.ReadDir(fsys, "."); err == nil && len(entries) == 1 && entries[0].IsDir() {
+			if sub, err := fs.Sub(fsys, entries[0].Name()); err == nil {
+				fsys = sub
+			}
+		}
+	}
+	return &ContentRoot{fsys: fsys, name: name}
+}
+
+// OpenContentRoot opens the content at location, a repository directory or a
+// .zip archive of one
+func OpenContentRoot(location string) (*ContentRoot, error) {
+	info, err := os.Stat(location)
+	if err != nil {
+		return nil, fmt.Errorf("content root %s is not accessible: %v", location, err)
+	}
+
+	var content *ContentRoot
+	switch {
+	case info.IsDir():
+		content = NewContentRoot(location)
+	case strings.EqualFold(filepath.Ext(location), ".zip"):
+		// The archive stays open for the lifetime of the server
+		archive, err := zip.OpenReader(location)
+		if err != nil {
+			return nil, fmt.Errorf("could not open content snapshot %s: %v", location, err)
+		}
+		content = NewContentRootFS(archive, location)
+	default:
+		return nil, fmt.Errorf("content root %s is neither a directory nor a .zip archive", location)
+	}
+
+	if !hasContent(content.fsys) {
+		return nil, fmt.Errorf("content root %s has no challenge-* or %s directories", location, packagesDir)
+	}
+	return content, nil
+}
+
+// ResolveContentRoot opens the content root given by the -content flag or,
+// when location is empty, by CONTENT_ROOT. Without either it looks for the
+// repository in the working directory, its parent and the directory of the
+// executable, so the server starts from the repository root and from web-ui.
+func ResolveContentRoot(location string) (*ContentRoot, error) {
+	if location == "" {
+		location = os.Getenv("CONTENT_ROOT")
+	}
+	if location != "" {
+		return OpenContentRoot(location)
+	}
+
+	candidates := []string{".", ".."}
+	if executable, err := os.Executable(); err == nil {
+		candidates = append(candidates, filepath.Dir(executable), filepath.Dir(filepath.Dir(executable)))
+	}
+	for _, dir := range candidates {
+		if hasContent(os.DirFS(dir)) {
+			return NewContentRoot(dir), nil
+		}
+	}
+	return nil, fmt.Errorf("could not find the challenges near the working directory; start the server from the repository or set CONTENT_ROOT")
+}
+
+// hasContent reports whether fsys holds challenges or packages at its root
+func hasContent(fsys fs.FS) bool {
+	if matches, _ := fs.Glob(fsys, "challenge-*/"+MainSolutionFile); len(matches) > 0 {
+		return true
+	}
+	info, err := fs.Stat(fsys, packagesDir)
+	return err == nil && info.IsDir()
+}
+
+// FS returns the content as a file system rooted at the repository root
+func (c *ContentRoot) FS() fs.FS {
+	return c.fsys
+}
+
+// Dir returns the absolute repository directory, or "" for a read-only snapshot
+func (c *ContentRoot) Dir() string {
+	return c.dir
+}
+
+// ReadOnly reports whether the content is a snapshot that cannot be written to
+func (c *ContentRoot) ReadOnly() bool {
+	return c.dir == ""
+}
+
+// String describes where the content comes from
+func (c *ContentRoot) String() string {
+	if c.ReadOnly() {
+		return c.name + " (read-only snapshot)"
+	}
+	return c.name
+}
+
+// ReadFile reads the file at the slash-separated path joined from elem
+func (c *ContentRoot) ReadFile(elem ...string) ([]byte, error) {
+	return fs.ReadFile(c.fsys, path.Join(elem...))
+}
+
+// Stat describes the file at the slash-separated path joined from elem
+func (c *ContentRoot) Stat(elem ...string) (fs.FileInfo, error) {
+	return fs.Stat(c.fsys, path.Join(elem...))
+}
+
+// ReadDir lists the directory at the slash-separated path joined from elem
+func (c *ContentRoot) ReadDir(elem ...string) ([]fs.DirEntry, error) {
+	return fs.ReadDir(c.fsys, path.Join(elem...))
+}
+
+// Glob returns the content paths matching pattern
+func (c *ContentRoot) Glob(pattern string) ([]string, error) {
+	return fs.Glob(c.fsys, pattern)
+}
@@ -0,0 +1,40 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// DataDir returns the directory for data the server persists itself, such as
+// benchmark results. DATA_DIR overrides the default ./data.
+func DataDir() string {
+	dir := os.Getenv("DATA_DIR")
+	if dir == "" {
+		dir = "data"
+	}
+	if absDir, err := filepath.Abs(dir); err == nil {
+		return absDir
+	}
+	return dir
+}
+
+// writeFileAtomic replaces path with data so readers never see a partial file
+func writeFileAtomic(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,197 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+
+	"web-ui/internal/models"
+)
+
+// diffContextLines is the number of unchanged lines shown around each change
+const diffContextLines = 3
+
+// maxDiffCells bounds the line comparison table; larger changes are shown as a full replacement
+const maxDiffCells = 4 << 20
+
+// DiffSubmissions compares the files of two submissions
+func DiffSubmissions(from, to *models.Submission) models.SubmissionDiff {
+	fromFiles := SubmissionFiles(from.Code, from.Files)
+	toFiles := SubmissionFiles(to.Code, to.Files)
+
+	names := SortedFileNames(fromFiles)
+	for _, name := range SortedFileNames(toFiles) {
+		if _, ok := fromFiles[name]; !ok {
+			names = append(names, name)
+		}
+	}
+
+	diff := models.SubmissionDiff{From: from.Summary(), To: to.Summary(), Files: []models.FileDiff{}}
+	for _, name := range names {
+		before, inFrom := fromFiles[name]
+		after, inTo := toFiles[name]
+		file := models.FileDiff{Name: name}
+		switch {
+		case !inFrom:
+			file.Status = "added"
+		case !inTo:
+			file.Status = "removed"
+		case before == after:
+			file.Status = "unchanged"
+		default:
+			file.Status = "modified"
+		}
+		if file.Status != "unchanged" {
+			file.Diff = UnifiedDiff(name, before, after)
+		}
+		diff.Files = append(diff.Files, file)
+	}
+	return diff
+}
+
+// diffLine is a line of an edit script: ' ' kept, '-' removed or '+' added
+type diffLine struct {
+	op   byte
+	text string
+}
+
+// UnifiedDiff returns the unified diff between two versions of a file, or "" if they are equal
+func UnifiedDiff(name, before, after string) string {
+	if before == after {
+		return ""
+	}
+	lines := diffLines(splitLines(before), splitLines(after))
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- a/%s\n+++ b/%s\n", name, name)
+	for start := 0; start < len(lines); {
+		// Find the next change and extend the hunk while changes are close together
+		first := start
+		for first < len(lines) && lines[first].op == ' ' {
+			first++
+		}
+		if first == len(lines) {
+			break
+		}
+		hunkStart := max(first-diffContextLines, start)
+		hunkEnd := first
+		for i := first; i < len(lines); i++ {
+			if lines[i].op != ' ' {
+				hunkEnd = i + 1
+			} else if i-hunkEnd >= 2*diffContextLines {
+				break
+			}
+		}
+		hunkEnd = min(hunkEnd+diffContextLines, len(lines))
+
+		oldStart, newStart := 1, 1
+		for _, line := range lines[:hunkStart] {
+			if line.op != '+' {
+				oldStart++
+			}
+			if line.op != '-' {
+				newStart++
+			}
+		}
+		oldCount, newCount := 0, 0
+		for _, line := range lines[hunkStart:hunkEnd] {
+			if line.op != '+' {
+				oldCount++
+			}
+			if line.op != '-' {
+				newCount++
+			}
+		}
+		fmt.Fprintf(&out, "@@ -%s +%s @@\n", hunkRange(oldStart, oldCount), hunkRange(newStart, newCount))
+		for _, line := range lines[hunkStart:hunkEnd] {
+			out.WriteByte(line.op)
+			out.WriteString(line.text)
+			out.WriteByte('\n')
+		}
+		start = hunkEnd
+	}
+	return out.String()
+}
+
+// hunkRange formats the start and length of a hunk side
+func hunkRange(start, count int) string {
+	if count == 0 {
+		// An empty side points at the line before it
+		return fmt.Sprintf("%d,0", start-1)
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
+
+// splitLines splits text into lines without their terminators
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
+
+// diffLines computes an edit script from a longest common subsequence of lines
+func diffLines(a, b []string) []diffLine {
+	// Common prefix and suffix need no table
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	var lines []diffLine
+	for _, text := range a[:prefix] {
+		lines = append(lines, diffLine{' ', text})
+	}
+	middleA, middleB := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+	n, m := len(middleA), len(middleB)
+
+	if (n+1)*(m+1) > maxDiffCells {
+		for _, text := range middleA {
+			lines = append(lines, diffLine{'-', text})
+		}
+		for _, text := range middleB {
+			lines = append(lines, diffLine{'+', text})
+		}
+	} else {
+		// lcs[i][j] is the LCS length of middleA[i:] and middleB[j:]
+		lcs := make([][]int32, n+1)
+		for i := range lcs {
+			lcs[i] = make([]int32, m+1)
+		}
+		for i := n - 1; i >= 0; i-- {
+			for j := m - 1; j >= 0; j-- {
+				if middleA[i] == middleB[j] {
+					lcs[i][j] = lcs[i+1][j+1] + 1
+				} else {
+					lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+				}
+			}
+		}
+		i, j := 0, 0
+		for i < n || j < m {
+			switch {
+			case i < n && j < m && middleA[i] == middleB[j]:
+				lines = append(lines, diffLine{' ', middleA[i]})
+				i++
+				j++
+			case i < n && (j == m || lcs[i+1][j] >= lcs[i][j+1]):
+				lines = append(lines, diffLine{'-', middleA[i]})
+				i++
+			default:
+				lines = append(lines, diffLine{'+', middleB[j]})
+				j++
+			}
+		}
+	}
+
+	for _, text := range a[len(a)-suffix:] {
+		lines = append(lines, diffLine{' ', text})
+	}
+	return lines
+}
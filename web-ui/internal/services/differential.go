@@ -0,0 +1,444 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"web-ui/internal/models"
+)
+
+// DifferentialGenerator is the function a challenge's reference/*_test.go files
+// declare to produce random arguments for each compared function:
+//
+//	func generateDifferentialInput(function string, r *rand.Rand, size int) []any
+const DifferentialGenerator = "generateDifferentialInput"
+
+// Seed of the random inputs, fixed so counterexamples are reproducible
+const differentialSeed = 1
+
+// differentialHarness is the test added to the workspace for a differential run.
+// Identifiers are prefixed so they cannot clash with the submission's package.
+var differentialHarness = template.Must(template.New("differential").Parse(`package main
+
+import (
+	dfjson "encoding/json"
+	dffmt "fmt"
+	dfrand "math/rand"
+	dfreflect "reflect"
+	dftesting "testing"
+
+	dfreference "{{.Module}}/reference"
+)
+
+var differentialFunctions = []struct {
+	name                  string
+	submission, reference any
+}{
+{{- range .Functions}}
+	{"{{.}}", {{.}}, dfreference.{{.}}},
+{{- end}}
+}
+
+type differentialCounterexample struct {
+	Function string   ` + "`json:\"function\"`" + `
+	Inputs   []string ` + "`json:\"inputs\"`" + `
+	Got      string   ` + "`json:\"got\"`" + `
+	Want     string   ` + "`json:\"want\"`" + `
+}
+
+func TestDifferential(t *dftesting.T) {
+	report := struct {
+		Cases           int                          ` + "`json:\"cases\"`" + `
+		Counterexamples []differentialCounterexample ` + "`json:\"counterexamples\"`" + `
+	}{Counterexamples: []differentialCounterexample{}}
+
+	for _, target := range differentialFunctions {
+		r := dfrand.New(dfrand.NewSource({{.Seed}}))
+		for i := 0; i < {{.Cases}}; i++ {
+			args := {{.Generator}}(target.name, r, 1+i*{{.MaxSize}}/{{.Cases}})
+			report.Cases++
+			if _, _, differs := differentialRun(target.submission, target.reference, args); !differs {
+				continue
+			}
+
+			args = differentialShrink(target.submission, target.reference, args)
+			got, want, _ := differentialRun(target.submission, target.reference, args)
+			inputs := make([]string, len(args))
+			for j, arg := range args {
+				inputs[j] = dffmt.Sprintf("%#v", arg)
+			}
+			report.Counterexamples = append(report.Counterexamples, differentialCounterexample{target.name, inputs, got, want})
+			t.Errorf("%s differs from the reference solution", target.name)
+			break
+		}
+	}
+
+	data, _ := dfjson.Marshal(report)
+	dffmt.Printf("\n{{.Marker}} %s\n", data)
+}
+
+// differentialRun calls both implementations. Inputs the reference panics on are not valid and never differ.
+func differentialRun(submission, reference any, args []any) (string, string, bool) {
+	want, wantPanic := differentialCall(reference, args)
+	if wantPanic != "" {
+		return "", "", false
+	}
+	got, gotPanic := differentialCall(submission, args)
+	if gotPanic != "" {
+		return "panic: " + gotPanic, differentialFormat(want), true
+	}
+	return differentialFormat(got), differentialFormat(want), !dfreflect.DeepEqual(got, want)
+}
+
+// differentialCall calls fn with copies of args so neither implementation sees the other's mutations
+func differentialCall(fn any, args []any) (results []any, panicked string) {
+	defer func() {
+		if r := recover(); r != nil {
+			panicked = dffmt.Sprint(r)
+		}
+	}()
+
+	fnValue := dfreflect.ValueOf(fn)
+	in := make([]dfreflect.Value, len(args))
+	for i, arg := range args {
+		if arg == nil {
+			in[i] = dfreflect.Zero(fnValue.Type().In(i))
+		} else {
+			in[i] = differentialClone(dfreflect.ValueOf(arg))
+		}
+	}
+	for _, out := range fnValue.Call(in) {
+		results = append(results, out.Interface())
+	}
+	return results, ""
+}
+
+func differentialFormat(results []any) string {
+	if len(results) == 1 {
+		return dffmt.Sprintf("%#v", results[0])
+	}
+	formatted := "("
+	for i, result := range results {
+		if i > 0 {
+			formatted += ", "
+		}
+		formatted += dffmt.Sprintf("%#v", result)
+	}
+	return formatted + ")"
+}
+
+func differentialClone(v dfreflect.Value) dfreflect.Value {
+	switch v.Kind() {
+	case dfreflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		clone := dfreflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			clone.Index(i).Set(differentialClone(v.Index(i)))
+		}
+		return clone
+	case dfreflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		clone := dfreflect.MakeMapWithSize(v.Type(), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			clone.SetMapIndex(iter.Key(), differentialClone(iter.Value()))
+		}
+		return clone
+	case dfreflect.Ptr:
+		if v.IsNil() {
+			return v
+		}
+		clone := dfreflect.New(v.Type().Elem())
+		clone.Elem().Set(differentialClone(v.Elem()))
+		return clone
+	case dfreflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+		clone := dfreflect.New(v.Type()).Elem()
+		clone.Set(differentialClone(v.Elem()))
+		return clone
+	}
+	return v
+}
+
+// differentialShrink greedily replaces arguments with smaller ones while the implementations still differ
+func differentialShrink(submission, reference any, args []any) []any {
+	args = append([]any(nil), args...)
+	budget := 2000
+	for improved := true; improved && budget > 0; {
+		improved = false
+		for i := range args {
+			for _, candidate := range differentialCandidates(args[i]) {
+				if budget--; budget < 0 {
+					return args
+				}
+				trial := append([]any(nil), args...)
+				trial[i] = candidate
+				if _, _, differs := differentialRun(submission, reference, trial); differs {
+					args, improved = trial, true
+					break
+				}
+			}
+		}
+	}
+	return args
+}
+
+// differentialCandidates returns smaller values of the same type: shorter strings
+// and slices, slices with smaller elements, and numbers closer to zero
+func differentialCandidates(arg any) []any {
+	if arg == nil {
+		return nil
+	}
+	v := dfreflect.ValueOf(arg)
+	var candidates []dfreflect.Value
+	switch v.Kind() {
+	case dfreflect.String:
+		runes := []rune(v.String())
+		for _, cut := range differentialCuts(len(runes)) {
+			shorter := append(append([]rune{}, runes[:cut[0]]...), runes[cut[1]:]...)
+			candidates = append(candidates, dfreflect.ValueOf(string(shorter)).Convert(v.Type()))
+		}
+	case dfreflect.Slice:
+		for _, cut := range differentialCuts(v.Len()) {
+			shorter := dfreflect.AppendSlice(dfreflect.MakeSlice(v.Type(), 0, v.Len()), v.Slice(0, cut[0]))
+			candidates = append(candidates, dfreflect.AppendSlice(shorter, v.Slice(cut[1], v.Len())))
+		}
+		for i := 0; i < v.Len() && i < 16; i++ {
+			if v.Index(i).Kind() == dfreflect.Interface && v.Index(i).IsNil() {
+				continue
+			}
+			for _, smaller := range differentialCandidates(v.Index(i).Interface()) {
+				clone := differentialClone(v)
+				clone.Index(i).Set(dfreflect.ValueOf(smaller))
+				candidates = append(candidates, clone)
+			}
+		}
+	case dfreflect.Int, dfreflect.Int8, dfreflect.Int16, dfreflect.Int32, dfreflect.Int64:
+		n, step := v.Int(), int64(1)
+		if n < 0 {
+			step = -1
+		}
+		for _, m := range []int64{0, n / 2, n - step} {
+			if n != 0 && m != n {
+				candidates = append(candidates, dfreflect.ValueOf(m).Convert(v.Type()))
+			}
+		}
+	case dfreflect.Uint, dfreflect.Uint8, dfreflect.Uint16, dfreflect.Uint32, dfreflect.Uint64:
+		n := v.Uint()
+		for _, m := range []uint64{0, n / 2, n - 1} {
+			if m < n {
+				candidates = append(candidates, dfreflect.ValueOf(m).Convert(v.Type()))
+			}
+		}
+	}
+
+	smaller := make([]any, len(candidates))
+	for i, candidate := range candidates {
+		smaller[i] = candidate.Interface()
+	}
+	return smaller
+}
+
+// differentialCuts returns [start, end) ranges to remove from a sequence of length n, largest first
+func differentialCuts(n int) [][2]int {
+	var cuts [][2]int
+	for size := n; size > 0; size /= 2 {
+		for start := 0; start+size <= n && len(cuts) < 64; start += size {
+			cuts = append(cuts, [2]int{start, start + size})
+		}
+	}
+	return cuts
+}
+`))
+
+// runDifferential compares the submission with the challenge's reference solution
+// on generated inputs. The reference is added as the package "<module>/reference"
+// and the generator next to the harness, all compiled in through a -overlay and
+// deleted before the comparison runs.
+func (es *ExecutionService) runDifferential(ctx context.Context, tempDir, workDir string, spec workspaceSpec) *models.DifferentialReport {
+	report := &models.DifferentialReport{}
+
+	marker, files, err := differentialFiles(spec)
+	if err == nil {
+		overlayDir := filepath.Join(tempDir, "differential")
+		var overlay string
+		overlay, err = writeOverlay(overlayDir, workDir, files)
+		if err == nil {
+			// vet cannot run on a package that only exists in the overlay
+			binary := filepath.Join(tempDir, "differential.test")
+			build := es.buildOverlayTests(ctx, workDir, overlayDir, overlay, binary, spec, []string{"-vet=off"}, es.moduleCache.Env())
+			switch {
+			case build.TerminationReason != "":
+				report.Error = build.TerminationReason
+				return report
+			case build.Err == nil && build.ExitCode != 0:
+				report.Error = "the submission does not match the signatures of the reference solution:\n" + strings.TrimSpace(build.Output)
+				return report
+			case build.Err == nil:
+				run := es.sandbox.Run(ctx, SandboxCommand{
+					Dir:    workDir,
+					Args:   []string{binary, "-test.run", "^TestDifferential$"},
+					Env:    es.moduleCache.Env(),
+					GoRoot: spec.GoRoot,
+					Limits: spec.Limits,
+				})
+				return parseDifferentialOutput(report, marker, run)
+			}
+			err = build.Err
+		}
+	}
+
+	fmt.Printf("Warning: could not prepare differential test for challenge %d: %v\n", spec.ChallengeID, err)
+	report.Error = "the reference solution could not be prepared"
+	return report
+}
+
+// differentialFiles returns the overlay files of a differential run and the marker of its result line
+func differentialFiles(spec workspaceSpec) (string, map[string]string, error) {
+	marker, err := resultMarker("DIFFERENTIAL")
+	if err != nil {
+		return "", nil, err
+	}
+
+	config := spec.Differential
+	cases, maxSize := config.Cases, config.MaxSize
+	if cases <= 0 {
+		cases = 200
+	}
+	if maxSize <= 0 {
+		maxSize = 50
+	}
+
+	var harness bytes.Buffer
+	err = differentialHarness.Execute(&harness, map[string]interface{}{
+		"Module":    spec.ModuleName,
+		"Functions": config.Functions,
+		"Generator": DifferentialGenerator,
+		"Cases":     cases,
+		"MaxSize":   maxSize,
+		"Seed":      differentialSeed,
+		"Marker":    marker,
+	})
+	if err != nil {
+		return "", nil, err
+	}
+
+	files := map[string]string{"differential_harness_test.go": harness.String()}
+	for name, content := range spec.ReferenceFiles {
+		if strings.HasSuffix(name, "_test.go") {
+			// The generator runs next to the harness, in the submission's package
+			files["reference_"+name] = content
+			continue
+		}
+		renamed, err := renamePackage(content, "reference")
+		if err != nil {
+			return "", nil, fmt.Errorf("%s: %v", name, err)
+		}
+		files[filepath.Join("reference", name)] = renamed
+	}
+	return marker, files, nil
+}
+
+// parseDifferentialOutput reads the result line of the differential harness
+func parseDifferentialOutput(report *models.DifferentialReport, marker string, run SandboxResult) *models.DifferentialReport {
+	for _, line := range strings.Split(run.Output, "\n") {
+		data := strings.TrimPrefix(strings.TrimSpace(line), marker+" ")
+		if data == strings.TrimSpace(line) {
+			continue
+		}
+		if err := json.Unmarshal([]byte(data), report); err == nil {
+			report.Passed = len(report.Counterexamples) == 0 && run.ExitCode == 0 && run.Err == nil
+			if !report.Passed && len(report.Counterexamples) == 0 {
+				report.Error = "the submission exited with an error during the comparison"
+			}
+			return report
+		}
+	}
+
+	// No result line: the run was cut short
+	switch {
+	case run.TerminationReason != "":
+		report.Error = run.TerminationReason
+	default:
+		report.Error = "the comparison did not complete"
+	}
+	return report
+}
+
+// renamePackage rewrites the package clause of a Go source file
+func renamePackage(source, name string) (string, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", source, parser.PackageClauseOnly)
+	if err != nil {
+		return "", err
+	}
+	start := fset.Position(file.Name.Pos()).Offset
+	end := fset.Position(file.Name.End()).Offset
+	return source[:start] + name + source[end:], nil
+}
+
+// DifferentialFunctions returns the exported functions declared by both the
+// challenge template and the reference solution, which are the ones compared
+func DifferentialFunctions(template string, referenceFiles map[string]string) []string {
+	templateFunctions := make(map[string]bool)
+	for _, name := range declaredFunctions(template) {
+		templateFunctions[name] = true
+	}
+
+	var functions []string
+	for _, fileName := range SortedFileNames(referenceFiles) {
+		if strings.HasSuffix(fileName, "_test.go") {
+			continue
+		}
+		for _, name := range declaredFunctions(referenceFiles[fileName]) {
+			if templateFunctions[name] && ast.IsExported(name) {
+				functions = append(functions, name)
+			}
+		}
+	}
+	return functions
+}
+
+// HasDifferentialGenerator reports whether the reference test files declare the input generator
+func HasDifferentialGenerator(referenceFiles map[string]string) bool {
+	for name, content := range referenceFiles {
+		if !strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		for _, function := range declaredFunctions(content) {
+			if function == DifferentialGenerator {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// declaredFunctions returns the package-level functions declared in a Go source file
+func declaredFunctions(source string) []string {
+	file, err := parser.ParseFile(token.NewFileSet(), "", source, 0)
+	if err != nil {
+		return nil
+	}
+	var names []string
+	for _, decl := range file.Decls {
+		if fn, ok := decl.(*ast.FuncDecl); ok && fn.Recv == nil {
+			names = append(names, fn.Name.Name)
+		}
+	}
+	return names
+}
@@ -1,10 +1,10 @@
 package services
 
 import (
+	"context"
 	"fmt"
 	"io/ioutil"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strings"
 	"time"
@@ -13,23 +13,190 @@ import (
 )
 
 // ExecutionService handles code execution and testing
-type ExecutionService struct{}
+type ExecutionService struct {
+	sandbox     *Sandbox
+	moduleCache *ModuleCache
+	toolchains  *Toolchains
+	queue       *ExecutionQueue
+	languages   *LanguageServers
+	submissions *SubmissionPaths
+}
+
+// NewExecutionService creates a new execution service that saves submissions into content
+func NewExecutionService(content *ContentRoot) *ExecutionService {
+	moduleCache := NewModuleCache()
+	es := &ExecutionService{
+		sandbox:     NewSandbox(),
+		moduleCache: moduleCache,
+		toolchains:  NewToolchains(moduleCache.Dir()),
+		queue:       NewExecutionQueue(),
+		submissions: NewSubmissionPaths(content),
+	}
+	es.languages = NewLanguageServers(es)
+	return es
+}
 
-// NewExecutionService creates a new execution service
-func NewExecutionService() *ExecutionService {
-	return &ExecutionService{}
+// Queue returns the queue that bounds concurrent runs
+func (es *ExecutionService) Queue() *ExecutionQueue {
+	return es.queue
+}
+
+// Toolchains returns the Go toolchains runs can be pinned to
+func (es *ExecutionService) Toolchains() *Toolchains {
+	return es.toolchains
+}
+
+// LanguageServers returns the gopls sessions of the browser editors
+func (es *ExecutionService) LanguageServers() *LanguageServers {
+	return es.languages
+}
+
+// SubmissionPaths returns the resolver of the directories submissions are saved to
+func (es *ExecutionService) SubmissionPaths() *SubmissionPaths {
+	return es.submissions
 }
 
 // ExecutionResult represents the result of code execution
 type ExecutionResult struct {
-	Passed      bool   `json:"passed"`
-	Output      string `json:"output"`
-	ExecutionMs int64  `json:"executionMs"`
+	Passed            bool                       `json:"passed"`
+	Output            string                     `json:"output"`
+	ExecutionMs       int64                      `json:"executionMs"`
+	Termination       string                     `json:"termination,omitempty"`       // "timeout", "oom", "signal" or "canceled" when a limit stopped the run
+	TerminationReason string                     `json:"terminationReason,omitempty"` // Human readable explanation of Termination
+	Report            *models.TestReport         `json:"report,omitempty"`            // Per-test results parsed from go test -json
+	Options           models.RunOptions          `json:"options"`                     // Checks that were performed
+	VetIssues         []models.SourceLocation    `json:"vetIssues,omitempty"`         // go vet diagnostics when Options.Vet is set
+	Coverage          *models.CoverageReport     `json:"coverage,omitempty"`          // Coverage when Options.Cover is set
+	Benchmarks        *models.BenchmarkReport    `json:"benchmarks,omitempty"`        // Benchmark grading when Options.Bench is set
+	Fuzz              *models.FuzzReport         `json:"fuzz,omitempty"`              // Fuzzing outcome when Options.Fuzz is set
+	Differential      *models.DifferentialReport `json:"differential,omitempty"`      // Reference comparison when Options.Differential is set
+	Hidden            *models.HiddenTestReport   `json:"hidden,omitempty"`            // Hidden test statuses on submit
+	Analysis          *models.AnalysisReport     `json:"analysis,omitempty"`          // Formatting, imports, vet and analyzer findings, reported on every run
+	GoVersion         string                     `json:"goVersion,omitempty"`         // Toolchain that ran the tests, e.g. "go1.25.1"
+	QueuePosition     int                        `json:"queuePosition,omitempty"`     // Position when the run was queued, 0 if it started immediately
+	QueuedMs          int64                      `json:"queuedMs,omitempty"`          // Time spent waiting for a worker
+}
+
+// RunCode executes the submitted files against a challenge's tests inside the sandbox.
+// The challenge's required options are always added to the requested ones.
+func (es *ExecutionService) RunCode(ctx context.Context, files map[string]string, challenge *models.Challenge, options models.RunOptions) ExecutionResult {
+	return es.RunCodeStream(ctx, files, challenge, options, nil)
+}
+
+// RunCodeStream is RunCode that reports progress to onEvent while the tests run
+func (es *ExecutionService) RunCodeStream(ctx context.Context, files map[string]string, challenge *models.Challenge, options models.RunOptions, onEvent func(RunEvent)) ExecutionResult {
+	return es.runWorkspace(ctx, challengeWorkspace(files, challenge, options, onEvent))
+}
+
+// SubmitCode is RunCode for a submission: the challenge's hidden tests run as
+// well and must pass, but only their names and statuses are reported.
+func (es *ExecutionService) SubmitCode(ctx context.Context, files map[string]string, challenge *models.Challenge, options models.RunOptions) ExecutionResult {
+	spec := challengeWorkspace(files, challenge, options, nil)
+	if challenge.HiddenTestFile != "" {
+		spec.HiddenFiles = map[string]string{"hidden_test.go": challenge.HiddenTestFile}
+	}
+	return es.runWorkspace(ctx, spec)
+}
+
+// challengeWorkspace describes a test run of a classic challenge
+func challengeWorkspace(files map[string]string, challenge *models.Challenge, options models.RunOptions, onEvent func(RunEvent)) workspaceSpec {
+	return workspaceSpec{
+		Files:          withTestFile(files, "solution_test.go", challenge.TestFile),
+		Sources:        files,
+		ModuleName:     fmt.Sprintf("challenge-%d", challenge.ID),
+		ModuleFile:     challenge.ModuleFile,
+		ModuleSum:      challenge.ModuleSum,
+		Code:           joinSources(files),
+		ChallengeID:    challenge.ID,
+		Limits:         challenge.Limits,
+		Options:        options.Merge(challenge.RequiredOptions),
+		Toolchain:      challenge.Toolchain,
+		Benchmark:      challenge.Benchmark,
+		BaselineFiles:  map[string]string{MainSolutionFile: challenge.Template},
+		Fuzz:           challenge.Fuzz,
+		FuzzFiles:      challenge.FuzzFiles,
+		Differential:   challenge.Differential,
+		ReferenceFiles: challenge.ReferenceFiles,
+		OnEvent:        onEvent,
+	}
+}
+
+// RunPackageChallenge executes the submitted files against a package challenge's tests.
+// It mirrors the package run_tests.sh: the pinned go.mod/go.sum are copied with the
+// module renamed to "challenge" and the solution sits next to solution-template_test.go.
+// A non-empty toolchain pins the run to that Go version.
+func (es *ExecutionService) RunPackageChallenge(ctx context.Context, files map[string]string, challenge *models.PackageChallenge, toolchain string) ExecutionResult {
+	spec := packageWorkspace(files, challenge)
+	spec.Files = withTestFile(files, "solution-template_test.go", challenge.TestFile)
+	spec.Options.Toolchain = toolchain
+	return es.runWorkspace(ctx, spec)
+}
+
+// packageWorkspace describes a workspace of a package challenge holding files
+func packageWorkspace(files map[string]string, challenge *models.PackageChallenge) workspaceSpec {
+	return workspaceSpec{
+		Files:      files,
+		Sources:    files,
+		ModuleName: "challenge",
+		ModuleFile: challenge.ModuleFile,
+		ModuleSum:  challenge.ModuleSum,
+		Code:       joinSources(files),
+		Toolchain:  challenge.Toolchain,
+	}
+}
+
+// withTestFile returns the workspace files: the submitted files plus the challenge test file
+func withTestFile(files map[string]string, testName, testContent string) map[string]string {
+	workspace := make(map[string]string, len(files)+1)
+	for name, content := range files {
+		workspace[name] = content
+	}
+	workspace[testName] = testContent
+	return workspace
+}
+
+// workspaceSpec describes the files and module of a single test run
+type workspaceSpec struct {
+	Files          map[string]string // File name to content, written into the workspace
+	Sources        map[string]string // The submitted files, statically analyzed
+	HiddenFiles    map[string]string // Tests kept out of the workspace and run in a second, unreported pass
+	ModuleName     string
+	ModuleFile     string // Pinned go.mod; empty to initialize a module and detect dependencies
+	ModuleSum      string
+	Code           string // All submitted sources, used for dependency detection
+	ChallengeID    int    // Classic challenge ID for known dependencies, 0 otherwise
+	Limits         models.ExecutionLimits
+	Options        models.RunOptions
+	Toolchain      *models.ToolchainRequirement // Declared by the challenge; Options.Toolchain overrides the target
+	GoRoot         string                       // Selected toolchain, set by runWorkspace
+	Benchmark      *models.BenchmarkConfig      // Graded when Options.Bench is set
+	BaselineFiles  map[string]string            // Reference sources the benchmark baselines run with
+	Fuzz           *models.FuzzConfig           // Fuzzed when Options.Fuzz is set
+	FuzzFiles      map[string]string            // Fuzz targets and reference, added like HiddenFiles
+	Differential   *models.DifferentialConfig   // Compared when Options.Differential is set
+	ReferenceFiles map[string]string            // Reference solution and input generator, added like HiddenFiles
+	OnEvent        func(RunEvent)               // Optional progress callback
 }
 
-// RunCode executes the provided code against a challenge's tests
-func (es *ExecutionService) RunCode(code string, challenge *models.Challenge) ExecutionResult {
+// runWorkspace prepares a workspace from spec and runs its tests inside the sandbox
+func (es *ExecutionService) runWorkspace(ctx context.Context, spec workspaceSpec) ExecutionResult {
 	start := time.Now()
+	emit := func(event RunEvent) {
+		if spec.OnEvent != nil {
+			spec.OnEvent(event)
+		}
+	}
+	emit(RunEvent{Type: RunEventStatus, Message: "Preparing workspace"})
+
+	toolchain, err := es.toolchains.Select(spec.Toolchain, spec.Options.Toolchain)
+	if err != nil {
+		return ExecutionResult{
+			Passed:  false,
+			Output:  fmt.Sprintf("No suitable Go toolchain: %v", err),
+			Options: spec.Options,
+		}
+	}
+	spec.GoRoot = toolchain.GoRoot
 
 	// Create temporary directory for execution
 	tempDir, err := ioutil.TempDir("", "challenge-exec")
@@ -39,86 +206,210 @@ func (es *ExecutionService) RunCode(code string, challenge *models.Challenge) Ex
 			Output: fmt.Sprintf("Failed to create temporary directory: %v", err),
 		}
 	}
-	defer os.RemoveAll(tempDir)
+	defer removeWorkspace(tempDir)
 
-	// Write the submitted code to temporary file
-	codePath := filepath.Join(tempDir, "solution-template.go")
-	err = ioutil.WriteFile(codePath, []byte(code), 0644)
-	if err != nil {
+	// The workspace holds only the sources; scratch space lives next to it
+	workDir := filepath.Join(tempDir, "workspace")
+	if err := os.Mkdir(workDir, 0755); err != nil {
 		return ExecutionResult{
 			Passed: false,
-			Output: fmt.Sprintf("Failed to write code file: %v", err),
+			Output: fmt.Sprintf("Failed to create workspace: %v", err),
 		}
 	}
 
-	// Write the test file to temporary directory
-	testPath := filepath.Join(tempDir, "solution_test.go")
-	err = ioutil.WriteFile(testPath, []byte(challenge.TestFile), 0644)
-	if err != nil {
-		return ExecutionResult{
-			Passed: false,
-			Output: fmt.Sprintf("Failed to write test file: %v", err),
+	// Write the submitted code and tests to the workspace
+	for name, content := range spec.Files {
+		err = ioutil.WriteFile(filepath.Join(workDir, name), []byte(content), 0644)
+		if err != nil {
+			return ExecutionResult{
+				Passed: false,
+				Output: fmt.Sprintf("Failed to write %s: %v", name, err),
+			}
 		}
 	}
 
-	// Initialize Go module
-	err = es.initGoModule(tempDir, challenge.ID)
+	// Set up the module and resolve dependencies from the shared cache
+	emit(RunEvent{Type: RunEventStatus, Message: "Resolving dependencies"})
+	err = es.prepareModule(ctx, workDir, spec)
 	if err != nil {
 		return ExecutionResult{
 			Passed: false,
-			Output: fmt.Sprintf("Failed to initialize Go module: %v", err),
+			Output: fmt.Sprintf("Failed to install dependencies: %v", err),
 		}
 	}
 
-	// Automatically detect and install dependencies based on imports
-	err = es.installDependencies(tempDir, code, challenge.ID)
-	if err != nil {
+	// Submitted code must not be able to modify its own workspace
+	if err := makeWorkspaceReadOnly(workDir); err != nil {
 		return ExecutionResult{
 			Passed: false,
-			Output: fmt.Sprintf("Failed to install dependencies: %v", err),
+			Output: fmt.Sprintf("Failed to prepare workspace: %v", err),
 		}
 	}
 
 	// Run tests
-	cmd := exec.Command("go", "test", "-v")
-	cmd.Dir = tempDir
-
-	output, err := cmd.CombinedOutput()
+	emit(RunEvent{Type: RunEventStatus, Message: "Compiling and running tests"})
+	limits := mergeLimits(spec.Limits)
+	testCommand := SandboxCommand{
+		Dir:    workDir,
+		Args:   []string{"go", "test", "-json", "-timeout", fmt.Sprintf("%ds", limits.TimeoutSeconds)},
+		Env:    es.moduleCache.Env(),
+		GoRoot: spec.GoRoot,
+		Limits: limits,
+	}
+	coverProfile := filepath.Join(scratchDir(workDir), "cover.out")
+	testCommand.Args, testCommand.Env = testArgsForOptions(testCommand.Args, testCommand.Env, spec.Options, coverProfile)
+	if spec.OnEvent != nil {
+		testCommand.OnLine = func(line string) {
+			for _, event := range runEventsFromLine(line) {
+				spec.OnEvent(event)
+			}
+		}
+	}
+	run := es.sandbox.Run(ctx, testCommand)
 	executionTime := time.Since(start).Milliseconds()
-	outputStr := string(output)
 
+	report, output := parseTestJSON(run.Output)
 	result := ExecutionResult{
-		Output:      outputStr,
-		ExecutionMs: executionTime,
+		Passed:            run.Err == nil && run.ExitCode == 0 && run.Termination == "" && report.Failed == 0,
+		Output:            output,
+		ExecutionMs:       executionTime,
+		Termination:       run.Termination,
+		TerminationReason: run.TerminationReason,
+		Report:            report,
+		Options:           spec.Options,
+		GoVersion:         toolchain.Version,
+		Analysis:          AnalyzeFiles(spec.Sources),
 	}
 
-	if err == nil {
-		result.Passed = true
-	} else {
-		// Check if tests ran but failed (this is the key logic!)
-		if _, ok := err.(*exec.ExitError); ok {
-			// Test ran but failed - this means tests executed but some failed
-			result.Passed = false // Tests failed, so Passed = false
-		} else {
-			// Command couldn't be run - this is a real error
+	// Extra checks only make sense once the package compiles
+	if !report.BuildFailed && run.Termination == "" {
+		if spec.Options.Cover {
+			result.Coverage = es.collectCoverage(ctx, workDir, spec.GoRoot, coverProfile)
+		}
+		// go vet is always part of the analysis report; its findings only fail the run when requested
+		emit(RunEvent{Type: RunEventStatus, Message: "Running go vet"})
+		vetOutput, issues := es.runVet(ctx, workDir, spec.GoRoot)
+		MergeVetDiagnostics(result.Analysis, issues, spec.Sources)
+		if spec.Options.Vet {
+			result.VetIssues = issues
+			if len(issues) > 0 {
+				result.Passed = false
+				output += "\n# go vet\n" + vetOutput
+				result.Output = output
+			}
+		}
+		if spec.Options.Race && report.DataRaces > 0 {
 			result.Passed = false
-			result.Output = fmt.Sprintf("Failed to run tests: %v\n%s", err, outputStr)
 		}
+		if len(spec.HiddenFiles) > 0 {
+			emit(RunEvent{Type: RunEventStatus, Message: "Running hidden tests"})
+			result.Hidden = es.runHiddenTests(ctx, tempDir, workDir, spec)
+			if result.Hidden.BuildFailed || result.Hidden.Failed > 0 {
+				result.Passed = false
+			}
+		}
+
+		// Comparisons, fuzzing and performance grading only make sense for correct solutions
+		if spec.Options.Differential && spec.Differential != nil && result.Passed {
+			emit(RunEvent{Type: RunEventStatus, Message: "Comparing with the reference solution"})
+			result.Differential = es.runDifferential(ctx, tempDir, workDir, spec)
+			if !result.Differential.Passed {
+				result.Passed = false
+			}
+		}
+		if spec.Options.Fuzz && spec.Fuzz != nil && len(spec.FuzzFiles) > 0 && result.Passed {
+			emit(RunEvent{Type: RunEventStatus, Message: "Fuzzing against the reference implementation"})
+			result.Fuzz = es.runFuzz(ctx, tempDir, workDir, spec)
+			if !result.Fuzz.Passed {
+				result.Passed = false
+			}
+		}
+		if spec.Options.Bench && spec.Benchmark != nil && result.Passed {
+			emit(RunEvent{Type: RunEventStatus, Message: "Running benchmarks"})
+			benchmarks, benchOutput := es.runBenchmarks(ctx, tempDir, workDir, spec, limits)
+			result.Benchmarks = benchmarks
+			output += "\n# benchmarks\n" + benchOutput
+			result.Output = output
+			if !benchmarks.Passed {
+				result.Passed = false
+			}
+		}
+		result.ExecutionMs = time.Since(start).Milliseconds()
+	}
+
+	if run.Err != nil && run.Termination == "" {
+		// Command couldn't be run - this is a real error
+		result.Output = fmt.Sprintf("Failed to run tests: %v\n%s", run.Err, run.Output)
+	}
+	if result.TerminationReason != "" {
+		result.Output = fmt.Sprintf("%s\n\n[sandbox] %s", result.Output, result.TerminationReason)
 	}
 
 	return result
 }
 
+// runSetupCommand runs a module setup step (go mod init, go get, ...) in the sandbox.
+// Setup steps may reach the module proxy and add to the shared module cache but
+// are still time and memory bounded; they don't run submitted code.
+func (es *ExecutionService) runSetupCommand(ctx context.Context, dir, goRoot string, args ...string) (string, error) {
+	run := es.sandbox.Run(ctx, SandboxCommand{
+		Dir:           dir,
+		Args:          args,
+		Env:           es.moduleCache.SetupEnv(),
+		GoRoot:        goRoot,
+		AllowNetwork:  !es.moduleCache.Offline(),
+		WritableCache: true,
+		WritableDir:   true,
+	})
+	if run.Err != nil {
+		return run.Output, run.Err
+	}
+	if run.Termination != "" {
+		return run.Output, fmt.Errorf("%s", run.TerminationReason)
+	}
+	if run.ExitCode != 0 {
+		return run.Output, fmt.Errorf("exit status %d", run.ExitCode)
+	}
+	return run.Output, nil
+}
+
+// prepareModule writes go.mod/go.sum and resolves every import of the sources and tests.
+// Challenges that ship a go.mod use their pinned versions; others fall back to import detection.
+func (es *ExecutionService) prepareModule(ctx context.Context, workDir string, spec workspaceSpec) error {
+	if spec.ModuleFile != "" {
+		goMod := rewriteModuleName(spec.ModuleFile, spec.ModuleName)
+		if err := ioutil.WriteFile(filepath.Join(workDir, "go.mod"), []byte(goMod), 0644); err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(filepath.Join(workDir, "go.sum"), []byte(spec.ModuleSum), 0644); err != nil {
+			return err
+		}
+	} else {
+		if err := es.initGoModule(ctx, workDir, spec.GoRoot, spec.ModuleName); err != nil {
+			return fmt.Errorf("failed to initialize Go module: %v", err)
+		}
+		if err := es.installDependencies(ctx, workDir, spec.GoRoot, spec.Code, spec.ChallengeID); err != nil {
+			return err
+		}
+	}
+
+	// Fill in go.sum and any requirements the submission adds; -e keeps compile errors for go test to report
+	output, err := es.runSetupCommand(ctx, workDir, spec.GoRoot, "go", "list", "-e", "-deps", "-test", "./...")
+	if err != nil {
+		return fmt.Errorf("failed to resolve modules: %v\nOutput: %s", err, output)
+	}
+	return nil
+}
+
 // initGoModule initializes a Go module in the temporary directory
-func (es *ExecutionService) initGoModule(tempDir string, challengeID int) error {
+func (es *ExecutionService) initGoModule(ctx context.Context, tempDir, goRoot, moduleName string) error {
 	// Initialize go.mod
-	cmd := exec.Command("go", "mod", "init", fmt.Sprintf("challenge-%d", challengeID))
-	cmd.Dir = tempDir
-	return cmd.Run()
+	_, err := es.runSetupCommand(ctx, tempDir, goRoot, "go", "mod", "init", moduleName)
+	return err
 }
 
 // installDependencies installs dependencies for the given challenge
-func (es *ExecutionService) installDependencies(tempDir string, code string, challengeID int) error {
+func (es *ExecutionService) installDependencies(ctx context.Context, tempDir, goRoot, code string, challengeID int) error {
 	// Detect imports from the code
 	requiredPackages := es.detectRequiredPackages(code, challengeID)
 
@@ -129,19 +420,14 @@ func (es *ExecutionService) installDependencies(tempDir string, code string, cha
 	// Install each required package
 	for _, pkg := range requiredPackages {
 		fmt.Printf("Installing dependency: %s\n", pkg)
-		cmd := exec.Command("go", "get", pkg)
-		cmd.Dir = tempDir
-
-		output, err := cmd.CombinedOutput()
+		output, err := es.runSetupCommand(ctx, tempDir, goRoot, "go", "get", pkg)
 		if err != nil {
-			return fmt.Errorf("failed to install package %s: %v\nOutput: %s", pkg, err, string(output))
+			return fmt.Errorf("failed to install package %s: %v\nOutput: %s", pkg, err, output)
 		}
 	}
 
 	// Run go mod tidy to clean up dependencies
-	tidyCmd := exec.Command("go", "mod", "tidy")
-	tidyCmd.Dir = tempDir
-	tidyCmd.Run() // Ignore errors for tidy
+	es.runSetupCommand(ctx, tempDir, goRoot, "go", "mod", "tidy") // Ignore errors for tidy
 
 	return nil
 }
@@ -277,9 +563,10 @@ func (es *ExecutionService) isExternalPackage(importPath string) bool {
 
 // SaveSubmissionRequest represents a request to save a submission to filesystem
 type SaveSubmissionRequest struct {
-	Username    string `json:"username"`
-	ChallengeID int    `json:"challengeId"`
-	Code        string `json:"code"`
+	Username    string            `json:"username"`
+	ChallengeID int               `json:"challengeId"`
+	Code        string            `json:"code"`
+	Files       map[string]string `json:"files,omitempty"` // Multi-file submissions; Code is the main file otherwise
 }
 
 // SaveSubmissionResponse represents the response from saving a submission
@@ -290,59 +577,13 @@ type SaveSubmissionResponse struct {
 	GitCommands []string `json:"gitCommands"`
 }
 
-// SaveSubmissionToFilesystem saves a user's submission to the filesystem
-func (es *ExecutionService) SaveSubmissionToFilesystem(request SaveSubmissionRequest) SaveSubmissionResponse {
-	// Get working directory for correct relative paths
-	workDir, _ := os.Getwd()
-
-	// Try different path approaches to handle potential path issues
-	var submissionDir string
-	var fileSaved bool
-
-	// Try multiple path options to ensure it works in different environments
-	pathOptions := []string{
-		// Option 1: From web-ui directory (standard case)
-		filepath.Join("..", fmt.Sprintf("challenge-%d", request.ChallengeID), "submissions", request.Username),
-		// Option 2: From root workspace
-		filepath.Join(fmt.Sprintf("challenge-%d", request.ChallengeID), "submissions", request.Username),
-		// Option 3: Absolute path from detected workspace root
-		filepath.Join(workDir, "..", fmt.Sprintf("challenge-%d", request.ChallengeID), "submissions", request.Username),
-	}
-
-	for _, dirPath := range pathOptions {
-		err := os.MkdirAll(dirPath, 0755)
-		if err != nil {
-			continue
-		}
-
-		solutionFile := filepath.Join(dirPath, "solution-template.go")
-		err = ioutil.WriteFile(solutionFile, []byte(request.Code), 0644)
-		if err != nil {
-			continue
-		}
-
-		submissionDir = dirPath
-		fileSaved = true
-		break
-	}
-
-	if !fileSaved {
-		return SaveSubmissionResponse{
-			Success: false,
-			Message: "Failed to save solution to any available path",
-		}
-	}
-
-	// Return success response with git commands
-	return SaveSubmissionResponse{
-		Success:  true,
-		Message:  "Solution saved to filesystem",
-		FilePath: filepath.Join(submissionDir, "solution-template.go"),
-		GitCommands: []string{
-			"cd " + filepath.Join(workDir, ".."),
-			fmt.Sprintf("git add %s", filepath.Join(fmt.Sprintf("challenge-%d", request.ChallengeID), "submissions", request.Username, "solution-template.go")),
-			fmt.Sprintf("git commit -m \"Add solution for Challenge %d\"", request.ChallengeID),
-			"git push origin main",
-		},
+// SaveSubmissionToFilesystem saves a user's submission into the content root.
+// The error reports an invalid username or challenge, or read-only content.
+func (es *ExecutionService) SaveSubmissionToFilesystem(request SaveSubmissionRequest) (SaveSubmissionResponse, error) {
+	dir, err := es.submissions.ChallengeDir(request.Username, request.ChallengeID)
+	if err != nil {
+		return SaveSubmissionResponse{}, err
 	}
+	files := SubmissionFiles(request.Code, request.Files)
+	return es.submissions.SaveSubmission(dir, files, MainSolutionFile, fmt.Sprintf("Add solution for Challenge %d", request.ChallengeID)), nil
 }
@@ -0,0 +1,198 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"web-ui/internal/models"
+)
+
+// Build tag of the challenge fuzz/ files, keeping them out of `go test ./...` in the challenge directory
+const fuzzBuildTag = "fuzz"
+
+// Longest failure message kept per target
+const maxFuzzMessageLines = 20
+
+// "    Failing input written to testdata/fuzz/FuzzReverse/582528ddfad69eb5"
+var fuzzFailingInputRe = regexp.MustCompile(`Failing input written to (\S+)`)
+
+// runFuzz fuzzes the submission with each target of the challenge. The targets
+// and their reference implementation are compiled in through a -overlay and
+// deleted before the fuzzing starts, like the hidden tests; the binary then runs
+// once per target in its own directory, where it stores failing inputs.
+func (es *ExecutionService) runFuzz(ctx context.Context, tempDir, workDir string, spec workspaceSpec) *models.FuzzReport {
+	report := &models.FuzzReport{Targets: []models.FuzzTargetResult{}, Passed: true}
+
+	overlayDir := filepath.Join(tempDir, "fuzz")
+	runDir := filepath.Join(tempDir, "fuzz-run")
+	overlay, err := writeOverlay(overlayDir, workDir, spec.FuzzFiles)
+	if err == nil {
+		// The binary stores failing inputs in testdata/fuzz of its directory
+		err = os.MkdirAll(filepath.Join(runDir, "testdata", "fuzz"), 0755)
+	}
+	if err != nil {
+		fmt.Printf("Warning: could not prepare fuzz targets: %v\n", err)
+		os.RemoveAll(overlayDir)
+		report.Passed = false
+		return report
+	}
+
+	// -fuzz builds the package with the coverage instrumentation that guides fuzzing
+	pattern := make([]string, len(spec.Fuzz.Targets))
+	for i, target := range spec.Fuzz.Targets {
+		pattern[i] = regexp.QuoteMeta(target)
+	}
+	binary := filepath.Join(tempDir, "fuzz.test")
+	flags := []string{"-tags", fuzzBuildTag, "-fuzz", "^(" + strings.Join(pattern, "|") + ")$"}
+	build := es.buildOverlayTests(ctx, workDir, overlayDir, overlay, binary, spec, flags, es.moduleCache.Env())
+	if build.Err != nil || build.ExitCode != 0 || build.Termination != "" {
+		message := strings.TrimSpace(build.Output)
+		switch {
+		case build.TerminationReason != "":
+			message = build.TerminationReason
+		case build.Err != nil && message == "":
+			message = build.Err.Error()
+		}
+		for _, target := range spec.Fuzz.Targets {
+			report.Targets = append(report.Targets, models.FuzzTargetResult{Name: target, Error: message})
+		}
+		report.Passed = false
+		return report
+	}
+
+	fuzztime := fuzzDuration(spec.Fuzz)
+	limits := mergeLimits(spec.Limits)
+	// Minimization needs time on top of the fuzzing itself
+	limits.TimeoutSeconds = max(limits.TimeoutSeconds, int(2*fuzztime.Seconds())+60)
+	limits.CPUSeconds = max(limits.CPUSeconds, limits.TimeoutSeconds)
+	// The fuzzing engine shares inputs with its workers through a 100MB file
+	limits.FileSizeMB = max(limits.FileSizeMB, 256)
+
+	for _, target := range spec.Fuzz.Targets {
+		run := es.sandbox.Run(ctx, SandboxCommand{
+			Dir: runDir,
+			Args: []string{
+				binary, "-test.run", "^$", "-test.fuzz", "^" + regexp.QuoteMeta(target) + "$",
+				"-test.fuzztime", fuzztime.String(), "-test.fuzzminimizetime", fuzztime.String(),
+				"-test.fuzzcachedir", filepath.Join(scratchDir(runDir), "fuzz"),
+				"-test.parallel", "2",
+			},
+			Env:         es.moduleCache.Env(),
+			Limits:      limits,
+			WritableDir: true,
+		})
+
+		result := parseFuzzOutput(target, run.Output, runDir)
+		if !result.Passed && result.Message == "" && result.Error == "" {
+			switch {
+			case run.TerminationReason != "":
+				result.Error = run.TerminationReason
+			case run.Err != nil:
+				result.Error = run.Err.Error()
+			}
+		}
+		if result.Passed && (run.ExitCode != 0 || run.Termination != "" || run.Err != nil) {
+			result.Passed = false
+			result.Error = strings.TrimSpace(run.Output)
+			if run.TerminationReason != "" {
+				result.Error = run.TerminationReason
+			}
+		}
+		if !result.Passed {
+			report.Passed = false
+		}
+		report.Targets = append(report.Targets, result)
+	}
+	return report
+}
+
+// fuzzDuration returns the configured -fuzztime of each target
+func fuzzDuration(config *models.FuzzConfig) time.Duration {
+	if duration, err := time.ParseDuration(config.Fuzztime); err == nil && duration > 0 {
+		return duration
+	}
+	return 10 * time.Second
+}
+
+// parseFuzzOutput turns the output of a fuzzing run in runDir into a target result
+func parseFuzzOutput(target, output, runDir string) models.FuzzTargetResult {
+	result := models.FuzzTargetResult{Name: target, Passed: true}
+	lines := strings.Split(output, "\n")
+
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(trimmed, "--- FAIL:"):
+			result.Passed = false
+			result.Message = fuzzFailureMessage(lines[i+1:])
+		}
+
+		if match := fuzzFailingInputRe.FindStringSubmatch(trimmed); match != nil {
+			result.Passed = false
+			if corpus, err := readFuzzInput(runDir, match[1]); err == nil {
+				result.Inputs = parseFuzzCorpus(string(corpus))
+			}
+		}
+	}
+	return result
+}
+
+// readFuzzInput reads a failing input the run reported. Submitted code writes
+// both the report and runDir, so only regular files inside runDir are read.
+func readFuzzInput(runDir, name string) ([]byte, error) {
+	root, err := filepath.EvalSymlinks(runDir)
+	if err != nil {
+		return nil, err
+	}
+	path, err := filepath.EvalSymlinks(filepath.Join(runDir, filepath.FromSlash(name)))
+	if err != nil {
+		return nil, err
+	}
+	if info, err := os.Lstat(path); err != nil || !info.Mode().IsRegular() || !isWithin(root, path) {
+		return nil, fmt.Errorf("%s is not a fuzz input of the run", name)
+	}
+	return ioutil.ReadFile(path)
+}
+
+// fuzzFailureMessage collects the failure lines that follow a "--- FAIL:" line
+func fuzzFailureMessage(lines []string) string {
+	var message []string
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "--- FAIL:") {
+			continue
+		}
+		if trimmed == "" || strings.HasPrefix(trimmed, "Failing input written to") || trimmed == "FAIL" {
+			break
+		}
+		message = append(message, trimmed)
+		if len(message) == maxFuzzMessageLines {
+			// Panics carry long stack traces; the top is enough to reproduce
+			break
+		}
+	}
+	return strings.Join(message, "\n")
+}
+
+// parseFuzzCorpus returns the values of a "go test fuzz v1" corpus file as Go literals
+func parseFuzzCorpus(corpus string) []string {
+	var inputs []string
+	for _, line := range strings.Split(corpus, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "go test fuzz") {
+			continue
+		}
+		// string("abc") reads better as "abc"; other types keep their conversion
+		if strings.HasPrefix(line, "string(") && strings.HasSuffix(line, ")") {
+			line = strings.TrimSuffix(strings.TrimPrefix(line, "string("), ")")
+		}
+		inputs = append(inputs, line)
+	}
+	return inputs
+}
@@ -0,0 +1,297 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"web-ui/internal/models"
+)
+
+// LanguageServerRoot is the workspace path editors use in LSP messages. Each
+// session maps it to its own temporary workspace, in both directions.
+const LanguageServerRoot = "/workspace"
+
+// Errors returned when a language server cannot be started or is stopped
+var (
+	ErrTooManyLanguageServers = errors.New("too many editor sessions, please try again later")
+	ErrTooManyEditorSessions  = errors.New("you already have the maximum number of editor sessions open")
+	ErrLanguageServerIdle     = errors.New("editor session closed after a period without activity")
+)
+
+// Language servers live as long as an editor tab, so they get a long wall clock
+// and a CPU budget instead of the per-run limits
+var languageServerLimits = models.ExecutionLimits{
+	TimeoutSeconds: 2 * 60 * 60,
+	CPUSeconds:     30 * 60,
+	MemoryMB:       2048,
+	FileSizeMB:     64,
+	MaxOutputKB:    64,
+}
+
+// maxLanguageServerMessage bounds a single LSP message read from gopls
+const maxLanguageServerMessage = 16 << 20
+
+// LanguageServers starts one sandboxed gopls process per editor session, in a
+// workspace seeded with the challenge template and its go.mod. The gopls binary
+// is GOPLS_PATH or gopls on PATH. GOPLS_MAX_SESSIONS caps concurrent sessions,
+// GOPLS_MAX_PER_USER those of one owner, as the execution queue counts them, and
+// GOPLS_IDLE_MINUTES stops a session the editor has not written to for so long.
+type LanguageServers struct {
+	es          *ExecutionService
+	command     string
+	maxSessions int
+	maxPerUser  int
+	idleTimeout time.Duration
+
+	mutex    sync.Mutex
+	sessions int
+	owners   map[string]int // Running sessions per owner
+}
+
+// NewLanguageServers locates gopls for the execution service's sandbox
+func NewLanguageServers(es *ExecutionService) *LanguageServers {
+	command := os.Getenv("GOPLS_PATH")
+	if command == "" {
+		command, _ = exec.LookPath("gopls")
+	}
+	if command == "" {
+		// Where go install golang.org/x/tools/gopls@latest puts it
+		if goPath := resolveGoEnv("GOPATH"); goPath != "" {
+			candidate := filepath.Join(filepath.SplitList(goPath)[0], "bin", "gopls")
+			if _, err := os.Stat(candidate); err == nil {
+				command = candidate
+			}
+		}
+	}
+
+	return &LanguageServers{
+		es:          es,
+		command:     command,
+		maxSessions: envInt("GOPLS_MAX_SESSIONS", 10),
+		maxPerUser:  envInt("GOPLS_MAX_PER_USER", 2),
+		idleTimeout: time.Duration(envInt("GOPLS_IDLE_MINUTES", 15)) * time.Minute,
+		owners:      make(map[string]int),
+	}
+}
+
+// Available reports whether gopls was found
+func (ls *LanguageServers) Available() bool {
+	return ls.command != ""
+}
+
+// StartForChallenge starts gopls for owner in a workspace with a classic challenge's template
+func (ls *LanguageServers) StartForChallenge(ctx context.Context, owner string, challenge *models.Challenge) (*LanguageServerSession, error) {
+	files := map[string]string{MainSolutionFile: challenge.Template}
+	spec := challengeWorkspace(files, challenge, models.RunOptions{}, nil)
+	spec.Files = files
+	return ls.start(ctx, owner, spec)
+}
+
+// StartForPackageChallenge starts gopls for owner in a workspace with a package challenge's template and pinned module
+func (ls *LanguageServers) StartForPackageChallenge(ctx context.Context, owner string, challenge *models.PackageChallenge) (*LanguageServerSession, error) {
+	return ls.start(ctx, owner, packageWorkspace(map[string]string{MainSolutionFile: challenge.Template}, challenge))
+}
+
+func (ls *LanguageServers) start(ctx context.Context, owner string, spec workspaceSpec) (*LanguageServerSession, error) {
+	if !ls.Available() {
+		return nil, fmt.Errorf("gopls is not installed; set GOPLS_PATH or install it with go install golang.org/x/tools/gopls@latest")
+	}
+	if err := ls.acquire(owner); err != nil {
+		return nil, err
+	}
+
+	session, err := ls.startSession(ctx, owner, spec)
+	if err != nil {
+		ls.release(owner)
+		return nil, err
+	}
+	return session, nil
+}
+
+func (ls *LanguageServers) startSession(ctx context.Context, owner string, spec workspaceSpec) (*LanguageServerSession, error) {
+	toolchain, err := ls.es.toolchains.Select(spec.Toolchain, "")
+	if err != nil {
+		return nil, fmt.Errorf("no suitable Go toolchain: %v", err)
+	}
+	spec.GoRoot = toolchain.GoRoot
+
+	tempDir, err := ioutil.TempDir("", "challenge-gopls")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary directory: %v", err)
+	}
+	workDir := filepath.Join(tempDir, "workspace")
+	if err := os.Mkdir(workDir, 0755); err != nil {
+		removeWorkspace(tempDir)
+		return nil, fmt.Errorf("failed to create workspace: %v", err)
+	}
+	for name, content := range spec.Files {
+		if err := ioutil.WriteFile(filepath.Join(workDir, name), []byte(content), 0644); err != nil {
+			removeWorkspace(tempDir)
+			return nil, fmt.Errorf("failed to write %s: %v", name, err)
+		}
+	}
+	// Resolving dependencies up front lets gopls work offline from the shared cache
+	if err := ls.es.prepareModule(ctx, workDir, spec); err != nil {
+		removeWorkspace(tempDir)
+		return nil, fmt.Errorf("failed to install dependencies: %v", err)
+	}
+
+	// Edits stay in gopls overlays; nothing is written to the workspace
+	if err := makeWorkspaceReadOnly(workDir); err != nil {
+		removeWorkspace(tempDir)
+		return nil, fmt.Errorf("failed to prepare workspace: %v", err)
+	}
+
+	process, err := ls.es.sandbox.Start(ctx, SandboxCommand{
+		Dir:    workDir,
+		Args:   []string{ls.command},
+		Env:    append(ls.es.moduleCache.Env(), "CGO_ENABLED=0"),
+		GoRoot: spec.GoRoot,
+		Limits: languageServerLimits,
+	})
+	if err != nil {
+		removeWorkspace(tempDir)
+		return nil, fmt.Errorf("failed to start gopls: %v", err)
+	}
+
+	session := &LanguageServerSession{
+		servers: ls,
+		owner:   owner,
+		process: process,
+		reader:  bufio.NewReader(process.Stdout),
+		tempDir: tempDir,
+		workDir: workDir,
+	}
+	session.idle = time.AfterFunc(ls.idleTimeout, session.closeIdle)
+	return session, nil
+}
+
+// acquire takes a session slot for owner
+func (ls *LanguageServers) acquire(owner string) error {
+	ls.mutex.Lock()
+	defer ls.mutex.Unlock()
+	if ls.owners[owner] >= ls.maxPerUser {
+		return ErrTooManyEditorSessions
+	}
+	if ls.sessions >= ls.maxSessions {
+		return ErrTooManyLanguageServers
+	}
+	ls.sessions++
+	ls.owners[owner]++
+	return nil
+}
+
+func (ls *LanguageServers) release(owner string) {
+	ls.mutex.Lock()
+	defer ls.mutex.Unlock()
+	ls.sessions--
+	if ls.owners[owner]--; ls.owners[owner] <= 0 {
+		delete(ls.owners, owner)
+	}
+}
+
+// LanguageServerSession is a running gopls process. Messages are JSON-RPC
+// payloads without the LSP Content-Length framing, with workspace paths
+// written as LanguageServerRoot.
+type LanguageServerSession struct {
+	servers *LanguageServers
+	owner   string
+	process *SandboxProcess
+	reader  *bufio.Reader
+	tempDir string
+	workDir string
+	idle    *time.Timer // Closes the session when the editor goes quiet
+
+	writeMutex sync.Mutex
+	closeOnce  sync.Once
+	idleMutex  sync.Mutex
+	idled      bool // Closed by the idle timer
+}
+
+// WriteMessage sends a message from the editor to gopls. Only the editor's
+// messages count as activity; gopls may keep talking to an abandoned tab.
+func (s *LanguageServerSession) WriteMessage(message []byte) error {
+	s.idle.Reset(s.servers.idleTimeout)
+	message = bytes.ReplaceAll(message, []byte("file://"+LanguageServerRoot), []byte("file://"+s.workDir))
+
+	s.writeMutex.Lock()
+	defer s.writeMutex.Unlock()
+	if _, err := fmt.Fprintf(s.process.Stdin, "Content-Length: %d\r\n\r\n", len(message)); err != nil {
+		return err
+	}
+	_, err := s.process.Stdin.Write(message)
+	return err
+}
+
+// ReadMessage returns the next message from gopls for the editor
+func (s *LanguageServerSession) ReadMessage() ([]byte, error) {
+	length := -1
+	for {
+		line, err := s.reader.ReadString('\n')
+		if err != nil {
+			if s.closedIdle() {
+				return nil, ErrLanguageServerIdle
+			}
+			if stderr := strings.TrimSpace(s.process.Stderr()); stderr != "" && err == io.EOF {
+				return nil, fmt.Errorf("gopls exited: %s", stderr)
+			}
+			return nil, err
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if line == "" {
+			break
+		}
+		name, value, ok := strings.Cut(line, ":")
+		if ok && strings.EqualFold(strings.TrimSpace(name), "Content-Length") {
+			length, err = strconv.Atoi(strings.TrimSpace(value))
+			if err != nil {
+				return nil, fmt.Errorf("invalid Content-Length from gopls: %v", err)
+			}
+		}
+	}
+	if length < 0 || length > maxLanguageServerMessage {
+		return nil, fmt.Errorf("invalid message length %d from gopls", length)
+	}
+
+	message := make([]byte, length)
+	if _, err := io.ReadFull(s.reader, message); err != nil {
+		return nil, err
+	}
+	return bytes.ReplaceAll(message, []byte(s.workDir), []byte(LanguageServerRoot)), nil
+}
+
+// Close stops gopls and removes the workspace
+func (s *LanguageServerSession) Close() {
+	s.closeOnce.Do(func() {
+		s.idle.Stop()
+		s.process.Stop()
+		removeWorkspace(s.tempDir)
+		s.servers.release(s.owner)
+	})
+}
+
+// closeIdle closes the session for inactivity; ReadMessage then returns ErrLanguageServerIdle
+func (s *LanguageServerSession) closeIdle() {
+	s.idleMutex.Lock()
+	s.idled = true
+	s.idleMutex.Unlock()
+	s.Close()
+}
+
+func (s *LanguageServerSession) closedIdle() bool {
+	s.idleMutex.Lock()
+	defer s.idleMutex.Unlock()
+	return s.idled
+}
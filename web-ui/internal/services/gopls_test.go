@@ -0,0 +1,31 @@
+package services
+
+import "testing"
+
+func TestLanguageServersAcquire(t *testing.T) {
+	ls := &LanguageServers{maxSessions: 3, maxPerUser: 2, owners: make(map[string]int)}
+
+	for i := 0; i < 2; i++ {
+		if err := ls.acquire("addr:203.0.113.7"); err != nil {
+			t.Fatalf("session %d: %v", i+1, err)
+		}
+	}
+	if err := ls.acquire("addr:203.0.113.7"); err != ErrTooManyEditorSessions {
+		t.Errorf("third session of one owner = %v, want %v", err, ErrTooManyEditorSessions)
+	}
+	if err := ls.acquire("user:alice"); err != nil {
+		t.Errorf("another owner's session: %v", err)
+	}
+	if err := ls.acquire("user:bob"); err != ErrTooManyLanguageServers {
+		t.Errorf("session beyond the server limit = %v, want %v", err, ErrTooManyLanguageServers)
+	}
+
+	ls.release("addr:203.0.113.7")
+	if err := ls.acquire("user:bob"); err != nil {
+		t.Errorf("session after a release: %v", err)
+	}
+	ls.release("user:alice")
+	if _, ok := ls.owners["user:alice"]; ok {
+		t.Error("owner without sessions is still tracked")
+	}
+}
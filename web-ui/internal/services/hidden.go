@@ -0,0 +1,292 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+
+	"web-ui/internal/models"
+)
+
+// hiddenHarnessTest is the test that runs the hidden tests as its subtests
+const hiddenHarnessTest = "TestHiddenHarness"
+
+// hiddenHarness is the test added to the hidden tests. It prints the status of
+// each hidden test on a line signed with the run's marker, so lines printed by
+// the submission cannot pass for results. Identifiers are prefixed so they
+// cannot clash with the package of the hidden tests.
+var hiddenHarness = template.Must(template.New("hidden").Parse(`package {{.Package}}
+
+import (
+	hdfmt "fmt"
+	hdtesting "testing"
+)
+
+func {{.Harness}}(t *hdtesting.T) {
+	for _, test := range []struct {
+		name string
+		fn   func(*hdtesting.T)
+	}{
+{{- range .Tests}}
+		{"{{.}}", {{.}}},
+{{- end}}
+	} {
+		name, fn := test.name, test.fn
+		t.Run(name, func(t *hdtesting.T) {
+			// Cleanups run once the test and its subtests are done, also after a panic
+			t.Cleanup(func() {
+				status := "{{.Pass}}"
+				if t.Failed() {
+					status = "{{.Fail}}"
+				} else if t.Skipped() {
+					status = "{{.Skip}}"
+				}
+				hdfmt.Printf("\n{{.Marker}} %s %s\n", name, status)
+			})
+			fn(t)
+		})
+	}
+}
+`))
+
+// runHiddenTests runs the hidden test files against a prepared workspace. The files
+// and their harness are compiled in through a -overlay so they never exist in the
+// workspace, and are deleted before the test binary runs. Only the statuses the
+// harness signs are reported; a test without one failed.
+func (es *ExecutionService) runHiddenTests(ctx context.Context, tempDir, workDir string, spec workspaceSpec) *models.HiddenTestReport {
+	hidden := &models.HiddenTestReport{Tests: []models.HiddenTestCase{}}
+	var names []string
+	for name, content := range spec.HiddenFiles {
+		fileNames, err := testFunctionNames(name, content, "Test")
+		if err != nil {
+			// A broken hidden suite must not let submissions pass unchecked
+			fmt.Printf("Warning: could not parse hidden tests of challenge %d: %v\n", spec.ChallengeID, err)
+			return failHiddenTests(hidden, nil)
+		}
+		names = append(names, fileNames...)
+	}
+	sort.Strings(names)
+	if len(names) == 0 {
+		return hidden
+	}
+
+	marker, files, err := hiddenFiles(spec, names)
+	var overlay string
+	overlayDir := filepath.Join(tempDir, "hidden")
+	if err == nil {
+		overlay, err = writeOverlay(overlayDir, workDir, files)
+	}
+	if err != nil {
+		fmt.Printf("Warning: could not prepare hidden tests: %v\n", err)
+		return failHiddenTests(hidden, names)
+	}
+
+	binary := filepath.Join(tempDir, "hidden.test")
+	flags, env := testArgsForOptions(nil, es.moduleCache.Env(), models.RunOptions{Race: spec.Options.Race}, "")
+	build := es.buildOverlayTests(ctx, workDir, overlayDir, overlay, binary, spec, flags, env)
+	if build.Err != nil || build.ExitCode != 0 || build.Termination != "" {
+		return failHiddenTests(hidden, names)
+	}
+
+	limits := mergeLimits(spec.Limits)
+	run := es.sandbox.Run(ctx, SandboxCommand{
+		Dir: workDir,
+		Args: []string{
+			binary, "-test.run", "^" + hiddenHarnessTest + "$",
+			"-test.timeout", fmt.Sprintf("%ds", limits.TimeoutSeconds),
+		},
+		Env:    env,
+		Limits: limits,
+	})
+	if run.Err != nil && run.Termination == "" {
+		return failHiddenTests(hidden, names)
+	}
+
+	statuses := parseHiddenOutput(run.Output, marker, names)
+	for _, name := range names {
+		// Tests that never finished were cut short by a panic or a limit
+		status, ok := statuses[name]
+		if !ok {
+			status = models.TestStatusFail
+		}
+		hidden.Tests = append(hidden.Tests, models.HiddenTestCase{Name: name, Status: status})
+		switch status {
+		case models.TestStatusPass:
+			hidden.Passed++
+		case models.TestStatusFail:
+			hidden.Failed++
+		}
+	}
+	hidden.Total = len(hidden.Tests)
+	return hidden
+}
+
+// hiddenFiles returns the overlay files of a hidden test run, the hidden tests
+// and a harness running names, and the marker of the harness's result lines
+func hiddenFiles(spec workspaceSpec, names []string) (string, map[string]string, error) {
+	marker, err := resultMarker("HIDDEN")
+	if err != nil {
+		return "", nil, err
+	}
+
+	files := make(map[string]string, len(spec.HiddenFiles)+1)
+	packageName := ""
+	for name, content := range spec.HiddenFiles {
+		file, err := parser.ParseFile(token.NewFileSet(), name, content, parser.PackageClauseOnly)
+		if err != nil {
+			return "", nil, err
+		}
+		packageName = file.Name.Name
+		files[name] = content
+	}
+
+	var harness bytes.Buffer
+	err = hiddenHarness.Execute(&harness, map[string]interface{}{
+		"Package": packageName,
+		"Harness": hiddenHarnessTest,
+		"Tests":   names,
+		"Marker":  marker,
+		"Pass":    models.TestStatusPass,
+		"Fail":    models.TestStatusFail,
+		"Skip":    models.TestStatusSkip,
+	})
+	if err != nil {
+		return "", nil, err
+	}
+	files["hidden_harness_test.go"] = harness.String()
+	return marker, files, nil
+}
+
+// parseHiddenOutput returns the statuses of the hidden tests from the result
+// lines signed with marker. Only the first result of each test counts.
+func parseHiddenOutput(output, marker string, names []string) map[string]string {
+	known := make(map[string]bool, len(names))
+	for _, name := range names {
+		known[name] = true
+	}
+	statuses := make(map[string]string, len(names))
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 3 || fields[0] != marker || !known[fields[1]] {
+			continue
+		}
+		if _, seen := statuses[fields[1]]; seen {
+			continue
+		}
+		switch fields[2] {
+		case models.TestStatusPass, models.TestStatusFail, models.TestStatusSkip:
+			statuses[fields[1]] = fields[2]
+		}
+	}
+	return statuses
+}
+
+// resultMarker returns a random marker for the result lines of a test harness.
+// The marker is only in the harness source, deleted before the submission
+// runs, and in the execute-only test binary. Short of searching its own
+// memory, the submission cannot learn it to print a fake result.
+func resultMarker(prefix string) (string, error) {
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	return prefix + "-" + hex.EncodeToString(nonce), nil
+}
+
+// failHiddenTests marks the hidden suite as not compiling and every test in it as failed
+func failHiddenTests(hidden *models.HiddenTestReport, names []string) *models.HiddenTestReport {
+	for _, name := range names {
+		hidden.Tests = append(hidden.Tests, models.HiddenTestCase{Name: name, Status: models.TestStatusFail})
+	}
+	hidden.BuildFailed = true
+	hidden.Failed = len(names)
+	hidden.Total = len(names)
+	return hidden
+}
+
+// buildOverlayTests compiles the workspace tests with the files of an overlay
+// into an execute-only test binary, then deletes the overlay directory along
+// with the packages compiled from it. Run on its own, submitted code can read
+// neither the overlay sources nor the binary.
+func (es *ExecutionService) buildOverlayTests(ctx context.Context, workDir, overlayDir, overlay, binary string, spec workspaceSpec, flags, env []string) SandboxResult {
+	args := append([]string{"go", "test", "-c", "-o", binary, "-overlay", overlay}, flags...)
+	env = append(env[:len(env):len(env)], "GOCACHE="+filepath.Join(overlayDir, "go-build"), "GOTMPDIR="+overlayDir)
+	run := es.sandbox.Run(ctx, SandboxCommand{
+		Dir:    workDir,
+		Args:   args,
+		Env:    env,
+		GoRoot: spec.GoRoot,
+		Limits: spec.Limits,
+	})
+	if err := os.RemoveAll(overlayDir); err != nil && run.Err == nil {
+		run.Err = err
+	}
+	if run.Err == nil && run.ExitCode == 0 && run.Termination == "" {
+		run.Err = os.Chmod(binary, 0111)
+	}
+	return run
+}
+
+// writeOverlay stores files outside the workspace and returns a go build
+// -overlay file that places them into it. The removed workspace files are
+// left out of the build.
+func writeOverlay(dir, workDir string, files map[string]string, removed ...string) (string, error) {
+	if err := os.Mkdir(dir, 0755); err != nil {
+		return "", err
+	}
+
+	replace := make(map[string]string, len(files)+len(removed))
+	for _, name := range removed {
+		replace[filepath.Join(workDir, name)] = ""
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return "", err
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0444); err != nil {
+			return "", err
+		}
+		replace[filepath.Join(workDir, name)] = path
+	}
+
+	data, err := json.Marshal(map[string]map[string]string{"Replace": replace})
+	if err != nil {
+		return "", err
+	}
+	overlay := filepath.Join(dir, "overlay.json")
+	if err := ioutil.WriteFile(overlay, data, 0444); err != nil {
+		return "", err
+	}
+	return overlay, nil
+}
+
+// testFunctionNames returns the top-level Test or Fuzz functions, depending on prefix, declared in a test file
+func testFunctionNames(filename, content, prefix string) ([]string, error) {
+	file, err := parser.ParseFile(token.NewFileSet(), filename, content, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Recv != nil || !strings.HasPrefix(fn.Name.Name, prefix) || fn.Name.Name == "TestMain" {
+			continue
+		}
+		names = append(names, fn.Name.Name)
+	}
+	return names, nil
+}
@@ -0,0 +1,78 @@
+package services
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseHiddenOutput(t *testing.T) {
+	const marker = "HIDDEN-0123"
+	names := []string{"TestA", "TestB", "TestC"}
+
+	tests := []struct {
+		name   string
+		output []string
+		want   map[string]string
+	}{
+		{
+			name: "signed results",
+			output: []string{
+				"=== RUN   TestHiddenHarness/TestA",
+				"HIDDEN-0123 TestA pass",
+				"HIDDEN-0123 TestB fail",
+				"HIDDEN-0123 TestC skip",
+			},
+			want: map[string]string{"TestA": "pass", "TestB": "fail", "TestC": "skip"},
+		},
+		{
+			name: "unsigned results printed by the submission",
+			output: []string{
+				"--- PASS: TestA (0.00s)",
+				`{"Action":"pass","Test":"TestB"}`,
+				"HIDDEN-9999 TestC pass",
+				"PASS",
+			},
+			want: map[string]string{},
+		},
+		{
+			name: "first result of a test counts",
+			output: []string{
+				"HIDDEN-0123 TestA fail",
+				"HIDDEN-0123 TestA pass",
+			},
+			want: map[string]string{"TestA": "fail"},
+		},
+		{
+			name: "unknown tests and statuses",
+			output: []string{
+				"HIDDEN-0123 TestD pass",
+				"HIDDEN-0123 TestA passed",
+				"HIDDEN-0123 TestB pass extra",
+				"prefix HIDDEN-0123 TestC pass",
+			},
+			want: map[string]string{},
+		},
+		{
+			name: "binary ended after the first test",
+			output: []string{
+				"HIDDEN-0123 TestA pass",
+				"panic: runtime error: index out of range [3] with length 3",
+			},
+			want: map[string]string{"TestA": "pass"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseHiddenOutput(strings.Join(tt.output, "\n")+"\n", marker, names)
+			if len(got) != len(tt.want) {
+				t.Fatalf("statuses = %v, want %v", got, tt.want)
+			}
+			for name, status := range tt.want {
+				if got[name] != status {
+					t.Errorf("statuses = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
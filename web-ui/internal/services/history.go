@@ -0,0 +1,293 @@
+package services
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+
+	"web-ui/internal/models"
+)
+
+// ErrSubmissionNotFound is returned for submissions that were never stored or were trimmed
+var ErrSubmissionNotFound = errors.New("submission not found")
+
+// SubmissionStore records every run and submit with its code and results
+type SubmissionStore interface {
+	// Add stores a submission and assigns its ID
+	Add(submission *models.Submission) error
+	// Get returns a stored submission with its code
+	Get(id int64) (*models.Submission, error)
+	// List returns the summaries matching query, newest first, and how many match in total
+	List(query SubmissionQuery) ([]models.SubmissionSummary, int)
+}
+
+// SubmissionQuery selects submissions from a store
+type SubmissionQuery struct {
+	Username         string
+	ChallengeID      int    // Classic challenge, 0 for any
+	Package          string // Package challenge together with PackageChallenge, "" for any
+	PackageChallenge string
+	Kind             string // models.SubmissionKindRun or models.SubmissionKindSubmit, "" for both
+	Offset           int
+	Limit            int
+}
+
+// matches reports whether a stored submission is selected by the query
+func (q SubmissionQuery) matches(summary models.SubmissionSummary) bool {
+	return summary.Username == q.Username &&
+		(q.ChallengeID == 0 || summary.ChallengeID == q.ChallengeID) &&
+		(q.Package == "" || summary.Package == q.Package && summary.PackageChallenge == q.PackageChallenge) &&
+		(q.Kind == "" || summary.Kind == q.Kind)
+}
+
+// FileSubmissionStore is a SubmissionStore in an append-only JSON lines file
+// in the data directory. Summaries are indexed in memory and full records are
+// read from the file on demand. Only the newest SUBMISSION_HISTORY_LIMIT
+// (default 100) submissions per user and challenge are kept; the file is
+// compacted once trimmed records make up most of it.
+type FileSubmissionStore struct {
+	path  string
+	limit int
+
+	mutex   sync.RWMutex
+	file    *os.File
+	size    int64
+	garbage int64 // Bytes of trimmed records still in the file
+	nextID  int64
+	order   []int64 // Oldest first
+	records map[int64]storedSubmission
+	byKey   map[string][]int64 // User and challenge to IDs, oldest first
+}
+
+// storedSubmission locates a record in the file
+type storedSubmission struct {
+	summary models.SubmissionSummary
+	offset  int64
+	length  int64
+}
+
+// NewFileSubmissionStore creates a submission store in the data directory
+func NewFileSubmissionStore() *FileSubmissionStore {
+	limit := 100
+	if value, err := strconv.Atoi(os.Getenv("SUBMISSION_HISTORY_LIMIT")); err == nil && value > 0 {
+		limit = value
+	}
+	return &FileSubmissionStore{
+		path:    filepath.Join(DataDir(), "submissions.jsonl"),
+		limit:   limit,
+		nextID:  1,
+		records: make(map[int64]storedSubmission),
+		byKey:   make(map[string][]int64),
+	}
+}
+
+// Load opens the store and indexes the stored submissions. A record cut short
+// by a crash is dropped.
+func (fs *FileSubmissionStore) Load() error {
+	if err := os.MkdirAll(filepath.Dir(fs.path), 0755); err != nil {
+		return fmt.Errorf("could not create %s: %v", filepath.Dir(fs.path), err)
+	}
+	file, err := os.OpenFile(fs.path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return fmt.Errorf("could not open %s: %v", fs.path, err)
+	}
+
+	fs.mutex.Lock()
+	defer fs.mutex.Unlock()
+	fs.file = file
+	fs.size = 0
+
+	reader := bufio.NewReader(file)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err == io.EOF {
+			if len(line) > 0 {
+				fmt.Printf("Warning: dropping incomplete submission record at the end of %s\n", fs.path)
+				if err := file.Truncate(fs.size); err != nil {
+					return fmt.Errorf("could not truncate %s: %v", fs.path, err)
+				}
+			}
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("could not read %s: %v", fs.path, err)
+		}
+
+		offset := fs.size
+		fs.size += int64(len(line))
+		var submission models.Submission
+		if err := json.Unmarshal(line, &submission); err != nil || submission.ID == 0 {
+			fmt.Printf("Warning: skipping unreadable submission record at offset %d of %s\n", offset, fs.path)
+			fs.garbage += int64(len(line))
+			continue
+		}
+		fs.index(submission.Summary(), offset, int64(len(line)))
+	}
+	return nil
+}
+
+// Add appends a submission to the file
+func (fs *FileSubmissionStore) Add(submission *models.Submission) error {
+	fs.mutex.Lock()
+	defer fs.mutex.Unlock()
+	if fs.file == nil {
+		return fmt.Errorf("submission store is not loaded")
+	}
+
+	submission.ID = fs.nextID
+	data, err := json.Marshal(submission)
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+	if _, err := fs.file.WriteAt(data, fs.size); err != nil {
+		return fmt.Errorf("could not write %s: %v", fs.path, err)
+	}
+
+	offset := fs.size
+	fs.size += int64(len(data))
+	fs.index(submission.Summary(), offset, int64(len(data)))
+
+	if fs.garbage > fs.size/2 && fs.garbage > 1<<20 {
+		if err := fs.compact(); err != nil {
+			fmt.Printf("Warning: could not compact %s: %v\n", fs.path, err)
+		}
+	}
+	return nil
+}
+
+// index adds a record to the in-memory index and trims the oldest submissions
+// of its user and challenge. Must be called with the mutex held.
+func (fs *FileSubmissionStore) index(summary models.SubmissionSummary, offset, length int64) {
+	fs.records[summary.ID] = storedSubmission{summary: summary, offset: offset, length: length}
+	fs.order = append(fs.order, summary.ID)
+	if summary.ID >= fs.nextID {
+		fs.nextID = summary.ID + 1
+	}
+
+	key := fmt.Sprintf("%s\x00%d\x00%s\x00%s", summary.Username, summary.ChallengeID, summary.Package, summary.PackageChallenge)
+	ids := append(fs.byKey[key], summary.ID)
+	for len(ids) > fs.limit {
+		fs.drop(ids[0])
+		ids = ids[1:]
+	}
+	fs.byKey[key] = ids
+}
+
+// drop removes a record from the index; its bytes stay in the file until the next compaction
+func (fs *FileSubmissionStore) drop(id int64) {
+	stored, ok := fs.records[id]
+	if !ok {
+		return
+	}
+	delete(fs.records, id)
+	fs.garbage += stored.length
+	for i, orderID := range fs.order {
+		if orderID == id {
+			fs.order = append(fs.order[:i], fs.order[i+1:]...)
+			break
+		}
+	}
+}
+
+// compact rewrites the file with only the indexed records. Must be called with the mutex held.
+func (fs *FileSubmissionStore) compact() error {
+	tmp, err := os.CreateTemp(filepath.Dir(fs.path), filepath.Base(fs.path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	writer := bufio.NewWriter(tmp)
+	offsets := make(map[int64]int64, len(fs.order))
+	var size int64
+	for _, id := range fs.order {
+		stored := fs.records[id]
+		data := make([]byte, stored.length)
+		if _, err := fs.file.ReadAt(data, stored.offset); err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+			return err
+		}
+		writer.Write(data)
+		offsets[id] = size
+		size += stored.length
+	}
+	if err := writer.Flush(); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), fs.path); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	fs.file.Close()
+	fs.file = tmp
+	fs.size = size
+	fs.garbage = 0
+	for id, offset := range offsets {
+		stored := fs.records[id]
+		stored.offset = offset
+		fs.records[id] = stored
+	}
+	return nil
+}
+
+// Get reads a submission from the file
+func (fs *FileSubmissionStore) Get(id int64) (*models.Submission, error) {
+	fs.mutex.RLock()
+	defer fs.mutex.RUnlock()
+	stored, ok := fs.records[id]
+	if !ok {
+		return nil, ErrSubmissionNotFound
+	}
+
+	data := make([]byte, stored.length)
+	if _, err := fs.file.ReadAt(data, stored.offset); err != nil {
+		return nil, fmt.Errorf("could not read submission %d: %v", id, err)
+	}
+	var submission models.Submission
+	if err := json.Unmarshal(data, &submission); err != nil {
+		return nil, fmt.Errorf("could not parse submission %d: %v", id, err)
+	}
+	return &submission, nil
+}
+
+// List returns one page of matching summaries, newest first
+func (fs *FileSubmissionStore) List(query SubmissionQuery) ([]models.SubmissionSummary, int) {
+	fs.mutex.RLock()
+	defer fs.mutex.RUnlock()
+
+	summaries := []models.SubmissionSummary{}
+	total := 0
+	for i := len(fs.order) - 1; i >= 0; i-- {
+		summary := fs.records[fs.order[i]].summary
+		if !query.matches(summary) {
+			continue
+		}
+		if total >= query.Offset && (query.Limit <= 0 || len(summaries) < query.Limit) {
+			summaries = append(summaries, summary)
+		}
+		total++
+	}
+	return summaries, total
+}
+
+// Close closes the file
+func (fs *FileSubmissionStore) Close() error {
+	fs.mutex.Lock()
+	defer fs.mutex.Unlock()
+	if fs.file == nil {
+		return nil
+	}
+	err := fs.file.Close()
+	fs.file = nil
+	return err
+}
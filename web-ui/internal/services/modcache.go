@@ -0,0 +1,223 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"web-ui/internal/models"
+)
+
+// ModuleCache configures the shared GOMODCACHE used by every execution.
+// It is pre-warmed from the go.mod/go.sum files shipped with each challenge
+// so runs resolve pinned dependencies locally instead of hitting the network.
+type ModuleCache struct {
+	dir     string
+	offline bool
+}
+
+// ModuleSource is a challenge module whose dependencies should be cached
+type ModuleSource struct {
+	Name       string            // e.g. "challenge-14" or "gin/challenge-1-basic-routing"
+	ModuleFile string            // go.mod content
+	ModuleSum  string            // go.sum content
+	Files      map[string]string // Go sources used to discover test-only imports
+}
+
+var moduleLineRe = regexp.MustCompile(`(?m)^module\s+\S+`)
+
+// NewModuleCache creates the module cache configuration from the environment.
+// EXECUTION_MODCACHE overrides the cache directory and EXECUTION_OFFLINE=true
+// forbids any module download during runs (GOPROXY=off).
+func NewModuleCache() *ModuleCache {
+	dir := os.Getenv("EXECUTION_MODCACHE")
+	if dir == "" {
+		if cacheDir, err := os.UserCacheDir(); err == nil {
+			dir = filepath.Join(cacheDir, "go-interview-practice", "mod")
+		} else {
+			dir = filepath.Join(os.TempDir(), "go-interview-practice-mod")
+		}
+	}
+	if absDir, err := filepath.Abs(dir); err == nil {
+		dir = absDir
+	}
+
+	return &ModuleCache{
+		dir:     dir,
+		offline: strings.EqualFold(os.Getenv("EXECUTION_OFFLINE"), "true") || os.Getenv("EXECUTION_OFFLINE") == "1",
+	}
+}
+
+// Dir returns the shared GOMODCACHE directory
+func (mc *ModuleCache) Dir() string {
+	return mc.dir
+}
+
+// Offline reports whether module downloads are disabled for runs
+func (mc *ModuleCache) Offline() bool {
+	return mc.offline
+}
+
+// Env returns the environment for commands that build and run submissions.
+// They see the shared cache read-only and use the modules setup resolved.
+func (mc *ModuleCache) Env() []string {
+	return []string{
+		"GOMODCACHE=" + mc.dir,
+		"GOFLAGS=-mod=readonly",
+		"GOPROXY=off",
+	}
+}
+
+// SetupEnv returns the environment for the module setup steps of a run, the
+// only commands of a run that may add modules to the shared cache
+func (mc *ModuleCache) SetupEnv() []string {
+	env := []string{
+		"GOMODCACHE=" + mc.dir,
+		"GOFLAGS=-mod=mod",
+	}
+	if mc.offline {
+		// Everything must come from the warmed cache; checksums were verified when it was filled
+		env = append(env, "GOPROXY=off", "GOSUMDB=off")
+	}
+	return env
+}
+
+// WarmEnv returns the environment used while filling the cache, which always may download
+func (mc *ModuleCache) WarmEnv() []string {
+	return []string{
+		"GOMODCACHE=" + mc.dir,
+		"GOFLAGS=-mod=mod",
+	}
+}
+
+// rewriteModuleName replaces the module path in a go.mod file
+func rewriteModuleName(goMod, name string) string {
+	return moduleLineRe.ReplaceAllString(goMod, "module "+name)
+}
+
+// ModuleSourcesFor collects the module files of all classic and package challenges
+func ModuleSourcesFor(challenges models.ChallengeMap, packageService *PackageService) []ModuleSource {
+	var sources []ModuleSource
+	for id, challenge := range challenges {
+		if challenge.ModuleFile == "" {
+			continue
+		}
+		sources = append(sources, ModuleSource{
+			Name:       fmt.Sprintf("challenge-%d", id),
+			ModuleFile: challenge.ModuleFile,
+			ModuleSum:  challenge.ModuleSum,
+			Files: map[string]string{
+				"solution-template.go":      challenge.Template,
+				"solution-template_test.go": challenge.TestFile,
+			},
+		})
+	}
+
+	for packageName := range packageService.GetPackages() {
+		packageChallenges, err := packageService.GetPackageChallenges(packageName)
+		if err != nil {
+			continue
+		}
+		for challengeID, challenge := range packageChallenges {
+			if challenge.ModuleFile == "" {
+				continue
+			}
+			sources = append(sources, ModuleSource{
+				Name:       packageName + "/" + challengeID,
+				ModuleFile: challenge.ModuleFile,
+				ModuleSum:  challenge.ModuleSum,
+				Files: map[string]string{
+					"solution-template.go":      challenge.Template,
+					"solution-template_test.go": challenge.TestFile,
+				},
+			})
+		}
+	}
+	return sources
+}
+
+// WarmModuleCache downloads the pinned dependencies of every source into the
+// shared module cache and builds them into the shared build cache
+func (es *ExecutionService) WarmModuleCache(ctx context.Context, sources []ModuleSource) {
+	start := time.Now()
+	if err := os.MkdirAll(es.moduleCache.Dir(), 0755); err != nil {
+		fmt.Printf("Warning: could not create module cache %s: %v\n", es.moduleCache.Dir(), err)
+		return
+	}
+
+	failed := 0
+	for _, source := range sources {
+		if err := es.warmModule(ctx, source); err != nil {
+			failed++
+			fmt.Printf("Warning: could not warm module cache for %s: %v\n", source.Name, err)
+		}
+	}
+
+	fmt.Printf("Module cache warmed for %d/%d challenge modules in %s (%s)\n",
+		len(sources)-failed, len(sources), time.Since(start).Round(time.Millisecond), es.moduleCache.Dir())
+}
+
+// warmModule resolves one challenge module, including test-only imports
+func (es *ExecutionService) warmModule(ctx context.Context, source ModuleSource) error {
+	tempDir, err := ioutil.TempDir("", "modcache-warm")
+	if err != nil {
+		return err
+	}
+	defer removeWorkspace(tempDir)
+
+	workDir := filepath.Join(tempDir, "workspace")
+	if err := os.Mkdir(workDir, 0755); err != nil {
+		return err
+	}
+
+	files := map[string]string{
+		"go.mod": rewriteModuleName(source.ModuleFile, "challenge"),
+		"go.sum": source.ModuleSum,
+	}
+	for name, content := range source.Files {
+		files[name] = content
+	}
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(workDir, name), []byte(content), 0644); err != nil {
+			return err
+		}
+	}
+
+	for _, args := range [][]string{
+		{"go", "mod", "download"},
+		{"go", "list", "-e", "-deps", "-test", "./..."},
+	} {
+		run := es.sandbox.Run(ctx, SandboxCommand{
+			Dir:           workDir,
+			Args:          args,
+			Env:           es.moduleCache.WarmEnv(),
+			Limits:        models.ExecutionLimits{TimeoutSeconds: 600, CPUSeconds: 600},
+			AllowNetwork:  true,
+			WritableCache: true,
+			WritableDir:   true,
+		})
+		if run.Err != nil {
+			return run.Err
+		}
+		if run.ExitCode != 0 || run.Termination != "" {
+			return fmt.Errorf("%s failed: %s", strings.Join(args, " "), strings.TrimSpace(run.Output))
+		}
+	}
+
+	// Building the template with its tests fills the shared build cache that
+	// runs start from. Templates are repository content, so they may write to
+	// it; ones that don't compile yet only warm less.
+	es.sandbox.Run(ctx, SandboxCommand{
+		Dir:       workDir,
+		Args:      []string{"go", "test", "-count=1", "-run", "^$", "./..."},
+		Env:       es.moduleCache.Env(),
+		Limits:    models.ExecutionLimits{TimeoutSeconds: 600, CPUSeconds: 600},
+		FillCache: true,
+	})
+	return nil
+}
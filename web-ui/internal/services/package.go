@@ -2,30 +2,41 @@ package services
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io/fs"
 	"net/http"
 	"os"
-	"path/filepath"
+	"path"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"web-ui/internal/models"
 )
 
 type PackageService struct {
-	httpClient   *http.Client
-	packagesPath string
-	// In-memory cache to avoid repeated GitHub API calls (no TTL; load once per process)
+	httpClient *http.Client
+	content    *ContentRoot
+
+	// loadMu serializes loads and reloads, and guards stars
+	loadMu sync.Mutex
+	// GitHub stars by repository URL, so reloads don't repeat GitHub API calls
+	stars map[string]int
+
+	mu sync.RWMutex
+	// Loaded on first use and replaced on reload, never modified once published
 	cachedPackages map[string]*models.Package
 }
 
-func NewPackageService() *PackageService {
+func NewPackageService(content *ContentRoot) *PackageService {
 	return &PackageService{
 		httpClient: &http.Client{
 			Timeout: 30 * time.Second,
 		},
-		packagesPath:   "../packages", // Relative to web-ui directory
+		content:        content,
+		stars:          make(map[string]int),
 		cachedPackages: nil,
 	}
 }
@@ -55,35 +66,99 @@ func (s *PackageService) LoadPackages() error {
 	return nil
 }
 
+// GetPackages returns all packages, loading them on first use. The map is a
+// snapshot that reloads replace rather than modify; callers must not change it.
 func (s *PackageService) GetPackages() map[string]*models.Package {
 	// Serve from cache if already populated
-	if s.cachedPackages != nil {
-		return s.cachedPackages
+	s.mu.RLock()
+	packages := s.cachedPackages
+	s.mu.RUnlock()
+	if packages != nil {
+		return packages
+	}
+
+	s.loadMu.Lock()
+	defer s.loadMu.Unlock()
+	// Another request may have loaded them while this one waited
+	if packages := s.getCachedPackages(); packages != nil {
+		return packages
 	}
+	return s.loadPackages()
+}
+
+// ReloadPackages reloads every package from the content root, fetching the
+// GitHub stars again
+func (s *PackageService) ReloadPackages() map[string]*models.Package {
+	s.loadMu.Lock()
+	defer s.loadMu.Unlock()
+	s.stars = make(map[string]int)
+	return s.loadPackages()
+}
 
+// ReloadPackage reloads a package from the content root, or drops it when its
+// package.json is gone. On errors the loaded package is kept.
+func (s *PackageService) ReloadPackage(packageName string) error {
+	s.loadMu.Lock()
+	defer s.loadMu.Unlock()
+	if s.getCachedPackages() == nil {
+		s.loadPackages()
+		return nil
+	}
+
+	packagePath := path.Join(packagesDir, packageName)
+	var pkg *models.Package
+	if _, err := s.content.Stat(packagePath, "package.json"); !errors.Is(err, fs.ErrNotExist) {
+		if pkg = s.loadPackage(packagePath, packageName); pkg == nil {
+			return fmt.Errorf("could not reload package %s", packageName)
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	packages := make(map[string]*models.Package, len(s.cachedPackages)+1)
+	for name, existing := range s.cachedPackages {
+		packages[name] = existing
+	}
+	if pkg != nil {
+		packages[pkg.Name] = pkg
+	} else {
+		delete(packages, packageName)
+	}
+	s.cachedPackages = packages
+	return nil
+}
+
+// getCachedPackages returns the loaded packages, or nil before the first load
+func (s *PackageService) getCachedPackages() map[string]*models.Package {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.cachedPackages
+}
+
+// loadPackages loads every package and publishes them; the caller holds s.loadMu
+func (s *PackageService) loadPackages() map[string]*models.Package {
 	packages := make(map[string]*models.Package)
 
 	// Read packages directory
-	entries, err := os.ReadDir(s.packagesPath)
+	entries, err := s.content.ReadDir(packagesDir)
 	if err != nil {
 		fmt.Printf("Error reading packages directory: %v\n", err)
-		// Populate cache (empty) to prevent repeated attempts this run
-		s.cachedPackages = packages
-		return s.cachedPackages
 	}
 
 	for _, entry := range entries {
 		if entry.IsDir() {
-			packagePath := filepath.Join(s.packagesPath, entry.Name())
+			packagePath := path.Join(packagesDir, entry.Name())
 			if pkg := s.loadPackage(packagePath, entry.Name()); pkg != nil {
 				packages[pkg.Name] = pkg
 			}
 		}
 	}
 
-	// Populate cache once per process lifetime
+	// Populate the cache, empty on errors to prevent repeated attempts
+	s.mu.Lock()
 	s.cachedPackages = packages
-	return s.cachedPackages
+	s.mu.Unlock()
+	return packages
 }
 
 func (s *PackageService) loadPackage(packagePath, packageName string) *models.Package {
@@ -95,8 +170,7 @@ func (s *PackageService) loadPackage(packagePath, packageName string) *models.Pa
 	}
 
 	// Load package.json
-	metadataPath := filepath.Join(packagePath, "package.json")
-	metadataBytes, err := os.ReadFile(metadataPath)
+	metadataBytes, err := s.content.ReadFile(packagePath, "package.json")
 	if err != nil {
 		fmt.Printf("Error reading package.json for %s: %v\n", packageName, err)
 		return nil
@@ -108,8 +182,12 @@ func (s *PackageService) loadPackage(packagePath, packageName string) *models.Pa
 		return nil
 	}
 
-	// Fetch real-time GitHub stars
-	stars := s.fetchGitHubStars(metadata.GitHubURL)
+	// Fetch real-time GitHub stars, once per repository until a full reload
+	stars, fetched := s.stars[metadata.GitHubURL]
+	if !fetched {
+		stars = s.fetchGitHubStars(metadata.GitHubURL)
+		s.stars[metadata.GitHubURL] = stars
+	}
 	if stars > 0 {
 		metadata.Stars = stars
 	}
@@ -141,10 +219,10 @@ func (s *PackageService) loadChallengeDetails(packagePath string, learningPath [
 	challengeDetails := make(map[string]*models.ChallengeInfo)
 
 	for i, challengeID := range learningPath {
-		challengePath := filepath.Join(packagePath, challengeID)
+		challengePath := path.Join(packagePath, challengeID)
 
 		// Check if challenge directory exists
-		if _, err := os.Stat(challengePath); os.IsNotExist(err) {
+		if _, err := s.content.Stat(challengePath); errors.Is(err, fs.ErrNotExist) {
 			// Challenge doesn't exist yet, mark as coming soon
 			challengeDetails[challengeID] = &models.ChallengeInfo{
 				ID:            challengeID,
@@ -196,14 +274,7 @@ func (s *PackageService) loadChallengeDetails(packagePath string, learningPath [
 
 // loadChallengeMetadata loads metadata from challenge directory
 func (s *PackageService) loadChallengeMetadata(challengePath string) *models.ChallengeMetadata {
-	metadataPath := filepath.Join(challengePath, "metadata.json")
-
-	// Check if metadata.json exists
-	if _, err := os.Stat(metadataPath); os.IsNotExist(err) {
-		return nil
-	}
-
-	metadataBytes, err := os.ReadFile(metadataPath)
+	metadataBytes, err := s.content.ReadFile(challengePath, "metadata.json")
 	if err != nil {
 		return nil
 	}
@@ -229,8 +300,7 @@ func (s *PackageService) generateTitleFromID(challengeID string) string {
 }
 
 func (s *PackageService) generateDescriptionFromReadme(challengePath string) string {
-	readmePath := filepath.Join(challengePath, "README.md")
-	content, err := os.ReadFile(readmePath)
+	content, err := s.content.ReadFile(challengePath, "README.md")
 	if err != nil {
 		return "Challenge content available"
 	}
@@ -303,14 +373,14 @@ func (s *PackageService) loadChallenges(packagePath string) []models.PackageChal
 	var challenges []models.PackageChallenge
 
 	// Read challenge directories
-	entries, err := os.ReadDir(packagePath)
+	entries, err := s.content.ReadDir(packagePath)
 	if err != nil {
 		return challenges
 	}
 
 	for _, entry := range entries {
 		if entry.IsDir() && strings.HasPrefix(entry.Name(), "challenge-") {
-			challengePath := filepath.Join(packagePath, entry.Name())
+			challengePath := path.Join(packagePath, entry.Name())
 			if challenge := s.loadChallenge(challengePath, entry.Name()); challenge != nil {
 				challenges = append(challenges, *challenge)
 			}
@@ -331,7 +401,7 @@ func (s *PackageService) loadChallenge(challengePath, challengeName string) *mod
 	title = strings.Title(strings.ReplaceAll(title, "-", " "))
 
 	// Load README.md for full content
-	readmeContent := s.readFileContent(filepath.Join(challengePath, "README.md"))
+	readmeContent := s.readFileContent(path.Join(challengePath, "README.md"))
 	if readmeContent == "" {
 		readmeContent = "Challenge content not available"
 	}
@@ -340,25 +410,25 @@ func (s *PackageService) loadChallenge(challengePath, challengeName string) *mod
 	// For package listing, templates can extract brief descriptions as needed
 
 	// Load solution template
-	template := s.readFileContent(filepath.Join(challengePath, "solution-template.go"))
+	template := s.readFileContent(path.Join(challengePath, "solution-template.go"))
 	if template == "" {
 		template = "// Solution template not available"
 	}
 
 	// Load test file
-	testFile := s.readFileContent(filepath.Join(challengePath, "solution-template_test.go"))
+	testFile := s.readFileContent(path.Join(challengePath, "solution-template_test.go"))
 	if testFile == "" {
 		testFile = "// Test file not available"
 	}
 
 	// Load hints
-	hints := s.readFileContent(filepath.Join(challengePath, "hints.md"))
+	hints := s.readFileContent(path.Join(challengePath, "hints.md"))
 	if hints == "" {
 		hints = "No hints available for this challenge."
 	}
 
 	// Load learning materials from learning.md (same as classic challenges)
-	learningMaterials := s.readFileContent(filepath.Join(challengePath, "learning.md"))
+	learningMaterials := s.readFileContent(path.Join(challengePath, "learning.md"))
 	if learningMaterials == "" {
 		learningMaterials = "*No learning materials available for this challenge yet.*"
 	}
@@ -380,6 +450,7 @@ func (s *PackageService) loadChallenge(challengePath, challengeName string) *mod
 		}
 	}
 
+	moduleFile := s.readFileContent(path.Join(challengePath, "go.mod"))
 	return &models.PackageChallenge{
 		ID:                challengeName,
 		Title:             title,
@@ -389,11 +460,14 @@ func (s *PackageService) loadChallenge(challengePath, challengeName string) *mod
 		TestFile:          testFile,
 		Hints:             hints,
 		LearningMaterials: learningMaterials, // Use learning.md for learning materials tab
+		ModuleFile:        moduleFile,
+		ModuleSum:         s.readFileContent(path.Join(challengePath, "go.sum")),
+		Toolchain:         ParseToolchainRequirement(moduleFile),
 	}
 }
 
 func (s *PackageService) readFileContent(filePath string) string {
-	content, err := os.ReadFile(filePath)
+	content, err := s.content.ReadFile(filePath)
 	if err != nil {
 		return ""
 	}
@@ -469,12 +543,11 @@ func (s *PackageService) GetPackage(packageID string) (*models.Package, error) {
 }
 
 func (s *PackageService) GetChallenge(packageID, challengeID string) *models.PackageChallenge {
-	// Load challenge directly from filesystem
-	packagePath := filepath.Join(s.packagesPath, packageID)
-	challengePath := filepath.Join(packagePath, challengeID)
+	// Load challenge directly from the content root
+	challengePath := path.Join(packagesDir, packageID, challengeID)
 
 	// Check if challenge directory exists
-	if _, err := os.Stat(challengePath); os.IsNotExist(err) {
+	if _, err := s.content.Stat(challengePath); errors.Is(err, fs.ErrNotExist) {
 		return nil
 	}
 
@@ -482,10 +555,10 @@ func (s *PackageService) GetChallenge(packageID, challengeID string) *models.Pac
 }
 
 func (s *PackageService) GetPackageChallenges(packageID string) (map[string]*models.PackageChallenge, error) {
-	packagePath := filepath.Join(s.packagesPath, packageID)
+	packagePath := path.Join(packagesDir, packageID)
 
 	// Check if package directory exists
-	if _, err := os.Stat(packagePath); os.IsNotExist(err) {
+	if _, err := s.content.Stat(packagePath); errors.Is(err, fs.ErrNotExist) {
 		return nil, fmt.Errorf("package %s not found", packageID)
 	}
 
@@ -502,12 +575,11 @@ func (s *PackageService) GetPackageChallenges(packageID string) (map[string]*mod
 }
 
 func (s *PackageService) GetPackageChallenge(packageID, challengeID string) (*models.PackageChallenge, error) {
-	// Load challenge directly from filesystem
-	packagePath := filepath.Join(s.packagesPath, packageID)
-	challengePath := filepath.Join(packagePath, challengeID)
+	// Load challenge directly from the content root
+	challengePath := path.Join(packagesDir, packageID, challengeID)
 
 	// Check if challenge directory exists
-	if _, err := os.Stat(challengePath); os.IsNotExist(err) {
+	if _, err := s.content.Stat(challengePath); errors.Is(err, fs.ErrNotExist) {
 		return nil, fmt.Errorf("challenge %s not found in package %s", challengeID, packageID)
 	}
 
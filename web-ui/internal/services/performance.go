@@ -0,0 +1,97 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"web-ui/internal/models"
+)
+
+// PerformanceService keeps the best benchmark result of every user per challenge
+type PerformanceService struct {
+	path    string
+	mutex   sync.RWMutex
+	entries map[int][]models.PerformanceEntry
+}
+
+// NewPerformanceService creates a performance service stored in the data directory
+func NewPerformanceService() *PerformanceService {
+	return &PerformanceService{
+		path:    filepath.Join(DataDir(), "performance.json"),
+		entries: make(map[int][]models.PerformanceEntry),
+	}
+}
+
+// LoadPerformance reads stored results; a missing file means no results yet
+func (ps *PerformanceService) LoadPerformance() error {
+	data, err := ioutil.ReadFile(ps.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("could not read %s: %v", ps.path, err)
+	}
+
+	entries := make(map[int][]models.PerformanceEntry)
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return fmt.Errorf("could not parse %s: %v", ps.path, err)
+	}
+
+	ps.mutex.Lock()
+	ps.entries = entries
+	ps.mutex.Unlock()
+	return nil
+}
+
+// RecordResult stores a graded benchmark result if it beats the user's previous best
+func (ps *PerformanceService) RecordResult(submission models.Submission) error {
+	if submission.Benchmarks == nil || !submission.Benchmarks.Passed || submission.Username == "" {
+		return nil
+	}
+	entry := models.PerformanceEntry{
+		Username:    submission.Username,
+		ChallengeID: submission.ChallengeID,
+		Score:       submission.Benchmarks.Score,
+		Results:     submission.Benchmarks.Results,
+		SubmittedAt: submission.SubmittedAt,
+	}
+
+	ps.mutex.Lock()
+	defer ps.mutex.Unlock()
+
+	entries := ps.entries[entry.ChallengeID]
+	for i, existing := range entries {
+		if existing.Username != entry.Username {
+			continue
+		}
+		if existing.Score <= entry.Score {
+			return nil
+		}
+		entries = append(entries[:i:i], entries[i+1:]...)
+		break
+	}
+	ps.entries[entry.ChallengeID] = append(entries, entry)
+
+	data, err := json.MarshalIndent(ps.entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(ps.path, data)
+}
+
+// GetLeaderboard returns the fastest solutions of a challenge, best first
+func (ps *PerformanceService) GetLeaderboard(challengeID int) []models.PerformanceEntry {
+	ps.mutex.RLock()
+	leaderboard := append([]models.PerformanceEntry{}, ps.entries[challengeID]...)
+	ps.mutex.RUnlock()
+
+	sort.SliceStable(leaderboard, func(i, j int) bool {
+		return leaderboard[i].Score < leaderboard[j].Score
+	})
+	return leaderboard
+}
@@ -0,0 +1,235 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"os"
+	"runtime"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Errors returned when a run cannot be queued
+var (
+	ErrQueueFull   = errors.New("execution queue is full, please try again in a moment")
+	ErrTooManyRuns = errors.New("you already have the maximum number of runs in progress")
+)
+
+// ExecutionQueue bounds how many test runs execute at once. Waiting runs are
+// dispatched round-robin across owners so one busy user cannot starve the rest.
+type ExecutionQueue struct {
+	workers    int
+	maxPerUser int
+	maxQueued  int
+
+	mutex    sync.Mutex
+	running  int
+	queued   int
+	inFlight map[string]int          // Queued plus running runs per owner
+	pending  map[string][]*queuedRun // Waiting runs per owner, oldest first
+	owners   []string                // Owners with waiting runs, in dispatch order
+}
+
+// queuedRun is a run waiting for a worker
+type queuedRun struct {
+	owner      string
+	ready      chan struct{}
+	onPosition func(position int)
+}
+
+// QueueStatus is a snapshot of the queue
+type QueueStatus struct {
+	Workers    int `json:"workers"`
+	Running    int `json:"running"`
+	Queued     int `json:"queued"`
+	MaxPerUser int `json:"maxPerUser"`
+	MaxQueued  int `json:"maxQueued"`
+}
+
+// NewExecutionQueue creates a queue configured from the environment.
+// EXECUTION_WORKERS sets the concurrency limit, EXECUTION_MAX_PER_USER the
+// per-owner cap and EXECUTION_MAX_QUEUE how many runs may wait.
+func NewExecutionQueue() *ExecutionQueue {
+	return &ExecutionQueue{
+		workers:    envInt("EXECUTION_WORKERS", max(1, runtime.NumCPU()/2)),
+		maxPerUser: envInt("EXECUTION_MAX_PER_USER", 2),
+		maxQueued:  envInt("EXECUTION_MAX_QUEUE", 50),
+		inFlight:   make(map[string]int),
+		pending:    make(map[string][]*queuedRun),
+	}
+}
+
+// envInt reads a positive integer from the environment
+func envInt(key string, fallback int) int {
+	if value, err := strconv.Atoi(os.Getenv(key)); err == nil && value > 0 {
+		return value
+	}
+	return fallback
+}
+
+// Run waits for a free worker and executes fn. onPosition, if set, is called
+// with the 1-based queue position whenever it changes. The run is dropped
+// from the queue, or canceled while running, when ctx is done.
+func (q *ExecutionQueue) Run(ctx context.Context, owner string, onPosition func(position int), fn func(ctx context.Context) ExecutionResult) (ExecutionResult, error) {
+	start := time.Now()
+
+	q.mutex.Lock()
+	if q.inFlight[owner] >= q.maxPerUser {
+		q.mutex.Unlock()
+		return ExecutionResult{}, ErrTooManyRuns
+	}
+	if q.running >= q.workers && q.queued >= q.maxQueued {
+		q.mutex.Unlock()
+		return ExecutionResult{}, ErrQueueFull
+	}
+	q.inFlight[owner]++
+
+	position := 0
+	if q.running < q.workers && q.queued == 0 {
+		q.running++
+		q.mutex.Unlock()
+	} else {
+		run := &queuedRun{owner: owner, ready: make(chan struct{}), onPosition: onPosition}
+		if len(q.pending[owner]) == 0 {
+			q.owners = append(q.owners, owner)
+		}
+		q.pending[owner] = append(q.pending[owner], run)
+		q.queued++
+		notify := q.positions()
+		position = notify[run]
+		q.mutex.Unlock()
+		notifyPositions(notify)
+
+		select {
+		case <-run.ready:
+		case <-ctx.Done():
+			q.mutex.Lock()
+			if q.remove(run) {
+				q.leave(owner)
+				notify := q.positions()
+				q.mutex.Unlock()
+				notifyPositions(notify)
+			} else {
+				// A worker was assigned at the same time; hand it on
+				q.mutex.Unlock()
+				q.release(owner)
+			}
+			return ExecutionResult{}, ctx.Err()
+		}
+	}
+
+	defer q.release(owner)
+	result := fn(ctx)
+	result.QueuePosition = position
+	result.QueuedMs = time.Since(start).Milliseconds() - result.ExecutionMs
+	if result.QueuedMs < 0 {
+		result.QueuedMs = 0
+	}
+	return result, nil
+}
+
+// release frees the owner's worker slot and dispatches waiting runs
+func (q *ExecutionQueue) release(owner string) {
+	q.mutex.Lock()
+	q.running--
+	q.leave(owner)
+
+	for q.running < q.workers && q.queued > 0 {
+		next := q.owners[0]
+		run := q.pending[next][0]
+		q.remove(run)
+		// Owners that still wait go to the back of the line
+		if len(q.pending[next]) > 0 {
+			q.owners = append(q.owners[1:], next)
+		}
+		q.running++
+		close(run.ready)
+	}
+	notify := q.positions()
+	q.mutex.Unlock()
+	notifyPositions(notify)
+}
+
+// leave drops one of the owner's runs from the in-flight count. Must be called with the mutex held.
+func (q *ExecutionQueue) leave(owner string) {
+	q.inFlight[owner]--
+	if q.inFlight[owner] <= 0 {
+		delete(q.inFlight, owner)
+	}
+}
+
+// remove takes a waiting run out of the queue; it reports false if the run was already dispatched
+func (q *ExecutionQueue) remove(run *queuedRun) bool {
+	runs := q.pending[run.owner]
+	for i, candidate := range runs {
+		if candidate != run {
+			continue
+		}
+		runs = append(runs[:i:i], runs[i+1:]...)
+		q.queued--
+		if len(runs) > 0 {
+			q.pending[run.owner] = runs
+			return true
+		}
+		delete(q.pending, run.owner)
+		for j, owner := range q.owners {
+			if owner == run.owner {
+				q.owners = append(q.owners[:j:j], q.owners[j+1:]...)
+				break
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// positions computes the dispatch position of every waiting run. Must be called with the mutex held.
+func (q *ExecutionQueue) positions() map[*queuedRun]int {
+	positions := make(map[*queuedRun]int, q.queued)
+	position := 1
+	for round := 0; position <= q.queued; round++ {
+		for _, owner := range q.owners {
+			if runs := q.pending[owner]; round < len(runs) {
+				positions[runs[round]] = position
+				position++
+			}
+		}
+	}
+	return positions
+}
+
+// notifyPositions reports queue positions outside of the queue lock
+func notifyPositions(positions map[*queuedRun]int) {
+	for run, position := range positions {
+		if run.onPosition != nil {
+			run.onPosition(position)
+		}
+	}
+}
+
+// Status returns a snapshot of the queue
+func (q *ExecutionQueue) Status() QueueStatus {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	return QueueStatus{
+		Workers:    q.workers,
+		Running:    q.running,
+		Queued:     q.queued,
+		MaxPerUser: q.maxPerUser,
+		MaxQueued:  q.maxQueued,
+	}
+}
+
+// Position returns the best queue position of the owner's waiting runs, or 0 if none wait
+func (q *ExecutionQueue) Position(owner string) int {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	best := 0
+	for run, position := range q.positions() {
+		if run.owner == owner && (best == 0 || position < best) {
+			best = position
+		}
+	}
+	return best
+}
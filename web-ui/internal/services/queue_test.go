@@ -0,0 +1,217 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newTestQueue creates a queue with the given limits
+func newTestQueue(t *testing.T, workers, maxPerUser, maxQueued int) *ExecutionQueue {
+	t.Setenv("EXECUTION_WORKERS", strconv.Itoa(workers))
+	t.Setenv("EXECUTION_MAX_PER_USER", strconv.Itoa(maxPerUser))
+	t.Setenv("EXECUTION_MAX_QUEUE", strconv.Itoa(maxQueued))
+	return NewExecutionQueue()
+}
+
+// occupy fills every worker of q with a run that blocks until the returned
+// function is called
+func occupy(t *testing.T, q *ExecutionQueue) func() {
+	t.Helper()
+	release := make(chan struct{})
+	var started, done sync.WaitGroup
+	for i := 0; i < q.workers; i++ {
+		started.Add(1)
+		done.Add(1)
+		go func() {
+			defer done.Done()
+			q.Run(context.Background(), "blocker", nil, func(ctx context.Context) ExecutionResult {
+				started.Done()
+				<-release
+				return ExecutionResult{}
+			})
+		}()
+	}
+	started.Wait()
+	return func() {
+		close(release)
+		done.Wait()
+	}
+}
+
+// waitQueued waits until n runs are waiting in q
+func waitQueued(t *testing.T, q *ExecutionQueue, n int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for q.Status().Queued != n {
+		if time.Now().After(deadline) {
+			t.Fatalf("queued = %d, want %d", q.Status().Queued, n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestExecutionQueueRoundRobin(t *testing.T) {
+	tests := []struct {
+		name   string
+		submit []string // Owners of the runs, queued in this order
+		want   []string // Owners in the order their runs start
+	}{
+		{
+			name:   "single owner keeps its order",
+			submit: []string{"a", "a", "a"},
+			want:   []string{"a", "a", "a"},
+		},
+		{
+			name:   "busy owner does not starve the others",
+			submit: []string{"a", "a", "a", "b", "c"},
+			want:   []string{"a", "b", "c", "a", "a"},
+		},
+		{
+			name:   "owners take turns",
+			submit: []string{"a", "a", "b", "b", "c"},
+			want:   []string{"a", "b", "c", "a", "b"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := newTestQueue(t, 1, 10, 10)
+			release := occupy(t, q)
+
+			var mu sync.Mutex
+			var started []string
+			var wg sync.WaitGroup
+			for i, owner := range tt.submit {
+				wg.Add(1)
+				go func(owner string) {
+					defer wg.Done()
+					_, err := q.Run(context.Background(), owner, nil, func(ctx context.Context) ExecutionResult {
+						mu.Lock()
+						started = append(started, owner)
+						mu.Unlock()
+						return ExecutionResult{}
+					})
+					if err != nil {
+						t.Errorf("run of %s: %v", owner, err)
+					}
+				}(owner)
+				waitQueued(t, q, i+1)
+			}
+
+			if position := q.Position(tt.submit[0]); position != 1 {
+				t.Errorf("position of %s = %d, want 1", tt.submit[0], position)
+			}
+
+			release()
+			wg.Wait()
+			if len(started) != len(tt.want) {
+				t.Fatalf("started %v, want %v", started, tt.want)
+			}
+			for i := range tt.want {
+				if started[i] != tt.want[i] {
+					t.Fatalf("started %v, want %v", started, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestExecutionQueueLimits(t *testing.T) {
+	tests := []struct {
+		name       string
+		maxPerUser int
+		maxQueued  int
+		waiting    []string // Owners of runs left waiting behind the blocker
+		canceled   int      // How many of the waiting runs are canceled before the last run
+		owner      string   // Owner of the last run
+		wantErr    error
+	}{
+		{
+			name:       "owner under the cap is queued",
+			maxPerUser: 2,
+			maxQueued:  10,
+			waiting:    []string{"a"},
+			owner:      "a",
+		},
+		{
+			name:       "owner at the cap is rejected",
+			maxPerUser: 2,
+			maxQueued:  10,
+			waiting:    []string{"a", "a"},
+			owner:      "a",
+			wantErr:    ErrTooManyRuns,
+		},
+		{
+			name:       "cap is per owner",
+			maxPerUser: 2,
+			maxQueued:  10,
+			waiting:    []string{"a", "a"},
+			owner:      "b",
+		},
+		{
+			name:       "canceled runs free their slot",
+			maxPerUser: 2,
+			maxQueued:  10,
+			waiting:    []string{"a", "a"},
+			canceled:   1,
+			owner:      "a",
+		},
+		{
+			name:       "full queue rejects everyone",
+			maxPerUser: 2,
+			maxQueued:  2,
+			waiting:    []string{"a", "b"},
+			owner:      "c",
+			wantErr:    ErrQueueFull,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := newTestQueue(t, 1, tt.maxPerUser, tt.maxQueued)
+			release := occupy(t, q)
+
+			var wg sync.WaitGroup
+			cancels := make([]context.CancelFunc, len(tt.waiting))
+			for i, owner := range tt.waiting {
+				ctx, cancel := context.WithCancel(context.Background())
+				cancels[i] = cancel
+				wg.Add(1)
+				go func(owner string) {
+					defer wg.Done()
+					q.Run(ctx, owner, nil, func(ctx context.Context) ExecutionResult { return ExecutionResult{} })
+				}(owner)
+				waitQueued(t, q, i+1)
+			}
+			for i := 0; i < tt.canceled; i++ {
+				cancels[i]()
+			}
+			waitQueued(t, q, len(tt.waiting)-tt.canceled)
+
+			// A context that is already done leaves the queue at once when queued
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			_, err := q.Run(ctx, tt.owner, nil, func(ctx context.Context) ExecutionResult { return ExecutionResult{} })
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("err = %v, want %v", err, tt.wantErr)
+				}
+			} else if !errors.Is(err, context.Canceled) {
+				t.Errorf("err = %v, want the run to be queued", err)
+			}
+
+			release()
+			wg.Wait()
+			for _, cancel := range cancels {
+				cancel()
+			}
+			if status := q.Status(); status.Running != 0 || status.Queued != 0 || len(q.inFlight) != 0 {
+				t.Errorf("queue not drained: %+v, in flight %v", status, q.inFlight)
+			}
+		})
+	}
+}
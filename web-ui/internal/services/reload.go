@@ -0,0 +1,223 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"io/fs"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultReloadInterval is how often the content root is polled for changes
+const DefaultReloadInterval = 2 * time.Second
+
+// ContentReloader keeps the loaded challenges, scoreboards and packages, and
+// the cached attempts of users, in step with the content root, so edited
+// challenges and merged submissions show without a restart. It polls the files
+// for changes, which works on every platform and file system, and reloads only
+// what changed. Each service publishes the reloaded content as a new snapshot,
+// so requests see either the old or the new version and never a partial one.
+type ContentReloader struct {
+	content     *ContentRoot
+	challenges  *ChallengeService
+	scoreboards *ScoreboardService
+	packages    *PackageService
+	users       *UserService
+
+	mu           sync.Mutex        // Serializes polls and reloads
+	fingerprints map[string]uint64 // Fingerprint of each watched unit at the last poll
+}
+
+// ReloadSummary counts what a full reload loaded
+type ReloadSummary struct {
+	Challenges  int `json:"challenges"`
+	Scoreboards int `json:"scoreboards"`
+	Packages    int `json:"packages"`
+}
+
+// NewContentReloader creates a reloader for the services' content. The
+// services must have been loaded; changes are detected from this point on.
+func NewContentReloader(content *ContentRoot, challenges *ChallengeService, scoreboards *ScoreboardService, packages *PackageService, users *UserService) *ContentReloader {
+	cr := &ContentReloader{
+		content:     content,
+		challenges:  challenges,
+		scoreboards: scoreboards,
+		packages:    packages,
+		users:       users,
+	}
+	cr.fingerprints = cr.scan()
+	return cr
+}
+
+// ReloadInterval returns the polling interval set by CONTENT_RELOAD_INTERVAL,
+// such as "5s"; 0 turns polling off
+func ReloadInterval() time.Duration {
+	value := os.Getenv("CONTENT_RELOAD_INTERVAL")
+	if value == "" {
+		return DefaultReloadInterval
+	}
+	if value == "0" {
+		return 0
+	}
+	interval, err := time.ParseDuration(value)
+	if err != nil || interval < 0 {
+		log.Printf("Warning: invalid CONTENT_RELOAD_INTERVAL %q, using %v", value, DefaultReloadInterval)
+		return DefaultReloadInterval
+	}
+	return interval
+}
+
+// Watch polls the content root every interval until ctx is done. Read-only
+// snapshots never change and are not polled.
+func (cr *ContentReloader) Watch(ctx context.Context, interval time.Duration) {
+	if cr.content.ReadOnly() || interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			cr.Poll()
+		}
+	}
+}
+
+// Poll reloads the challenges, scoreboards, packages and user submissions whose
+// files changed since the last poll and returns their content paths
+func (cr *ContentReloader) Poll() []string {
+	cr.mu.Lock()
+	defer cr.mu.Unlock()
+
+	fingerprints := cr.scan()
+	var changed []string
+	for unit, fingerprint := range fingerprints {
+		if previous, ok := cr.fingerprints[unit]; !ok || previous != fingerprint {
+			changed = append(changed, unit)
+		}
+	}
+	for unit := range cr.fingerprints {
+		if _, ok := fingerprints[unit]; !ok {
+			changed = append(changed, unit)
+		}
+	}
+	sort.Strings(changed)
+
+	for _, unit := range changed {
+		// A failed reload keeps the old version until the files change again
+		if err := cr.reload(unit); err != nil {
+			log.Printf("Warning: %v", err)
+			continue
+		}
+		log.Printf("Reloaded %s", unit)
+	}
+	cr.fingerprints = fingerprints
+	return changed
+}
+
+// ReloadAll reloads every challenge, scoreboard and package, and drops the
+// cached attempts of every user
+func (cr *ContentReloader) ReloadAll() (ReloadSummary, error) {
+	cr.mu.Lock()
+	defer cr.mu.Unlock()
+
+	if err := cr.challenges.LoadChallenges(); err != nil {
+		return ReloadSummary{}, err
+	}
+	challenges := cr.challenges.GetChallenges()
+	if err := cr.scoreboards.LoadScoreboards(challenges); err != nil {
+		return ReloadSummary{}, err
+	}
+	packages := cr.packages.ReloadPackages()
+	cr.users.InvalidateAttempts()
+	cr.fingerprints = cr.scan()
+
+	return ReloadSummary{
+		Challenges:  len(challenges),
+		Scoreboards: len(cr.scoreboards.GetAllScoreboards()),
+		Packages:    len(packages),
+	}, nil
+}
+
+// reload reloads a unit returned by scan
+func (cr *ContentReloader) reload(unit string) error {
+	if packageName, ok := strings.CutPrefix(unit, packagesDir+"/"); ok {
+		return cr.packages.ReloadPackage(packageName)
+	}
+	dir, file, _ := strings.Cut(unit, "/")
+	if username, ok := strings.CutPrefix(file, "submissions/"); ok {
+		cr.users.InvalidateAttempts(username)
+		return nil
+	}
+	id, _ := strconv.Atoi(strings.TrimPrefix(dir, "challenge-"))
+	if file == "SCOREBOARD.md" {
+		cr.scoreboards.ReloadScoreboard(id)
+		// Scores of attempts come from the scoreboards
+		cr.users.InvalidateAttempts()
+		return nil
+	}
+	return cr.challenges.ReloadChallenge(id)
+}
+
+// scan fingerprints the watched files by unit: each classic challenge
+// directory ("challenge-N"), its scoreboard ("challenge-N/SCOREBOARD.md"), the
+// submission of each user to it ("challenge-N/submissions/username") and each
+// package directory ("packages/name"). Package submissions are not watched.
+func (cr *ContentReloader) scan() map[string]uint64 {
+	hashes := make(map[string]uint64)
+	fs.WalkDir(cr.content.FS(), ".", func(name string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return nil // Files may vanish while a change is written
+		}
+		parts := strings.Split(name, "/")
+		if entry.IsDir() {
+			switch {
+			case name == ".":
+				return nil
+			case len(parts) == 1 && name != packagesDir && !classicChallengeDirRe.MatchString(name):
+				return fs.SkipDir
+			case entry.Name() == "submissions" && (parts[0] == packagesDir || len(parts) != 2):
+				return fs.SkipDir
+			}
+			return nil
+		}
+
+		var unit string
+		switch {
+		case len(parts) < 2:
+			return nil
+		case parts[0] == packagesDir:
+			if len(parts) < 3 {
+				return nil
+			}
+			unit = packagesDir + "/" + parts[1]
+		case parts[1] == "submissions":
+			if len(parts) < 4 {
+				return nil
+			}
+			unit = strings.Join(parts[:3], "/")
+		case len(parts) == 2 && parts[1] == "SCOREBOARD.md":
+			unit = name
+		default:
+			unit = parts[0]
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return nil
+		}
+		hash := fnv.New64a()
+		fmt.Fprintf(hash, "%x %s %d %d", hashes[unit], name, info.Size(), info.ModTime().UnixNano())
+		hashes[unit] = hash.Sum64()
+		return nil
+	})
+	return hashes
+}
@@ -0,0 +1,50 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestContentReloaderRefreshesAttempts(t *testing.T) {
+	root := t.TempDir()
+	for name, content := range map[string]string{
+		"challenge-1/README.md":               "# Challenge 1: Sum\n",
+		"challenge-1/solution-template.go":    "package main\n",
+		"challenge-1/submissions/alice/x.txt": "not a submission yet\n",
+	} {
+		file := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	content := NewContentRoot(root)
+	challenges := NewChallengeService(content)
+	if err := challenges.LoadChallenges(); err != nil {
+		t.Fatal(err)
+	}
+	scoreboards := NewScoreboardService(content)
+	users := NewUserService(content)
+	reloader := NewContentReloader(content, challenges, scoreboards, NewPackageService(content), users)
+
+	if attempts := users.GetUserAttempts("alice", challenges.GetChallenges()); attempts.AttemptedIDs[1] {
+		t.Fatal("alice attempted challenge 1 before submitting")
+	}
+
+	// A pull request with alice's submission was merged
+	submission := filepath.Join(root, "challenge-1", "submissions", "alice", MainSolutionFile)
+	if err := os.WriteFile(submission, []byte("package main\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if changed := reloader.Poll(); !reflect.DeepEqual(changed, []string{"challenge-1/submissions/alice"}) {
+		t.Errorf("changed = %v, want the submission of alice only", changed)
+	}
+	if attempts := users.GetUserAttempts("alice", challenges.GetChallenges()); !attempts.AttemptedIDs[1] {
+		t.Error("alice's merged submission does not show as attempted")
+	}
+}
@@ -0,0 +1,97 @@
+package services
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"web-ui/internal/models"
+)
+
+// "challenge-1/solution-template.go:8:	Sum		100.0%"
+var coverFuncRe = regexp.MustCompile(`^(\S+\.go):(\d+):\s+(\S+)\s+([\d.]+)%$`)
+
+// testArgsForOptions adds the go test flags and environment needed by the run options
+func testArgsForOptions(args []string, env []string, options models.RunOptions, coverProfile string) ([]string, []string) {
+	if options.Race {
+		// The race detector needs cgo
+		args = append(args, "-race")
+		env = append(env, "CGO_ENABLED=1")
+	}
+	if options.Cover {
+		mode := "set"
+		if options.Race {
+			mode = "atomic"
+		}
+		args = append(args, "-covermode="+mode, "-coverprofile="+coverProfile)
+	}
+	return args, env
+}
+
+// collectCoverage turns a coverage profile into per-function coverage
+func (es *ExecutionService) collectCoverage(ctx context.Context, workDir, goRoot, profile string) *models.CoverageReport {
+	if _, err := os.Stat(profile); err != nil {
+		return nil
+	}
+
+	run := es.sandbox.Run(ctx, SandboxCommand{
+		Dir:    workDir,
+		Args:   []string{"go", "tool", "cover", "-func=" + profile},
+		Env:    es.moduleCache.Env(),
+		GoRoot: goRoot,
+	})
+	if run.Err != nil || run.ExitCode != 0 {
+		return nil
+	}
+	return parseCoverFunc(run.Output)
+}
+
+// parseCoverFunc parses the output of `go tool cover -func`
+func parseCoverFunc(output string) *models.CoverageReport {
+	report := &models.CoverageReport{Functions: []models.FunctionCoverage{}}
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "total:") {
+			fields := strings.Fields(line)
+			report.Total, _ = strconv.ParseFloat(strings.TrimSuffix(fields[len(fields)-1], "%"), 64)
+			continue
+		}
+
+		match := coverFuncRe.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		lineNo, _ := strconv.Atoi(match[2])
+		percent, _ := strconv.ParseFloat(match[4], 64)
+		report.Functions = append(report.Functions, models.FunctionCoverage{
+			File:     filepath.Base(match[1]),
+			Line:     lineNo,
+			Function: match[3],
+			Percent:  percent,
+		})
+	}
+	return report
+}
+
+// runVet runs go vet on the workspace and returns its output and diagnostics
+func (es *ExecutionService) runVet(ctx context.Context, workDir, goRoot string) (string, []models.SourceLocation) {
+	run := es.sandbox.Run(ctx, SandboxCommand{
+		Dir:    workDir,
+		Args:   []string{"go", "vet", "./..."},
+		Env:    es.moduleCache.Env(),
+		GoRoot: goRoot,
+	})
+	if run.ExitCode == 0 {
+		return run.Output, nil
+	}
+
+	issues := parseBuildErrors(run.Output)
+	if len(issues) == 0 && strings.TrimSpace(run.Output) != "" {
+		// Unparsed failure; still report it rather than passing silently
+		issues = []models.SourceLocation{{Message: strings.TrimSpace(run.Output)}}
+	}
+	return run.Output, issues
+}
@@ -0,0 +1,551 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"web-ui/internal/models"
+)
+
+// Termination reasons reported when a sandbox limit stops a run
+const (
+	TerminationTimeout  = "timeout"
+	TerminationOOM      = "oom"
+	TerminationSignal   = "signal"
+	TerminationCanceled = "canceled"
+)
+
+// Default limits applied when a challenge does not declare its own
+var defaultExecutionLimits = models.ExecutionLimits{
+	TimeoutSeconds: 60,
+	CPUSeconds:     120,
+	MemoryMB:       2048,
+	FileSizeMB:     64,
+	MaxOutputKB:    1024,
+}
+
+// sandboxEnvPassthrough lists the host environment variables a sandboxed
+// process may see. Everything else (API keys loaded from .env included) is dropped.
+var sandboxEnvPassthrough = []string{
+	"PATH", "GOROOT", "GOPROXY", "GOSUMDB", "GOPRIVATE", "GONOSUMDB", "GONOPROXY",
+	"CGO_ENABLED", "CC", "CXX", "HTTP_PROXY", "HTTPS_PROXY", "NO_PROXY",
+	"http_proxy", "https_proxy", "no_proxy", "SSL_CERT_FILE", "SSL_CERT_DIR",
+}
+
+// sandboxSystemPaths are the host paths an isolated command sees read-only
+// besides its toolchain: programs, libraries and the configuration they read.
+// Paths missing on a system are skipped.
+var sandboxSystemPaths = []string{
+	"/usr", "/bin", "/sbin", "/lib", "/lib32", "/lib64", "/libx32",
+	"/etc/alternatives", "/etc/ld.so.cache", "/etc/ssl", "/etc/pki", "/etc/ca-certificates",
+	"/etc/resolv.conf", "/etc/hosts", "/etc/nsswitch.conf", "/etc/passwd", "/etc/group", "/etc/localtime",
+}
+
+// Sandbox runs untrusted commands with resource limits, a hard kill on
+// deadline and a dedicated process group. Where namespaces are available each
+// command runs isolated: it sees only the system paths, its toolchain, its run
+// directory and the module cache, and has no network unless allowed. Commands
+// only run without isolation when SANDBOX_ALLOW_UNISOLATED=true opts in.
+type Sandbox struct {
+	goRoot          string // Toolchain of "go" commands that don't select one
+	buildCache      string // Filled by trusted builds; other commands build on a private layer over it
+	allowUnisolated bool
+
+	mutex     sync.Mutex
+	isolation bool // Cleared after the first failed attempt to create namespaces, if unisolated runs are allowed
+}
+
+// sandboxView is what an isolated command sees of the host file system. Paths
+// are mounted at the same place; Tmp is mounted at /tmp.
+type sandboxView struct {
+	Root       string // Empty directory the new root is mounted on
+	Tmp        string
+	ReadOnly   []string
+	Writable   []string
+	BuildCache string // Shared build cache layered read-only under the command's GOCACHE
+	Network    bool   // Keep the host network
+}
+
+// SandboxCommand describes a single command to run inside the sandbox
+type SandboxCommand struct {
+	Dir           string
+	Args          []string
+	Env           []string // Extra KEY=VALUE pairs on top of the scrubbed environment
+	GoRoot        string   // Toolchain that runs "go" commands; empty for the go command on PATH
+	Limits        models.ExecutionLimits
+	AllowNetwork  bool
+	WritableCache bool              // The command may add modules to the GOMODCACHE of Env; others see it read-only
+	WritableDir   bool              // The command may write to Dir, as module setup does; others see it read-only
+	FillCache     bool              // The command builds only repository content, so it may write to the shared build cache
+	OnLine        func(line string) // Called for every complete output line as it is produced
+}
+
+// SandboxResult is the outcome of a sandboxed command
+type SandboxResult struct {
+	Output            string
+	ExitCode          int
+	Termination       string
+	TerminationReason string
+	Truncated         bool
+	Err               error // Set when the command could not be started at all
+}
+
+// NewSandbox creates a sandbox configured from the environment.
+// EXECUTION_BUILDCACHE overrides the shared build cache directory.
+func NewSandbox() *Sandbox {
+	buildCache := os.Getenv("EXECUTION_BUILDCACHE")
+	if buildCache == "" {
+		if cacheDir, err := os.UserCacheDir(); err == nil {
+			buildCache = filepath.Join(cacheDir, "go-interview-practice", "build")
+		} else {
+			buildCache = filepath.Join(os.TempDir(), "go-interview-practice-build")
+		}
+	}
+	if absDir, err := filepath.Abs(buildCache); err == nil {
+		buildCache = absDir
+	}
+
+	return &Sandbox{
+		goRoot:          resolveGoEnv("GOROOT"),
+		buildCache:      buildCache,
+		allowUnisolated: strings.EqualFold(os.Getenv("SANDBOX_ALLOW_UNISOLATED"), "true") || os.Getenv("SANDBOX_ALLOW_UNISOLATED") == "1",
+		isolation:       true,
+	}
+}
+
+// resolveGoEnv asks the go command for one of its settings
+func resolveGoEnv(key string) string {
+	output, err := exec.Command("go", "env", key).Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(output))
+}
+
+// mergeLimits fills unset fields of limits with the service defaults
+func mergeLimits(limits models.ExecutionLimits) models.ExecutionLimits {
+	if limits.TimeoutSeconds <= 0 {
+		limits.TimeoutSeconds = defaultExecutionLimits.TimeoutSeconds
+	}
+	if limits.CPUSeconds <= 0 {
+		limits.CPUSeconds = defaultExecutionLimits.CPUSeconds
+	}
+	if limits.MemoryMB <= 0 {
+		limits.MemoryMB = defaultExecutionLimits.MemoryMB
+	}
+	if limits.FileSizeMB <= 0 {
+		limits.FileSizeMB = defaultExecutionLimits.FileSizeMB
+	}
+	if limits.MaxOutputKB <= 0 {
+		limits.MaxOutputKB = defaultExecutionLimits.MaxOutputKB
+	}
+	return limits
+}
+
+// Run executes the command and classifies how it ended
+func (s *Sandbox) Run(ctx context.Context, sc SandboxCommand) SandboxResult {
+	limits := mergeLimits(sc.Limits)
+	runCtx, cancel := context.WithTimeout(ctx, time.Duration(limits.TimeoutSeconds)*time.Second)
+	defer cancel()
+
+	view, err := s.isolationView(sc, limits)
+	if err != nil {
+		return SandboxResult{ExitCode: -1, Err: err}
+	}
+
+	start := func(view *sandboxView) (*exec.Cmd, *limitedBuffer, error) {
+		output := &limitedBuffer{limit: limits.MaxOutputKB * 1024, onLine: sc.OnLine}
+		cmd, err := s.buildCommand(runCtx, sc, limits, view, output)
+		if err == nil {
+			err = cmd.Start()
+		}
+		return cmd, output, err
+	}
+	cmd, output, err := start(view)
+	if err != nil && view != nil {
+		// User namespaces are often disabled in containers
+		if err = s.disableIsolation(err); err == nil {
+			cmd, output, err = start(nil)
+		}
+	}
+	if err != nil {
+		return SandboxResult{ExitCode: -1, Err: err}
+	}
+
+	err = cmd.Wait()
+	output.flush()
+	result := SandboxResult{
+		Output:    output.String(),
+		Truncated: output.truncated,
+	}
+	if result.Truncated {
+		result.Output += fmt.Sprintf("\n... output truncated after %d KB\n", limits.MaxOutputKB)
+	}
+
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+		result.ExitCode = 0
+	case errors.As(err, &exitErr):
+		result.ExitCode = exitErr.ExitCode()
+	default:
+		result.ExitCode = -1
+		result.Err = err
+	}
+
+	result.Termination, result.TerminationReason = classifyTermination(runCtx, ctx, cmd, result.Output, limits)
+	return result
+}
+
+// SandboxProcess is a long-running sandboxed command, such as a language
+// server, that is talked to over its standard input and output
+type SandboxProcess struct {
+	Stdin  io.WriteCloser
+	Stdout io.ReadCloser
+	stderr *limitedBuffer
+	cmd    *exec.Cmd
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// Start launches a long-running command with the sandbox limits. Limits.TimeoutSeconds
+// bounds its lifetime; it is killed earlier when ctx is done or Stop is called.
+func (s *Sandbox) Start(ctx context.Context, sc SandboxCommand) (*SandboxProcess, error) {
+	limits := mergeLimits(sc.Limits)
+	view, err := s.isolationView(sc, limits)
+	if err != nil {
+		return nil, err
+	}
+	runCtx, cancel := context.WithTimeout(ctx, time.Duration(limits.TimeoutSeconds)*time.Second)
+
+	start := func(view *sandboxView) (*SandboxProcess, error) {
+		stderr := &limitedBuffer{limit: limits.MaxOutputKB * 1024}
+		cmd, err := s.buildCommand(runCtx, sc, limits, view, stderr)
+		if err != nil {
+			return nil, err
+		}
+		cmd.Stdout = nil
+		stdin, err := cmd.StdinPipe()
+		if err != nil {
+			return nil, err
+		}
+		stdout, err := cmd.StdoutPipe()
+		if err != nil {
+			return nil, err
+		}
+		if err := cmd.Start(); err != nil {
+			return nil, err
+		}
+		return &SandboxProcess{Stdin: stdin, Stdout: stdout, stderr: stderr, cmd: cmd, cancel: cancel, done: make(chan struct{})}, nil
+	}
+
+	process, err := start(view)
+	if err != nil && view != nil {
+		if err = s.disableIsolation(err); err == nil {
+			process, err = start(nil)
+		}
+	}
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	go func() {
+		process.cmd.Wait()
+		close(process.done)
+	}()
+	return process, nil
+}
+
+// Stop kills the process and waits for it to exit
+func (p *SandboxProcess) Stop() {
+	p.cancel()
+	<-p.done
+}
+
+// Stderr returns what the process wrote to its standard error so far
+func (p *SandboxProcess) Stderr() string {
+	return p.stderr.String()
+}
+
+// buildCommand creates the exec.Cmd with a scrubbed environment and, when view
+// is set, platform isolation
+func (s *Sandbox) buildCommand(ctx context.Context, sc SandboxCommand, limits models.ExecutionLimits, view *sandboxView, output *limitedBuffer) (*exec.Cmd, error) {
+	name := sc.Args[0]
+	if name == "go" && sc.GoRoot != "" {
+		name = filepath.Join(sc.GoRoot, "bin", "go")
+	}
+	cmd := exec.CommandContext(ctx, name, sc.Args[1:]...)
+	cmd.Dir = sc.Dir
+	cmd.Env = s.environment(sc)
+	cmd.Stdout = output
+	cmd.Stderr = output
+	cmd.WaitDelay = 2 * time.Second
+	if err := configureSandboxCommand(cmd, limits, view); err != nil {
+		return nil, err
+	}
+	return cmd, nil
+}
+
+// isolationView returns what an isolated command sees, or nil when commands run
+// without isolation. It fails when isolation is unavailable and unisolated runs
+// are not allowed.
+func (s *Sandbox) isolationView(sc SandboxCommand, limits models.ExecutionLimits) (*sandboxView, error) {
+	if !s.canIsolate() {
+		if s.allowUnisolated {
+			return nil, nil
+		}
+		return nil, errors.New("the sandbox cannot isolate commands on this system; set SANDBOX_ALLOW_UNISOLATED=true to run them without file system and network isolation")
+	}
+
+	runDir := filepath.Dir(sc.Dir)
+	view := &sandboxView{
+		Root:     filepath.Join(runDir, "root"),
+		Tmp:      scratchDir(sc.Dir),
+		ReadOnly: append([]string{}, sandboxSystemPaths...),
+		Writable: []string{runDir},
+		Network:  sc.AllowNetwork || limits.AllowNetwork,
+	}
+	// The workspace's permission bits belong to the command's own user, who
+	// could restore them; a read-only mount over it cannot be undone
+	if !sc.WritableDir {
+		view.ReadOnly = append(view.ReadOnly, sc.Dir)
+	}
+	goRoot := sc.GoRoot
+	if goRoot == "" {
+		goRoot = s.goRoot
+	}
+	if goRoot != "" {
+		view.ReadOnly = append(view.ReadOnly, goRoot)
+	}
+	if filepath.IsAbs(sc.Args[0]) {
+		view.ReadOnly = append(view.ReadOnly, sc.Args[0])
+	}
+	// The caches may not exist yet when they were not warmed
+	mountPoints := []string{view.Root, view.Tmp}
+	if sc.FillCache {
+		view.Writable = append(view.Writable, s.buildCache)
+		mountPoints = append(mountPoints, s.buildCache)
+	} else {
+		view.BuildCache = s.buildCache
+	}
+	if modCache := envValue(sc.Env, "GOMODCACHE"); modCache != "" {
+		if sc.WritableCache {
+			view.Writable = append(view.Writable, modCache)
+		} else {
+			view.ReadOnly = append(view.ReadOnly, modCache)
+		}
+		mountPoints = append(mountPoints, modCache)
+	}
+	for _, dir := range mountPoints {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, fmt.Errorf("failed to prepare the sandbox: %v", err)
+		}
+	}
+	return view, nil
+}
+
+// envValue returns the value of key in env, where later entries win
+func envValue(env []string, key string) string {
+	for i := len(env) - 1; i >= 0; i-- {
+		if value, ok := strings.CutPrefix(env[i], key+"="); ok {
+			return value
+		}
+	}
+	return ""
+}
+
+// environment builds the minimal environment visible to sandboxed processes
+func (s *Sandbox) environment(sc SandboxCommand) []string {
+	env := []string{}
+	for _, key := range sandboxEnvPassthrough {
+		if value, ok := os.LookupEnv(key); ok {
+			env = append(env, key+"="+value)
+		}
+	}
+
+	// Private scratch space next to the workspace
+	scratch := scratchDir(sc.Dir)
+	os.MkdirAll(scratch, 0755)
+	env = append(env,
+		"HOME="+scratch,
+		"TMPDIR="+scratch,
+		"GOTMPDIR="+scratch,
+		"GOTOOLCHAIN=local",
+		"GOTELEMETRY=off",
+	)
+	if sc.FillCache {
+		env = append(env, "GOCACHE="+s.buildCache)
+	} else {
+		// A writable shared build cache would let one run plant compiled packages
+		// for the next; isolated commands see the shared one under this
+		env = append(env, "GOCACHE="+filepath.Join(scratch, "go-build"))
+	}
+	if sc.GoRoot != "" {
+		// Later entries win, so tools started by go resolve to the same toolchain
+		env = append(env, "GOROOT="+sc.GoRoot, "PATH="+filepath.Join(sc.GoRoot, "bin")+string(os.PathListSeparator)+os.Getenv("PATH"))
+	}
+
+	return append(env, sc.Env...)
+}
+
+// scratchDir returns the writable directory that belongs to a workspace
+func scratchDir(workDir string) string {
+	return filepath.Join(filepath.Dir(workDir), "scratch")
+}
+
+// canIsolate reports whether commands are still run in their own namespaces
+func (s *Sandbox) canIsolate() bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.isolation && namespaceIsolationSupported
+}
+
+// disableIsolation handles a failure to start an isolated command. When
+// unisolated runs are allowed it stops further namespace attempts and returns
+// nil, so the command is retried without; otherwise it returns the error.
+func (s *Sandbox) disableIsolation(err error) error {
+	if !s.allowUnisolated {
+		return fmt.Errorf("could not isolate the command: %v; set SANDBOX_ALLOW_UNISOLATED=true to run it without file system and network isolation", err)
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.isolation {
+		fmt.Printf("Warning: namespaces unavailable, running tests without file system and network isolation: %v\n", err)
+		s.isolation = false
+	}
+	return nil
+}
+
+// classifyTermination works out which limit, if any, ended the run
+func classifyTermination(runCtx, parentCtx context.Context, cmd *exec.Cmd, output string, limits models.ExecutionLimits) (string, string) {
+	if parentCtx.Err() != nil {
+		return TerminationCanceled, "Run canceled before it finished"
+	}
+	if errors.Is(runCtx.Err(), context.DeadlineExceeded) {
+		return TerminationTimeout, fmt.Sprintf("Wall-clock limit of %ds exceeded", limits.TimeoutSeconds)
+	}
+	if strings.Contains(output, "panic: test timed out after") {
+		return TerminationTimeout, fmt.Sprintf("Wall-clock limit of %ds exceeded", limits.TimeoutSeconds)
+	}
+	if strings.Contains(output, "signal: CPU time limit exceeded") || strings.Contains(output, "SIGXCPU") {
+		return TerminationTimeout, fmt.Sprintf("CPU time limit of %ds exceeded", limits.CPUSeconds)
+	}
+	if strings.Contains(output, "fatal error: runtime: out of memory") ||
+		strings.Contains(output, "runtime: cannot allocate memory") ||
+		strings.Contains(output, "fatal error: out of memory") {
+		return TerminationOOM, fmt.Sprintf("Memory limit of %d MB exceeded", limits.MemoryMB)
+	}
+
+	// The test binary is a child of `go test`, which reports its death in the output
+	if strings.Contains(output, "signal: killed") {
+		return TerminationOOM, "Process was killed, most likely by the kernel OOM killer"
+	}
+	if sig := terminatingSignal(cmd); sig != "" {
+		return TerminationSignal, "Process terminated by signal " + sig
+	}
+	if idx := strings.Index(output, "signal: "); idx >= 0 && strings.Contains(output, "FAIL") {
+		line := output[idx:]
+		if end := strings.IndexByte(line, '\n'); end >= 0 {
+			line = line[:end]
+		}
+		return TerminationSignal, "Test binary terminated by " + line
+	}
+	return "", ""
+}
+
+// limitedBuffer keeps at most limit bytes of output and drops the rest.
+// Kept output is also passed line by line to onLine, if set.
+type limitedBuffer struct {
+	buf       bytes.Buffer
+	limit     int
+	truncated bool
+	onLine    func(line string)
+	lineStart int // Offset in buf of the first line not yet passed to onLine
+	mutex     sync.Mutex
+}
+
+func (lb *limitedBuffer) Write(p []byte) (int, error) {
+	lb.mutex.Lock()
+	defer lb.mutex.Unlock()
+
+	remaining := lb.limit - lb.buf.Len()
+	if remaining <= 0 {
+		lb.truncated = lb.truncated || len(p) > 0
+		return len(p), nil
+	}
+	if len(p) > remaining {
+		lb.buf.Write(p[:remaining])
+		lb.truncated = true
+	} else {
+		lb.buf.Write(p)
+	}
+	lb.emitLines()
+	return len(p), nil
+}
+
+// emitLines passes every newly completed line to onLine. Must be called with the mutex held.
+func (lb *limitedBuffer) emitLines() {
+	if lb.onLine == nil {
+		return
+	}
+	for {
+		pending := lb.buf.Bytes()[lb.lineStart:]
+		end := bytes.IndexByte(pending, '\n')
+		if end < 0 {
+			return
+		}
+		lb.onLine(string(pending[:end]))
+		lb.lineStart += end + 1
+	}
+}
+
+// flush passes a final unterminated line to onLine
+func (lb *limitedBuffer) flush() {
+	lb.mutex.Lock()
+	defer lb.mutex.Unlock()
+	if lb.onLine != nil && lb.lineStart < lb.buf.Len() {
+		lb.onLine(string(lb.buf.Bytes()[lb.lineStart:]))
+		lb.lineStart = lb.buf.Len()
+	}
+}
+
+func (lb *limitedBuffer) String() string {
+	lb.mutex.Lock()
+	defer lb.mutex.Unlock()
+	return lb.buf.String()
+}
+
+// makeWorkspaceReadOnly removes write permission from a prepared workspace.
+// Isolated commands also see it through a read-only mount; the permissions
+// protect it when commands run unisolated.
+func makeWorkspaceReadOnly(dir string) error {
+	return filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return os.Chmod(path, 0555)
+		}
+		return os.Chmod(path, 0444)
+	})
+}
+
+// removeWorkspace restores write permission so a read-only workspace can be deleted
+func removeWorkspace(dir string) {
+	filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err == nil && info.IsDir() {
+			os.Chmod(path, 0755)
+		}
+		return nil
+	})
+	os.RemoveAll(dir)
+}
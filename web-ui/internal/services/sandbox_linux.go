@@ -0,0 +1,346 @@
+//go:build linux
+
+package services
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"web-ui/internal/models"
+)
+
+// sandboxLauncherArg makes the server binary act as a tiny launcher that
+// isolates and applies rlimits to itself and then execs the real command, so
+// both are in place before any untrusted code runs
+const sandboxLauncherArg = "__sandbox-exec"
+
+// namespaceIsolationSupported is true where commands can get their own namespaces
+const namespaceIsolationSupported = true
+
+// sandboxDevices are the device nodes an isolated command can use
+var sandboxDevices = []string{"/dev/null", "/dev/zero", "/dev/full", "/dev/random", "/dev/urandom"}
+
+// prctl options of linux/prctl.h
+const (
+	prCapBSetDrop   = 24
+	prSetNoNewPrivs = 38
+)
+
+// statfs flags of linux/statfs.h
+const (
+	statfsNoDev      = 0x4
+	statfsNoExec     = 0x8
+	statfsNoAtime    = 0x400
+	statfsNoDirAtime = 0x800
+	statfsRelAtime   = 0x1000
+)
+
+// configureSandboxCommand wraps the command in the launcher and gives it its own
+// process group. With a view it also gets its own user, mount and PID
+// namespaces, and a network namespace without interfaces unless view.Network.
+func configureSandboxCommand(cmd *exec.Cmd, limits models.ExecutionLimits, view *sandboxView) error {
+	// Without the launcher the command would run without limits
+	self, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("the sandbox launcher is unavailable: %v", err)
+	}
+	launcherArgs := []string{
+		self, sandboxLauncherArg,
+		"-cpu", strconv.Itoa(limits.CPUSeconds),
+		"-as", strconv.FormatInt(int64(limits.MemoryMB)<<20, 10),
+		"-fsize", strconv.FormatInt(int64(limits.FileSizeMB)<<20, 10),
+	}
+
+	attr := &syscall.SysProcAttr{Setpgid: true}
+	if view != nil {
+		launcherArgs = append(launcherArgs, "-root", view.Root, "-tmp", view.Tmp, "-buildcache", view.BuildCache)
+		for _, path := range view.ReadOnly {
+			launcherArgs = append(launcherArgs, "-ro", path)
+		}
+		for _, path := range view.Writable {
+			launcherArgs = append(launcherArgs, "-rw", path)
+		}
+
+		// The launcher is root of the user namespace to set up the mounts; it
+		// drops every capability before running the command
+		attr.Cloneflags = syscall.CLONE_NEWUSER | syscall.CLONE_NEWNS | syscall.CLONE_NEWPID
+		if !view.Network {
+			attr.Cloneflags |= syscall.CLONE_NEWNET
+		}
+		attr.UidMappings = []syscall.SysProcIDMap{{ContainerID: 0, HostID: os.Getuid(), Size: 1}}
+		attr.GidMappings = []syscall.SysProcIDMap{{ContainerID: 0, HostID: os.Getgid(), Size: 1}}
+		attr.GidMappingsEnableSetgroups = false
+	}
+	cmd.Args = append(append(launcherArgs, "--"), cmd.Args...)
+	cmd.Path = self
+	cmd.SysProcAttr = attr
+
+	// Kill the whole process group so compilers and test binaries die with `go`
+	cmd.Cancel = func() error {
+		if cmd.Process == nil {
+			return nil
+		}
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+	return nil
+}
+
+// terminatingSignal returns the signal that killed the command, if any
+func terminatingSignal(cmd *exec.Cmd) string {
+	if cmd.ProcessState == nil {
+		return ""
+	}
+	status, ok := cmd.ProcessState.Sys().(syscall.WaitStatus)
+	if !ok || !status.Signaled() {
+		return ""
+	}
+	return status.Signal().String()
+}
+
+// RunSandboxLauncherIfRequested turns the current process into the rlimit
+// launcher when started with sandboxLauncherArg. It must be called first in main.
+func RunSandboxLauncherIfRequested() {
+	if len(os.Args) < 2 || os.Args[1] != sandboxLauncherArg {
+		return
+	}
+
+	flags := flag.NewFlagSet(sandboxLauncherArg, flag.ExitOnError)
+	cpu := flags.Uint64("cpu", 0, "CPU seconds per process")
+	as := flags.Uint64("as", 0, "address space bytes per process")
+	fsize := flags.Uint64("fsize", 0, "maximum file size in bytes")
+	root := flags.String("root", "", "empty directory to build an isolated root on")
+	tmp := flags.String("tmp", "", "directory mounted at /tmp of the isolated root")
+	buildCache := flags.String("buildcache", "", "build cache layered read-only under $GOCACHE")
+	var readOnly, writable pathList
+	flags.Var(&readOnly, "ro", "host path the command sees read-only (repeatable)")
+	flags.Var(&writable, "rw", "host path the command may write to (repeatable)")
+	flags.Parse(os.Args[2:])
+
+	args := flags.Args()
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "sandbox: no command given")
+		os.Exit(2)
+	}
+
+	if *root != "" {
+		if err := isolateFileSystem(*root, *tmp, readOnly, writable, *buildCache); err != nil {
+			fmt.Fprintf(os.Stderr, "sandbox: %v\n", err)
+			os.Exit(2)
+		}
+	}
+
+	limits := map[int]uint64{
+		syscall.RLIMIT_CPU:   *cpu,
+		syscall.RLIMIT_AS:    *as,
+		syscall.RLIMIT_FSIZE: *fsize,
+		syscall.RLIMIT_CORE:  0,
+	}
+	for resource, value := range limits {
+		if value == 0 && resource != syscall.RLIMIT_CORE {
+			continue
+		}
+		if err := syscall.Setrlimit(resource, &syscall.Rlimit{Cur: value, Max: value}); err != nil {
+			fmt.Fprintf(os.Stderr, "sandbox: failed to set rlimit %d: %v\n", resource, err)
+			os.Exit(2)
+		}
+	}
+
+	if *root != "" {
+		if err := dropCapabilities(); err != nil {
+			fmt.Fprintf(os.Stderr, "sandbox: %v\n", err)
+			os.Exit(2)
+		}
+	}
+
+	path, err := exec.LookPath(args[0])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "sandbox: %v\n", err)
+		os.Exit(2)
+	}
+	err = syscall.Exec(path, args, os.Environ())
+	fmt.Fprintf(os.Stderr, "sandbox: exec failed: %v\n", err)
+	os.Exit(2)
+}
+
+// pathList collects the values of a repeated flag
+type pathList []string
+
+func (l *pathList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *pathList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// sandboxMount is a host path mounted into the isolated root
+type sandboxMount struct {
+	source, target string
+	readOnly       bool
+}
+
+// isolateFileSystem replaces the root of the launcher's mount namespace with a
+// read-only tmpfs on root. The new root holds only the given host paths, tmp
+// mounted at /tmp, the sandbox devices and a /proc of the PID namespace.
+// buildCache, if set, is layered under $GOCACHE.
+func isolateFileSystem(root, tmp string, readOnly, writable []string, buildCache string) error {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	// Keep the mounts below from propagating back to the host
+	if err := syscall.Mount("", "/", "", syscall.MS_REC|syscall.MS_PRIVATE, ""); err != nil {
+		return fmt.Errorf("failed to make mounts private: %v", err)
+	}
+	if err := syscall.Mount("tmpfs", root, "tmpfs", syscall.MS_NOSUID|syscall.MS_NODEV, "mode=0755"); err != nil {
+		return fmt.Errorf("failed to mount the new root: %v", err)
+	}
+
+	var mounts []sandboxMount
+	for _, path := range readOnly {
+		mounts = append(mounts, sandboxMount{path, path, true})
+	}
+	for _, path := range append(writable, sandboxDevices...) {
+		mounts = append(mounts, sandboxMount{path, path, false})
+	}
+	if tmp != "" {
+		mounts = append(mounts, sandboxMount{tmp, "/tmp", false})
+	}
+	// Parents first, so no mount hides another
+	sort.Slice(mounts, func(i, j int) bool { return mounts[i].target < mounts[j].target })
+	for _, m := range mounts {
+		if err := bindMount(m.source, filepath.Join(root, m.target), m.readOnly); err != nil {
+			return err
+		}
+	}
+
+	if goCache := os.Getenv("GOCACHE"); buildCache != "" && goCache != "" {
+		layerBuildCache(buildCache, filepath.Join(root, goCache))
+	}
+
+	// Commands run without /proc where it cannot be mounted, as in some containers
+	proc := filepath.Join(root, "proc")
+	if err := os.Mkdir(proc, 0555); err == nil {
+		syscall.Mount("proc", proc, "proc", syscall.MS_NOSUID|syscall.MS_NODEV|syscall.MS_NOEXEC, "")
+	}
+	for name, target := range map[string]string{"fd": "/proc/self/fd", "stdin": "/proc/self/fd/0", "stdout": "/proc/self/fd/1", "stderr": "/proc/self/fd/2"} {
+		os.Symlink(target, filepath.Join(root, "dev", name))
+	}
+
+	oldRoot := filepath.Join(root, ".old-root")
+	if err := os.Mkdir(oldRoot, 0700); err != nil {
+		return err
+	}
+	if err := syscall.PivotRoot(root, oldRoot); err != nil {
+		return fmt.Errorf("failed to switch to the new root: %v", err)
+	}
+	if err := syscall.Unmount("/.old-root", syscall.MNT_DETACH); err != nil {
+		return fmt.Errorf("failed to detach the host root: %v", err)
+	}
+	os.Remove("/.old-root")
+	if err := syscall.Mount("", "/", "", syscall.MS_REMOUNT|syscall.MS_BIND|syscall.MS_RDONLY|syscall.MS_NOSUID|syscall.MS_NODEV, ""); err != nil {
+		return fmt.Errorf("failed to make the new root read-only: %v", err)
+	}
+	return os.Chdir(cwd)
+}
+
+// bindMount mounts source at target, creating target. Missing sources are
+// skipped, as systems differ in which of the system paths exist.
+func bindMount(source, target string, readOnly bool) error {
+	info, err := os.Stat(source)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		err = os.MkdirAll(target, 0755)
+	} else if err = os.MkdirAll(filepath.Dir(target), 0755); err == nil {
+		var file *os.File
+		if file, err = os.OpenFile(target, os.O_CREATE|os.O_WRONLY, 0644); err == nil {
+			file.Close()
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create mount point for %s: %v", source, err)
+	}
+
+	// Writable paths are the run's own, so their submounts are not needed
+	flags := uintptr(syscall.MS_BIND)
+	if readOnly {
+		flags |= syscall.MS_REC
+	}
+	if err := syscall.Mount(source, target, "", flags, ""); err != nil {
+		return fmt.Errorf("failed to mount %s: %v", source, err)
+	}
+	if !readOnly {
+		return nil
+	}
+
+	// A remount in a user namespace must keep the flags the host mount locked
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(target, &stat); err != nil {
+		return fmt.Errorf("failed to inspect %s: %v", source, err)
+	}
+	flags = syscall.MS_REMOUNT | syscall.MS_BIND | syscall.MS_RDONLY | syscall.MS_NOSUID
+	for statfsFlag, mountFlag := range map[int64]uintptr{
+		statfsNoDev:      syscall.MS_NODEV,
+		statfsNoExec:     syscall.MS_NOEXEC,
+		statfsNoAtime:    syscall.MS_NOATIME,
+		statfsNoDirAtime: syscall.MS_NODIRATIME,
+		statfsRelAtime:   syscall.MS_RELATIME,
+	} {
+		if stat.Flags&statfsFlag != 0 {
+			flags |= mountFlag
+		}
+	}
+	if err := syscall.Mount("", target, "", flags, ""); err != nil {
+		return fmt.Errorf("failed to make %s read-only: %v", source, err)
+	}
+	return nil
+}
+
+// layerBuildCache mounts an overlay at dir whose lower layer is the shared
+// build cache, so a build reuses its packages but writes only below dir. Where
+// the overlay cannot be mounted, as on older kernels, dir stays an empty cache.
+func layerBuildCache(buildCache, dir string) {
+	if _, err := os.Stat(buildCache); err != nil {
+		return
+	}
+	upper, work := dir+".upper", dir+".work"
+	for _, d := range []string{dir, upper, work} {
+		if err := os.MkdirAll(d, 0755); err != nil {
+			return
+		}
+	}
+	options := fmt.Sprintf("lowerdir=%s,upperdir=%s,workdir=%s", buildCache, upper, work)
+	syscall.Mount("overlay", dir, "overlay", syscall.MS_NOSUID|syscall.MS_NODEV, options)
+}
+
+// dropCapabilities empties the capability bounding set, so the command runs
+// without the capabilities the launcher has in its user namespace and cannot
+// undo the mounts, and keeps it from gaining privileges on exec
+func dropCapabilities() error {
+	for capability := uintptr(0); ; capability++ {
+		_, _, errno := syscall.RawSyscall(syscall.SYS_PRCTL, prCapBSetDrop, capability, 0)
+		if errno == syscall.EINVAL {
+			break // Past the last capability
+		}
+		if errno != 0 {
+			return fmt.Errorf("failed to drop capability %d: %v", capability, errno)
+		}
+	}
+	if _, _, errno := syscall.RawSyscall(syscall.SYS_PRCTL, prSetNoNewPrivs, 1, 0); errno != 0 {
+		return fmt.Errorf("failed to set no_new_privs: %v", errno)
+	}
+	return nil
+}
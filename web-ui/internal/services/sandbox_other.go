@@ -0,0 +1,32 @@
+//go:build !linux
+
+package services
+
+import (
+	"os/exec"
+
+	"web-ui/internal/models"
+)
+
+// namespaceIsolationSupported is false outside Linux; only timeouts are enforced
+const namespaceIsolationSupported = false
+
+// configureSandboxCommand only arranges for the process to be killed on deadline,
+// rlimits and namespaces are Linux features
+func configureSandboxCommand(cmd *exec.Cmd, limits models.ExecutionLimits, view *sandboxView) error {
+	cmd.Cancel = func() error {
+		if cmd.Process == nil {
+			return nil
+		}
+		return cmd.Process.Kill()
+	}
+	return nil
+}
+
+// terminatingSignal is not reported outside Linux
+func terminatingSignal(cmd *exec.Cmd) string {
+	return ""
+}
+
+// RunSandboxLauncherIfRequested is a no-op outside Linux
+func RunSandboxLauncherIfRequested() {}
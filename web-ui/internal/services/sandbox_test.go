@@ -0,0 +1,72 @@
+package services
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestMain lets the test binary act as the sandbox launcher, as main does
+func TestMain(m *testing.M) {
+	RunSandboxLauncherIfRequested()
+	os.Exit(m.Run())
+}
+
+func TestSandboxWorkspaceIsReadOnly(t *testing.T) {
+	if !namespaceIsolationSupported {
+		t.Skip("the sandbox isolates commands only on Linux")
+	}
+	t.Setenv("EXECUTION_BUILDCACHE", t.TempDir())
+	t.Setenv("SANDBOX_ALLOW_UNISOLATED", "false")
+	sandbox := NewSandbox()
+
+	tempDir := t.TempDir()
+	t.Cleanup(func() { removeWorkspace(tempDir) })
+	workDir := filepath.Join(tempDir, "workspace")
+	if err := os.Mkdir(workDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(workDir, "solution.go"), []byte("package main\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := makeWorkspaceReadOnly(workDir); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		script string
+	}{
+		{"plant a test file", "echo 'package main' > planted_test.go"},
+		{"restore write permission", "chmod -R u+w . && echo 'package main' > planted_test.go"},
+		{"replace a file", "chmod u+w . solution.go; echo 'package other' > solution.go"},
+		{"rename the workspace", "cd .. && mv workspace elsewhere && mkdir workspace && echo 'package main' > workspace/planted_test.go"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			run := sandbox.Run(context.Background(), SandboxCommand{
+				Dir:  workDir,
+				Args: []string{"sh", "-c", tt.script},
+			})
+			if run.Err != nil {
+				t.Skipf("the sandbox cannot isolate commands here: %v", run.Err)
+			}
+			if run.ExitCode == 0 {
+				t.Errorf("the command succeeded:\n%s", run.Output)
+			}
+
+			entries, err := os.ReadDir(workDir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(entries) != 1 || entries[0].Name() != "solution.go" {
+				t.Errorf("workspace holds %v, want only solution.go", entries)
+			}
+			if content, _ := os.ReadFile(filepath.Join(workDir, "solution.go")); string(content) != "package main\n" {
+				t.Errorf("solution.go = %q", content)
+			}
+		})
+	}
+}
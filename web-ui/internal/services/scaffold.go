@@ -0,0 +1,272 @@
+package services
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// scaffoldFiles holds the templates new challenges are created from. Files
+// ending in .tmpl are text/templates executed with a scaffoldData; the
+// run_tests.sh scripts are copied as they are.
+//
+//go:embed scaffold
+var scaffoldFiles embed.FS
+
+var scaffoldTemplates = template.Must(template.New("scaffold").Funcs(template.FuncMap{
+	"json": scaffoldJSON,
+}).ParseFS(scaffoldFiles, "scaffold/*.tmpl"))
+
+// scaffoldJSON formats a string or a list of strings as JSON the way the
+// metadata files write them: unescaped and with lists on one line
+func scaffoldJSON(v interface{}) (string, error) {
+	quote := func(s string) (string, error) {
+		var buf bytes.Buffer
+		encoder := json.NewEncoder(&buf)
+		encoder.SetEscapeHTML(false)
+		err := encoder.Encode(s)
+		return strings.TrimSuffix(buf.String(), "\n"), err
+	}
+	switch v := v.(type) {
+	case string:
+		return quote(v)
+	case []string:
+		items := make([]string, len(v))
+		for i, s := range v {
+			var err error
+			if items[i], err = quote(s); err != nil {
+				return "", err
+			}
+		}
+		return "[" + strings.Join(items, ", ") + "]", nil
+	}
+	return "", fmt.Errorf("cannot format %T as JSON", v)
+}
+
+// Package challenge directories such as "challenge-3-validation-errors"
+var packageChallengeDirRe = regexp.MustCompile(`^challenge-([1-9][0-9]*)-`)
+
+// Runs of characters that cannot appear in a package challenge slug
+var slugSeparatorRe = regexp.MustCompile(`[^a-z0-9]+`)
+
+// NewChallenge describes a challenge for ScaffoldService to create
+type NewChallenge struct {
+	Package    string   // Package to add the challenge to; empty for a classic challenge
+	Title      string   // Title shown on the site
+	Difficulty string   // Beginner, Intermediate or Advanced
+	Slug       string   // Package challenges: the name after "challenge-N-"; derived from the title when empty
+	Tags       []string // Tags shown on the challenge page
+}
+
+// scaffoldData is what the scaffold templates are executed with
+type scaffoldData struct {
+	NewChallenge
+	Number int    // N of challenge-N
+	ID     string // Directory name, challenge-N or challenge-N-slug
+	Path   string // Content path of the challenge directory
+	Module string // Module path of go.mod
+}
+
+// ScaffoldService creates new challenges in a repository checkout
+type ScaffoldService struct {
+	content *ContentRoot
+}
+
+// NewScaffoldService creates a scaffold service for content
+func NewScaffoldService(content *ContentRoot) *ScaffoldService {
+	return &ScaffoldService{content: content}
+}
+
+// CreateChallenge creates the directory of a new challenge with the next free
+// number and returns its content path. Package challenges are appended to the
+// learning_path of their package.json. The generated template compiles against
+// a stub test it fails, so the challenge passes the validator until its author
+// fills in the TODOs.
+func (s *ScaffoldService) CreateChallenge(spec NewChallenge) (string, error) {
+	if s.content.ReadOnly() {
+		return "", fmt.Errorf("challenges cannot be created in %s", s.content)
+	}
+	spec.Title = strings.TrimSpace(spec.Title)
+	if spec.Title == "" {
+		return "", errors.New("the challenge needs a title")
+	}
+	switch spec.Difficulty {
+	case "Beginner", "Intermediate", "Advanced":
+	default:
+		return "", fmt.Errorf("difficulty %q is not Beginner, Intermediate or Advanced", spec.Difficulty)
+	}
+	if spec.Tags == nil {
+		spec.Tags = []string{}
+	}
+
+	data := scaffoldData{NewChallenge: spec}
+	var err error
+	if spec.Package == "" {
+		data.Number, err = s.nextNumber(".", classicChallengeDirRe)
+		data.ID = fmt.Sprintf("challenge-%d", data.Number)
+		data.Path = data.ID
+		data.Module = data.ID
+	} else {
+		err = s.preparePackageChallenge(&data)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	dir := filepath.Join(s.content.Dir(), filepath.FromSlash(data.Path))
+	if _, err := os.Stat(dir); err == nil {
+		return "", fmt.Errorf("%s already exists", data.Path)
+	}
+	if err := s.writeFiles(dir, data); err != nil {
+		os.RemoveAll(dir)
+		return "", err
+	}
+	if spec.Package != "" {
+		if err := s.addToLearningPath(spec.Package, data.ID); err != nil {
+			os.RemoveAll(dir)
+			return "", err
+		}
+	}
+	return data.Path, nil
+}
+
+// preparePackageChallenge numbers and names a package challenge
+func (s *ScaffoldService) preparePackageChallenge(data *scaffoldData) error {
+	if !contentNameRe.MatchString(data.Package) {
+		return fmt.Errorf("invalid package name %q", data.Package)
+	}
+	packageDir := path.Join(packagesDir, data.Package)
+	if _, err := s.content.Stat(packageDir, "package.json"); err != nil {
+		return fmt.Errorf("package %s has no package.json: %v", data.Package, err)
+	}
+
+	slug := data.Slug
+	if slug == "" {
+		slug = strings.Trim(slugSeparatorRe.ReplaceAllString(strings.ToLower(data.Title), "-"), "-")
+	}
+	if slug == "" || !contentNameRe.MatchString(slug) {
+		return fmt.Errorf("invalid challenge slug %q; use lower-case letters, digits and hyphens", slug)
+	}
+
+	number, err := s.nextNumber(packageDir, packageChallengeDirRe)
+	if err != nil {
+		return err
+	}
+	data.Number = number
+	data.ID = fmt.Sprintf("challenge-%d-%s", number, slug)
+	data.Path = path.Join(packageDir, data.ID)
+	data.Module = fmt.Sprintf("%s-challenge-%d", data.Package, number)
+	return nil
+}
+
+// nextNumber returns one more than the highest challenge number in dir, where
+// nameRe matches challenge directory names and captures their number
+func (s *ScaffoldService) nextNumber(dir string, nameRe *regexp.Regexp) (int, error) {
+	entries, err := s.content.ReadDir(dir)
+	if err != nil {
+		return 0, fmt.Errorf("could not list %s: %v", dir, err)
+	}
+	highest := 0
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		if match := nameRe.FindStringSubmatch(entry.Name()); match != nil {
+			n, _ := strconv.Atoi(match[1])
+			highest = max(highest, n)
+		}
+	}
+	return highest + 1, nil
+}
+
+// writeFiles creates dir with the scaffold files executed for data
+func (s *ScaffoldService) writeFiles(dir string, data scaffoldData) error {
+	if err := os.MkdirAll(filepath.Join(dir, "submissions"), 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %v", dir, err)
+	}
+
+	for _, tmpl := range scaffoldTemplates.Templates() {
+		name := strings.TrimSuffix(tmpl.Name(), ".tmpl")
+		if name == tmpl.Name() {
+			continue // The root template
+		}
+		var content strings.Builder
+		if err := tmpl.Execute(&content, data); err != nil {
+			return fmt.Errorf("failed to generate %s: %v", name, err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content.String()), 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %v", name, err)
+		}
+	}
+
+	script := "scaffold/run_tests.sh"
+	if data.Package != "" {
+		script = "scaffold/package_run_tests.sh"
+	}
+	content, err := fs.ReadFile(scaffoldFiles, script)
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "run_tests.sh"), content, 0755); err != nil {
+		return fmt.Errorf("failed to write run_tests.sh: %v", err)
+	}
+	return nil
+}
+
+// Matches the learning_path array of a package.json; its entries are plain names
+var learningPathRe = regexp.MustCompile(`"learning_path"\s*:\s*\[([^\]]*)\]`)
+
+// addToLearningPath appends challengeID to the learning_path of a package.
+// package.json is edited in place, so the rest of the file keeps its layout.
+func (s *ScaffoldService) addToLearningPath(packageName, challengeID string) error {
+	file := filepath.Join(s.content.Dir(), packagesDir, packageName, "package.json")
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		return fmt.Errorf("failed to read package.json: %v", err)
+	}
+	loc := learningPathRe.FindSubmatchIndex(content)
+	if loc == nil {
+		return fmt.Errorf("%s has no learning_path", file)
+	}
+
+	entry, _ := json.Marshal(challengeID)
+	entries := content[loc[2]:loc[3]]
+	var insert string
+	if last := strings.LastIndexByte(string(entries), '"'); last < 0 {
+		insert = "\n    " + string(entry) + "\n  "
+	} else {
+		// Indent the new entry like the last one
+		indent := "    "
+		if lineStart := strings.LastIndexByte(string(entries[:last]), '\n'); lineStart >= 0 {
+			line := string(entries[lineStart+1:])
+			indent = line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+		}
+		// Insert after the closing quote of the last entry
+		loc[3] = loc[2] + last + 1
+		insert = ",\n" + indent + string(entry)
+	}
+	updated := string(content[:loc[3]]) + insert + string(content[loc[3]:])
+
+	var metadata PackageMetadata
+	if err := json.Unmarshal([]byte(updated), &metadata); err != nil {
+		return fmt.Errorf("could not add %s to the learning_path of %s: %v", challengeID, file, err)
+	}
+	if n := len(metadata.LearningPath); n == 0 || metadata.LearningPath[n-1] != challengeID {
+		return fmt.Errorf("could not add %s to the learning_path of %s", challengeID, file)
+	}
+	if err := ioutil.WriteFile(file, []byte(updated), 0644); err != nil {
+		return fmt.Errorf("failed to write package.json: %v", err)
+	}
+	return nil
+}
@@ -1,10 +1,9 @@
 package services
 
 import (
-	"io/ioutil"
-	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"web-ui/internal/models"
@@ -12,36 +11,108 @@ import (
 
 // ScoreboardService handles scoreboard-related operations
 type ScoreboardService struct {
-	scoreboards models.ScoreboardMap
+	content *ContentRoot
+
+	mu          sync.RWMutex
+	scoreboards models.ScoreboardMap             // Replaced on every change, never modified once published
+	added       map[int][]models.ScoreboardEntry // Submissions added since their SCOREBOARD.md was loaded
 }
 
-// NewScoreboardService creates a new scoreboard service
-func NewScoreboardService() *ScoreboardService {
+// NewScoreboardService creates a new scoreboard service for the scoreboards in content
+func NewScoreboardService(content *ContentRoot) *ScoreboardService {
 	return &ScoreboardService{
+		content:     content,
 		scoreboards: make(models.ScoreboardMap),
+		added:       make(map[int][]models.ScoreboardEntry),
 	}
 }
 
-// LoadScoreboards loads all scoreboards from the filesystem
+// LoadScoreboards loads all scoreboards from the content root, replacing the
+// loaded ones. Submissions added since are kept until their users show up in
+// the files.
 func (ss *ScoreboardService) LoadScoreboards(challenges models.ChallengeMap) error {
+	loaded := make(models.ScoreboardMap)
 	for id := range challenges {
-		challengeDir := filepath.Join("..", "challenge-"+strconv.Itoa(id))
-		ss.loadScoreboardForChallenge(id, challengeDir)
+		if entries, ok := ss.loadScoreboardForChallenge(id); ok {
+			loaded[id] = entries
+		}
+	}
+
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	for id := range ss.added {
+		if _, ok := challenges[id]; !ok {
+			delete(ss.added, id)
+		}
 	}
+	scoreboards := make(models.ScoreboardMap, len(loaded))
+	for id := range challenges {
+		entries, ok := loaded[id]
+		if entries = ss.mergeAdded(id, entries); ok || len(entries) > 0 {
+			scoreboards[id] = entries
+		}
+	}
+	ss.scoreboards = scoreboards
 	return nil
 }
 
+// ReloadScoreboard reloads the scoreboard of challenge id from its
+// SCOREBOARD.md, keeping the submissions added to it since that are not in the file
+func (ss *ScoreboardService) ReloadScoreboard(id int) {
+	entries, ok := ss.loadScoreboardForChallenge(id)
+
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	scoreboards := ss.copyScoreboards()
+	if entries = ss.mergeAdded(id, entries); ok || len(entries) > 0 {
+		scoreboards[id] = entries
+	} else {
+		delete(scoreboards, id)
+	}
+	ss.scoreboards = scoreboards
+}
+
+// mergeAdded appends the submissions added to challenge id to its entries
+// loaded from SCOREBOARD.md. Users now in the file had their submissions
+// merged into it, so theirs are dropped. The caller holds ss.mu.
+func (ss *ScoreboardService) mergeAdded(id int, entries []models.ScoreboardEntry) []models.ScoreboardEntry {
+	inFile := make(map[string]bool, len(entries))
+	for _, entry := range entries {
+		inFile[strings.ToLower(entry.Username)] = true
+	}
+	var kept []models.ScoreboardEntry
+	for _, entry := range ss.added[id] {
+		if !inFile[strings.ToLower(entry.Username)] {
+			kept = append(kept, entry)
+		}
+	}
+	if len(kept) == 0 {
+		delete(ss.added, id)
+	} else {
+		ss.added[id] = kept
+	}
+	return append(entries, kept...)
+}
+
 // loadScoreboardForChallenge loads the scoreboard for a specific challenge
-func (ss *ScoreboardService) loadScoreboardForChallenge(id int, dir string) {
-	scoreboardPath := filepath.Join(dir, "SCOREBOARD.md")
-	scoreboardContent, err := ioutil.ReadFile(scoreboardPath)
+func (ss *ScoreboardService) loadScoreboardForChallenge(id int) ([]models.ScoreboardEntry, bool) {
+	scoreboardContent, err := ss.content.ReadFile("challenge-"+strconv.Itoa(id), "SCOREBOARD.md")
 	if err != nil {
-		return
+		return nil, false
 	}
 
 	// Parse scoreboard markdown table
-	entries := ss.parseScoreboardMarkdown(string(scoreboardContent), id)
-	ss.scoreboards[id] = entries
+	return ss.parseScoreboardMarkdown(string(scoreboardContent), id), true
+}
+
+// copyScoreboards returns a copy of the scoreboard map to change and publish;
+// the caller holds ss.mu
+func (ss *ScoreboardService) copyScoreboards() models.ScoreboardMap {
+	scoreboards := make(models.ScoreboardMap, len(ss.scoreboards)+1)
+	for id, entries := range ss.scoreboards {
+		scoreboards[id] = entries
+	}
+	return scoreboards
 }
 
 // parseScoreboardMarkdown parses the scoreboard markdown table
@@ -85,10 +156,15 @@ func (ss *ScoreboardService) parseScoreboardMarkdown(content string, challengeID
 		}
 
 		var username string
+		var testsPassed, testsTotal int
 
 		if format == 1 {
 			// Format is: | Username | Passed Tests | Total Tests |
 			username = strings.TrimSpace(parts[1])
+			if len(parts) >= 4 {
+				testsPassed, _ = strconv.Atoi(strings.TrimSpace(parts[2]))
+				testsTotal, _ = strconv.Atoi(strings.TrimSpace(parts[3]))
+			}
 		} else {
 			// Format is: | Rank | Username | Solution | Date Submitted |
 			username = strings.TrimSpace(parts[2])
@@ -104,6 +180,8 @@ func (ss *ScoreboardService) parseScoreboardMarkdown(content string, challengeID
 			Username:    username,
 			ChallengeID: challengeID,
 			SubmittedAt: time.Now(),
+			TestsPassed: testsPassed,
+			TestsTotal:  testsTotal,
 		}
 
 		entries = append(entries, entry)
@@ -124,12 +202,17 @@ func (ss *ScoreboardService) isNumeric(s string) bool {
 
 // GetScoreboard returns the scoreboard for a specific challenge
 func (ss *ScoreboardService) GetScoreboard(challengeID int) ([]models.ScoreboardEntry, bool) {
+	ss.mu.RLock()
+	defer ss.mu.RUnlock()
 	scoreboard, exists := ss.scoreboards[challengeID]
 	return scoreboard, exists
 }
 
-// GetAllScoreboards returns all scoreboards
+// GetAllScoreboards returns all scoreboards. The map is a snapshot that later
+// changes replace rather than modify; callers must not change it.
 func (ss *ScoreboardService) GetAllScoreboards() models.ScoreboardMap {
+	ss.mu.RLock()
+	defer ss.mu.RUnlock()
 	return ss.scoreboards
 }
 
@@ -139,12 +222,19 @@ func (ss *ScoreboardService) AddSubmission(submission models.Submission) {
 		Username:    submission.Username,
 		ChallengeID: submission.ChallengeID,
 		SubmittedAt: submission.SubmittedAt,
+		TestsPassed: submission.TestsPassed,
+		TestsTotal:  submission.TestsTotal,
 	}
 
-	// Add to the scoreboard for this challenge
-	if ss.scoreboards[submission.ChallengeID] == nil {
-		ss.scoreboards[submission.ChallengeID] = []models.ScoreboardEntry{}
-	}
-
-	ss.scoreboards[submission.ChallengeID] = append(ss.scoreboards[submission.ChallengeID], entry)
+	// Add to a copy of the scoreboard for this challenge, so earlier snapshots stay unchanged
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	previous := ss.scoreboards[submission.ChallengeID]
+	entries := make([]models.ScoreboardEntry, len(previous), len(previous)+1)
+	copy(entries, previous)
+
+	scoreboards := ss.copyScoreboards()
+	scoreboards[submission.ChallengeID] = append(entries, entry)
+	ss.scoreboards = scoreboards
+	ss.added[submission.ChallengeID] = append(ss.added[submission.ChallengeID], entry)
 }
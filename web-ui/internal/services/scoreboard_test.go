@@ -0,0 +1,87 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"web-ui/internal/models"
+)
+
+// writeScoreboard writes the SCOREBOARD.md of challenge-1 in root with a row per user
+func writeScoreboard(t *testing.T, root string, usernames ...string) {
+	t.Helper()
+	content := "# Scoreboard for challenge-1\n| Username   | Passed Tests | Total Tests |\n|------------|--------------|-------------|\n"
+	for _, username := range usernames {
+		content += "| " + username + " | 1 | 1 |\n"
+	}
+	if err := os.WriteFile(filepath.Join(root, "challenge-1", "SCOREBOARD.md"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// scoreboardUsers returns the sorted usernames on the scoreboard of challenge-1
+func scoreboardUsers(ss *ScoreboardService) string {
+	entries, _ := ss.GetScoreboard(1)
+	var usernames []string
+	for _, entry := range entries {
+		usernames = append(usernames, entry.Username)
+	}
+	sort.Strings(usernames)
+	return strings.Join(usernames, ",")
+}
+
+func TestScoreboardReloadKeepsAddedSubmissions(t *testing.T) {
+	root := t.TempDir()
+	if err := os.Mkdir(filepath.Join(root, "challenge-1"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	writeScoreboard(t, root, "alice")
+	challenges := models.ChallengeMap{1: {ID: 1}}
+
+	ss := NewScoreboardService(NewContentRoot(root))
+	if err := ss.LoadScoreboards(challenges); err != nil {
+		t.Fatal(err)
+	}
+	for _, username := range []string{"bob", "carol"} {
+		ss.AddSubmission(models.Submission{Username: username, ChallengeID: 1, SubmittedAt: time.Now(), TestsPassed: 1, TestsTotal: 1})
+	}
+	if got := scoreboardUsers(ss); got != "alice,bob,carol" {
+		t.Fatalf("scoreboard = %s, want alice,bob,carol", got)
+	}
+
+	// A pull request adding bob was merged
+	writeScoreboard(t, root, "alice", "Bob")
+	ss.ReloadScoreboard(1)
+	if got := scoreboardUsers(ss); got != "Bob,alice,carol" {
+		t.Errorf("after reloading the scoreboard = %s, want Bob,alice,carol", got)
+	}
+
+	// A full reload keeps carol, who is still not in the file
+	if err := ss.LoadScoreboards(challenges); err != nil {
+		t.Fatal(err)
+	}
+	if got := scoreboardUsers(ss); got != "Bob,alice,carol" {
+		t.Errorf("after a full reload the scoreboard = %s, want Bob,alice,carol", got)
+	}
+
+	// Without the file only the added submissions are left
+	if err := os.Remove(filepath.Join(root, "challenge-1", "SCOREBOARD.md")); err != nil {
+		t.Fatal(err)
+	}
+	ss.ReloadScoreboard(1)
+	if got := scoreboardUsers(ss); got != "carol" {
+		t.Errorf("without SCOREBOARD.md the scoreboard = %s, want carol", got)
+	}
+
+	// Submissions to removed challenges go with them
+	if err := ss.LoadScoreboards(models.ChallengeMap{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := ss.GetScoreboard(1); ok {
+		t.Error("scoreboard of a removed challenge is still loaded")
+	}
+}
@@ -0,0 +1,60 @@
+package services
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// Run event types sent while a test run is in progress
+const (
+	RunEventQueued    = "queued"     // Waiting for a worker; Position is set
+	RunEventStatus    = "status"     // Setup progress; Message is set
+	RunEventCompile   = "compile"    // Compiler or module output; Output is set
+	RunEventTestStart = "test-start" // A test or subtest started
+	RunEventTestPass  = "test-pass"
+	RunEventTestFail  = "test-fail"
+	RunEventTestSkip  = "test-skip"
+	RunEventOutput    = "output" // A line printed by the tests; Test is empty for package output
+	RunEventResult    = "result" // The run finished; Result is set
+	RunEventError     = "error"  // The run could not be started; Message is set
+)
+
+// RunEvent is a single progress update of a streamed test run
+type RunEvent struct {
+	Type      string           `json:"type"`
+	Test      string           `json:"test,omitempty"`
+	Output    string           `json:"output,omitempty"`
+	ElapsedMs int64            `json:"elapsedMs,omitempty"`
+	Position  int              `json:"position,omitempty"`
+	Message   string           `json:"message,omitempty"`
+	Result    *ExecutionResult `json:"result,omitempty"`
+}
+
+// runEventsFromLine converts one line of go test -json output into run events
+func runEventsFromLine(line string) []RunEvent {
+	if strings.TrimSpace(line) == "" {
+		return nil
+	}
+
+	var event testEvent
+	if !strings.HasPrefix(line, "{") || json.Unmarshal([]byte(line), &event) != nil {
+		// Plain text comes from the go command itself, e.g. compiler errors
+		return []RunEvent{{Type: RunEventCompile, Output: line}}
+	}
+
+	switch event.Action {
+	case "build-output":
+		return []RunEvent{{Type: RunEventCompile, Output: strings.TrimRight(event.Output, "\n")}}
+	case "run":
+		return []RunEvent{{Type: RunEventTestStart, Test: event.Test}}
+	case "output":
+		return []RunEvent{{Type: RunEventOutput, Test: event.Test, Output: strings.TrimRight(event.Output, "\n")}}
+	case "pass", "fail", "skip":
+		if event.Test == "" {
+			// The package summary is covered by the final result
+			return nil
+		}
+		return []RunEvent{{Type: "test-" + event.Action, Test: event.Test, ElapsedMs: int64(event.Elapsed * 1000)}}
+	}
+	return nil
+}
@@ -0,0 +1,160 @@
+package services
+
+import (
+	"fmt"
+	"go/build"
+	"go/build/constraint"
+	"go/parser"
+	"go/token"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// MainSolutionFile is the file every submission contains; it takes the place of the challenge template
+const MainSolutionFile = "solution-template.go"
+
+// Limits on multi-file submissions
+const (
+	maxSubmissionFiles = 16
+	maxSubmissionBytes = 512 * 1024
+)
+
+// Allowed submission file names: a flat, lower-case .go file such as "user_service.go"
+var submissionFileNameRe = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*\.go$`)
+
+// SubmissionFiles returns the files of a submission. Requests either send a
+// map of file names to contents or, like single-file clients, only code.
+func SubmissionFiles(code string, files map[string]string) map[string]string {
+	if len(files) > 0 {
+		return files
+	}
+	return map[string]string{MainSolutionFile: code}
+}
+
+// ValidateSubmissionFiles checks submission files against the allow-list: the
+// main solution file is present, every name is a plain non-test .go file that
+// every build includes, and the additional files belong to the same package as
+// the challenge template.
+func ValidateSubmissionFiles(files map[string]string, template string) error {
+	if _, ok := files[MainSolutionFile]; !ok {
+		return fmt.Errorf("submission must contain %s", MainSolutionFile)
+	}
+	if len(files) > maxSubmissionFiles {
+		return fmt.Errorf("submission has %d files, at most %d are allowed", len(files), maxSubmissionFiles)
+	}
+
+	packageName := packageClause(template)
+	total := 0
+	for _, name := range SortedFileNames(files) {
+		content := files[name]
+		total += len(content)
+
+		if !submissionFileNameRe.MatchString(name) {
+			return fmt.Errorf("file name %q is not allowed: use lower-case letters, digits, '-' and '_' with a .go extension", name)
+		}
+		if strings.HasSuffix(name, "_test.go") {
+			return fmt.Errorf("file %s is not allowed: test files are provided by the challenge", name)
+		}
+		if err := checkBuildConstraints(name, content); err != nil {
+			return err
+		}
+		// The main file keeps the existing behaviour: a wrong package is a compile error
+		if packageName != "" && name != MainSolutionFile {
+			if filePackage := packageClause(content); filePackage != packageName {
+				return fmt.Errorf("file %s must be in package %s", name, packageName)
+			}
+		}
+	}
+	if total > maxSubmissionBytes {
+		return fmt.Errorf("submission is %d KB, at most %d KB are allowed", total/1024, maxSubmissionBytes/1024)
+	}
+	return nil
+}
+
+// noPlatform matches no GOOS or GOARCH, so it leaves out every file whose name
+// carries one, such as helpers_windows.go
+var noPlatform = build.Context{GOOS: "none", GOARCH: "none", Compiler: "gc"}
+
+// checkBuildConstraints rejects a file the go command could leave out of a
+// build, so tests and tools would see a different package than the submitter
+func checkBuildConstraints(name, content string) error {
+	if file, err := parser.ParseFile(token.NewFileSet(), "", content, parser.PackageClauseOnly|parser.ParseComments); err == nil {
+		for _, group := range file.Comments {
+			if group.Pos() >= file.Package {
+				break
+			}
+			for _, comment := range group.List {
+				if constraint.IsGoBuild(comment.Text) || constraint.IsPlusBuild(comment.Text) {
+					return fmt.Errorf("file %s is not allowed: build constraints such as %q are not supported", name, comment.Text)
+				}
+			}
+		}
+	}
+
+	// Only the name decides here; the content was checked above
+	platform := noPlatform
+	platform.OpenFile = func(string) (io.ReadCloser, error) {
+		return ioutil.NopCloser(strings.NewReader("package p\n")), nil
+	}
+	if match, err := platform.MatchFile("", name); err == nil && !match {
+		return fmt.Errorf("file %s is not allowed: the name limits it to one GOOS or GOARCH", name)
+	}
+	return nil
+}
+
+// SortedFileNames returns the file names in a stable order
+func SortedFileNames(files map[string]string) []string {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// WriteSubmissionFiles writes the submitted files into a submission directory,
+// storing the main solution file under mainName. Existing files are only
+// replaced if they are regular files, so writes never follow a symbolic link.
+func WriteSubmissionFiles(dir string, files map[string]string, mainName string) error {
+	if _, ok := files[mainName]; ok && mainName != MainSolutionFile {
+		return fmt.Errorf("file %s is not allowed: the main solution file is saved under that name", mainName)
+	}
+	for _, name := range SortedFileNames(files) {
+		target := name
+		if name == MainSolutionFile {
+			target = mainName
+		}
+		path := filepath.Join(dir, target)
+		if info, err := os.Lstat(path); err == nil && !info.Mode().IsRegular() {
+			return fmt.Errorf("%s is not a regular file", path)
+		}
+		if err := ioutil.WriteFile(path, []byte(files[name]), 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// joinSources concatenates the submitted files, e.g. for import detection
+func joinSources(files map[string]string) string {
+	var sources strings.Builder
+	for _, name := range SortedFileNames(files) {
+		sources.WriteString(files[name])
+		sources.WriteString("\n")
+	}
+	return sources.String()
+}
+
+// packageClause returns the package name declared by a Go source file, or "" if it cannot be parsed
+func packageClause(source string) string {
+	file, err := parser.ParseFile(token.NewFileSet(), "", source, parser.PackageClauseOnly)
+	if err != nil {
+		return ""
+	}
+	return file.Name.Name
+}
@@ -0,0 +1,93 @@
+package services
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateSubmissionFiles(t *testing.T) {
+	const template = "package main\n\nfunc Sum(a, b int) int { return 0 }\n"
+	const solution = "package main\n\nfunc Sum(a, b int) int { return a + b }\n"
+
+	tests := []struct {
+		name    string
+		files   map[string]string
+		wantErr string
+	}{
+		{
+			name:  "main file only",
+			files: map[string]string{MainSolutionFile: solution},
+		},
+		{
+			name:  "additional file",
+			files: map[string]string{MainSolutionFile: solution, "helpers.go": "// Helpers for Sum\npackage main\n"},
+		},
+		{
+			name:    "main file missing",
+			files:   map[string]string{"helpers.go": "package main\n"},
+			wantErr: "must contain " + MainSolutionFile,
+		},
+		{
+			name:    "invalid file name",
+			files:   map[string]string{MainSolutionFile: solution, "../helpers.go": "package main\n"},
+			wantErr: "is not allowed",
+		},
+		{
+			name:    "test file",
+			files:   map[string]string{MainSolutionFile: solution, "sum_test.go": "package main\n"},
+			wantErr: "test files are provided by the challenge",
+		},
+		{
+			name:    "wrong package",
+			files:   map[string]string{MainSolutionFile: solution, "helpers.go": "package other\n"},
+			wantErr: "must be in package main",
+		},
+		{
+			name:    "go:build constraint",
+			files:   map[string]string{MainSolutionFile: solution, "helpers.go": "//go:build ignore\n\npackage main\n"},
+			wantErr: "build constraints",
+		},
+		{
+			name:    "plus build constraint after a comment",
+			files:   map[string]string{MainSolutionFile: solution, "helpers.go": "// Helpers\n\n// +build !linux\n\npackage main\n"},
+			wantErr: "build constraints",
+		},
+		{
+			name:    "constraint in the main file",
+			files:   map[string]string{MainSolutionFile: "//go:build linux\n\n" + solution},
+			wantErr: "build constraints",
+		},
+		{
+			name:  "constraint-like comment after the package clause",
+			files: map[string]string{MainSolutionFile: solution, "helpers.go": "package main\n\n//go:build ignore\n"},
+		},
+		{
+			name:    "GOOS file name",
+			files:   map[string]string{MainSolutionFile: solution, "helpers_windows.go": "package main\n"},
+			wantErr: "GOOS or GOARCH",
+		},
+		{
+			name:    "GOARCH file name",
+			files:   map[string]string{MainSolutionFile: solution, "sum_linux_amd64.go": "package main\n"},
+			wantErr: "GOOS or GOARCH",
+		},
+		{
+			name:    "too large",
+			files:   map[string]string{MainSolutionFile: solution, "data.go": "package main\n\n// " + strings.Repeat("x", maxSubmissionBytes)},
+			wantErr: "KB are allowed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateSubmissionFiles(tt.files, template)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+			} else if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("err = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,207 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// GitHub usernames: letters, digits and single hyphens, not starting or ending with a hyphen
+var githubUsernameRe = regexp.MustCompile(`^[A-Za-z0-9](?:[A-Za-z0-9]|-[A-Za-z0-9])*$`)
+
+// maxGitHubUsername is the longest username GitHub allows
+const maxGitHubUsername = 39
+
+// Package and package challenge directory names such as "gin" and "challenge-1-basic-routing"
+var contentNameRe = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+
+// ValidateUsername checks that username is a valid GitHub username, which also
+// makes it a safe directory name
+func ValidateUsername(username string) error {
+	if username == "" {
+		return fmt.Errorf("username is required")
+	}
+	if len(username) > maxGitHubUsername || !githubUsernameRe.MatchString(username) {
+		return fmt.Errorf("invalid username %q: GitHub usernames have at most %d letters, digits and single hyphens, and do not start or end with a hyphen", username, maxGitHubUsername)
+	}
+	return nil
+}
+
+// SubmissionPaths resolves the directories submissions are saved to. It only
+// accepts GitHub usernames and challenges that exist in the repository, and
+// keeps every path inside the repository root, following symbolic links, so
+// request fields cannot direct writes elsewhere. The PR check applies the
+// same rules to the files a pull request changes. Read-only content snapshots
+// have nowhere to save to, so every directory is refused for them.
+type SubmissionPaths struct {
+	root string // Absolute repository directory, "" for snapshots
+}
+
+// NewSubmissionPaths creates a resolver for the repository of content
+func NewSubmissionPaths(content *ContentRoot) *SubmissionPaths {
+	return &SubmissionPaths{root: content.Dir()}
+}
+
+// Root returns the absolute repository root, or "" for a read-only snapshot
+func (sp *SubmissionPaths) Root() string {
+	return sp.root
+}
+
+// errReadOnlyContent is returned for every directory of a read-only snapshot
+var errReadOnlyContent = errors.New("submissions cannot be saved: the site serves a read-only content snapshot")
+
+// ChallengeDir returns the submission directory of a user for a classic
+// challenge. Errors describe invalid input.
+func (sp *SubmissionPaths) ChallengeDir(username string, challengeID int) (string, error) {
+	if err := ValidateUsername(username); err != nil {
+		return "", err
+	}
+	if sp.root == "" {
+		return "", errReadOnlyContent
+	}
+	challengeDir := fmt.Sprintf("challenge-%d", challengeID)
+	if challengeID <= 0 || !sp.isChallengeDir(challengeDir) {
+		return "", fmt.Errorf("unknown challenge %d", challengeID)
+	}
+	return sp.confine(filepath.Join(challengeDir, "submissions", username))
+}
+
+// PackageChallengeDir returns the submission directory of a user for a
+// package challenge. Errors describe invalid input.
+func (sp *SubmissionPaths) PackageChallengeDir(username, packageName, challengeID string) (string, error) {
+	if err := ValidateUsername(username); err != nil {
+		return "", err
+	}
+	if !contentNameRe.MatchString(packageName) {
+		return "", fmt.Errorf("invalid package name %q", packageName)
+	}
+	if !contentNameRe.MatchString(challengeID) {
+		return "", fmt.Errorf("invalid challenge ID %q", challengeID)
+	}
+	if sp.root == "" {
+		return "", errReadOnlyContent
+	}
+	challengeDir := filepath.Join(packagesDir, packageName, challengeID)
+	if !sp.isChallengeDir(challengeDir) {
+		return "", fmt.Errorf("unknown challenge %s in package %s", challengeID, packageName)
+	}
+	return sp.confine(filepath.Join(challengeDir, "submissions", username))
+}
+
+// isChallengeDir reports whether rel is a directory of the repository holding a challenge template
+func (sp *SubmissionPaths) isChallengeDir(rel string) bool {
+	info, err := os.Lstat(filepath.Join(sp.root, rel, MainSolutionFile))
+	return err == nil && info.Mode().IsRegular()
+}
+
+// confine returns the absolute path of rel after checking that it stays in the
+// repository once the symbolic links along its existing part are followed
+func (sp *SubmissionPaths) confine(rel string) (string, error) {
+	path := filepath.Join(sp.root, rel)
+	root, err := filepath.EvalSymlinks(sp.root)
+	if err != nil {
+		return "", fmt.Errorf("repository root %s is not accessible: %v", sp.root, err)
+	}
+
+	for existing := path; ; existing = filepath.Dir(existing) {
+		resolved, err := filepath.EvalSymlinks(existing)
+		if err == nil {
+			if !isWithin(root, resolved) {
+				return "", fmt.Errorf("%s leads outside the repository", filepath.ToSlash(rel))
+			}
+			break
+		}
+		if filepath.Dir(existing) == existing {
+			break
+		}
+	}
+	return path, nil
+}
+
+// isWithin reports whether path is dir or inside it
+func isWithin(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// SaveSubmission writes files into dir, a directory returned by ChallengeDir
+// or PackageChallengeDir, storing the main solution file as mainName. It
+// returns the git commands that commit the files with commitMessage.
+func (sp *SubmissionPaths) SaveSubmission(dir string, files map[string]string, mainName, commitMessage string) SaveSubmissionResponse {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return SaveSubmissionResponse{Success: false, Message: fmt.Sprintf("Failed to create %s: %v", dir, err)}
+	}
+	if err := WriteSubmissionFiles(dir, files, mainName); err != nil {
+		return SaveSubmissionResponse{Success: false, Message: fmt.Sprintf("Failed to save solution: %v", err)}
+	}
+
+	relativeDir, err := filepath.Rel(sp.root, dir)
+	if err != nil {
+		relativeDir = dir
+	}
+	return SaveSubmissionResponse{
+		Success:  true,
+		Message:  "Solution saved to filesystem",
+		FilePath: filepath.Join(dir, mainName),
+		GitCommands: []string{
+			"cd " + sp.root,
+			fmt.Sprintf("git add %s", filepath.ToSlash(relativeDir)),
+			fmt.Sprintf("git commit -m \"%s\"", commitMessage),
+			"git push origin main",
+		},
+	}
+}
+
+// CheckChangedFile applies the saving rules to a file a pull request by author
+// changes, given as a slash-separated path from the repository root. It
+// reports whether the file is in a submission directory; such files must be
+// flat .go files in the author's own directory of a known challenge, and
+// package submissions may not add a file named like the challenge template.
+func (sp *SubmissionPaths) CheckChangedFile(author, file string) (bool, error) {
+	parts := strings.Split(file, "/")
+
+	var username, name string
+	var err error
+	switch {
+	case len(parts) >= 3 && strings.HasPrefix(parts[0], "challenge-") && parts[1] == "submissions":
+		id, convErr := strconv.Atoi(strings.TrimPrefix(parts[0], "challenge-"))
+		if convErr != nil || parts[0] != fmt.Sprintf("challenge-%d", id) {
+			return true, fmt.Errorf("%s: invalid challenge directory %s", file, parts[0])
+		}
+		if len(parts) != 4 {
+			return true, fmt.Errorf("%s: submission files must be directly in %s/submissions/<username>/", file, parts[0])
+		}
+		username, name = parts[2], parts[3]
+		_, err = sp.ChallengeDir(username, id)
+	case len(parts) >= 5 && parts[0] == "packages" && parts[3] == "submissions":
+		if len(parts) != 6 {
+			return true, fmt.Errorf("%s: submission files must be directly in %s/submissions/<username>/", file, strings.Join(parts[:3], "/"))
+		}
+		username, name = parts[4], parts[5]
+		_, err = sp.PackageChallengeDir(username, parts[1], parts[2])
+		if err == nil && name == MainSolutionFile {
+			// The tests run solution.go under this name, so it would be replaced
+			return true, fmt.Errorf("%s: package submissions keep their main file as solution.go, %s is not allowed", file, MainSolutionFile)
+		}
+	default:
+		return false, nil
+	}
+
+	if err != nil {
+		return true, fmt.Errorf("%s: %v", file, err)
+	}
+	if !strings.EqualFold(username, author) {
+		return true, fmt.Errorf("%s: only %s may change this submission, not %s", file, username, author)
+	}
+	if !submissionFileNameRe.MatchString(name) {
+		return true, fmt.Errorf("%s: submission files must be lower-case .go files", file)
+	}
+	if info, err := os.Lstat(filepath.Join(sp.root, filepath.FromSlash(file))); err == nil && !info.Mode().IsRegular() {
+		return true, fmt.Errorf("%s: submission files must be regular files", file)
+	}
+	return true, nil
+}
@@ -0,0 +1,269 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newTestSubmissionPaths creates a repository with one classic and one package
+// challenge, a classic challenge whose submissions link outside the
+// repository, and a submission file that is a symbolic link
+func newTestSubmissionPaths(t *testing.T) *SubmissionPaths {
+	t.Helper()
+	root := t.TempDir()
+	outside := t.TempDir()
+	for _, dir := range []string{"challenge-1", "challenge-2", "packages/gin/challenge-1-basic-routing", "challenge-1/submissions/alice"} {
+		if err := os.MkdirAll(filepath.Join(root, filepath.FromSlash(dir)), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, dir := range []string{"challenge-1", "challenge-2", "packages/gin/challenge-1-basic-routing"} {
+		if err := os.WriteFile(filepath.Join(root, filepath.FromSlash(dir), MainSolutionFile), []byte("package main\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink(outside, filepath.Join(root, "challenge-2", "submissions")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Join(outside, "secret.go"), filepath.Join(root, "challenge-1", "submissions", "alice", "link.go")); err != nil {
+		t.Fatal(err)
+	}
+	return NewSubmissionPaths(NewContentRoot(root))
+}
+
+func TestValidateUsername(t *testing.T) {
+	tests := []struct {
+		username string
+		valid    bool
+	}{
+		{"alice", true},
+		{"Alice-Smith", true},
+		{"a1-b2-c3", true},
+		{strings.Repeat("a", 39), true},
+		{"", false},
+		{strings.Repeat("a", 40), false},
+		{"-alice", false},
+		{"alice-", false},
+		{"al--ice", false},
+		{"al_ice", false},
+		{"../../web-ui", false},
+		{"alice/bob", false},
+		{".", false},
+	}
+
+	for _, tt := range tests {
+		err := ValidateUsername(tt.username)
+		if tt.valid && err != nil {
+			t.Errorf("ValidateUsername(%q) = %v, want valid", tt.username, err)
+		} else if !tt.valid && err == nil {
+			t.Errorf("ValidateUsername(%q) accepted an invalid username", tt.username)
+		}
+	}
+}
+
+func TestSubmissionPathsConfine(t *testing.T) {
+	sp := newTestSubmissionPaths(t)
+
+	tests := []struct {
+		name    string
+		rel     string
+		wantErr string
+	}{
+		{name: "existing directory", rel: "challenge-1/submissions/alice"},
+		{name: "directory yet to be created", rel: "challenge-1/submissions/bob"},
+		{name: "parent directory", rel: "../outside", wantErr: "leads outside the repository"},
+		{name: "symbolic link to outside", rel: "challenge-2/submissions/alice", wantErr: "leads outside the repository"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path, err := sp.confine(filepath.FromSlash(tt.rel))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("confine(%q) = %q, %v; want error containing %q", tt.rel, path, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("confine(%q): %v", tt.rel, err)
+			}
+			if want := filepath.Join(sp.Root(), filepath.FromSlash(tt.rel)); path != want {
+				t.Errorf("confine(%q) = %q, want %q", tt.rel, path, want)
+			}
+		})
+	}
+}
+
+func TestSubmissionPathsChallengeDirs(t *testing.T) {
+	sp := newTestSubmissionPaths(t)
+
+	if dir, err := sp.ChallengeDir("alice", 1); err != nil || dir != filepath.Join(sp.Root(), "challenge-1", "submissions", "alice") {
+		t.Errorf("ChallengeDir(alice, 1) = %q, %v", dir, err)
+	}
+	if _, err := sp.ChallengeDir("alice", 2); err == nil {
+		t.Error("ChallengeDir accepted submissions linking outside the repository")
+	}
+	if _, err := sp.ChallengeDir("alice", 3); err == nil {
+		t.Error("ChallengeDir accepted an unknown challenge")
+	}
+	if _, err := sp.ChallengeDir("../../web-ui", 1); err == nil {
+		t.Error("ChallengeDir accepted an invalid username")
+	}
+
+	if dir, err := sp.PackageChallengeDir("alice", "gin", "challenge-1-basic-routing"); err != nil || dir != filepath.Join(sp.Root(), "packages", "gin", "challenge-1-basic-routing", "submissions", "alice") {
+		t.Errorf("PackageChallengeDir(alice, gin, challenge-1-basic-routing) = %q, %v", dir, err)
+	}
+	for _, args := range [][2]string{{"..", "challenge-1-basic-routing"}, {"gin", "../challenge-1"}, {"gin", "challenge-2-middleware"}, {"echo", "challenge-1-basic-routing"}} {
+		if _, err := sp.PackageChallengeDir("alice", args[0], args[1]); err == nil {
+			t.Errorf("PackageChallengeDir(alice, %s, %s) accepted an invalid challenge", args[0], args[1])
+		}
+	}
+
+	snapshot := NewSubmissionPaths(NewContentRootFS(os.DirFS(sp.Root()), "snapshot"))
+	if _, err := snapshot.ChallengeDir("alice", 1); err != errReadOnlyContent {
+		t.Errorf("ChallengeDir of a snapshot = %v, want %v", err, errReadOnlyContent)
+	}
+}
+
+func TestCheckChangedFile(t *testing.T) {
+	sp := newTestSubmissionPaths(t)
+
+	tests := []struct {
+		name           string
+		author         string
+		file           string
+		wantSubmission bool
+		wantErr        string
+	}{
+		{
+			name:   "file outside submissions",
+			author: "alice",
+			file:   "challenge-1/README.md",
+		},
+		{
+			name:   "web-ui file",
+			author: "alice",
+			file:   "web-ui/main.go",
+		},
+		{
+			name:           "own classic submission",
+			author:         "alice",
+			file:           "challenge-1/submissions/alice/solution-template.go",
+			wantSubmission: true,
+		},
+		{
+			name:           "author name differs in case",
+			author:         "Alice",
+			file:           "challenge-1/submissions/alice/helpers.go",
+			wantSubmission: true,
+		},
+		{
+			name:           "own package submission",
+			author:         "alice",
+			file:           "packages/gin/challenge-1-basic-routing/submissions/alice/solution.go",
+			wantSubmission: true,
+		},
+		{
+			name:           "another user's submission",
+			author:         "mallory",
+			file:           "challenge-1/submissions/alice/solution-template.go",
+			wantSubmission: true,
+			wantErr:        "only alice may change this submission",
+		},
+		{
+			name:           "nested file",
+			author:         "alice",
+			file:           "challenge-1/submissions/alice/sub/solution.go",
+			wantSubmission: true,
+			wantErr:        "must be directly in challenge-1/submissions/<username>/",
+		},
+		{
+			name:           "file directly in submissions",
+			author:         "alice",
+			file:           "challenge-1/submissions/solution.go",
+			wantSubmission: true,
+			wantErr:        "must be directly in",
+		},
+		{
+			name:           "non-canonical challenge directory",
+			author:         "alice",
+			file:           "challenge-01/submissions/alice/solution.go",
+			wantSubmission: true,
+			wantErr:        "invalid challenge directory",
+		},
+		{
+			name:           "unknown challenge",
+			author:         "alice",
+			file:           "challenge-3/submissions/alice/solution.go",
+			wantSubmission: true,
+			wantErr:        "unknown challenge 3",
+		},
+		{
+			name:           "submissions linking outside the repository",
+			author:         "alice",
+			file:           "challenge-2/submissions/alice/solution.go",
+			wantSubmission: true,
+			wantErr:        "leads outside the repository",
+		},
+		{
+			name:           "invalid username directory",
+			author:         "alice",
+			file:           "challenge-1/submissions/al_ice/solution.go",
+			wantSubmission: true,
+			wantErr:        "invalid username",
+		},
+		{
+			name:           "not a Go file",
+			author:         "alice",
+			file:           "challenge-1/submissions/alice/run.sh",
+			wantSubmission: true,
+			wantErr:        "lower-case .go files",
+		},
+		{
+			name:           "symbolic link",
+			author:         "alice",
+			file:           "challenge-1/submissions/alice/link.go",
+			wantSubmission: true,
+			wantErr:        "must be regular files",
+		},
+		{
+			name:           "package template name",
+			author:         "alice",
+			file:           "packages/gin/challenge-1-basic-routing/submissions/alice/solution-template.go",
+			wantSubmission: true,
+			wantErr:        "solution-template.go is not allowed",
+		},
+		{
+			name:           "unknown package challenge",
+			author:         "alice",
+			file:           "packages/gin/challenge-9-missing/submissions/alice/solution.go",
+			wantSubmission: true,
+			wantErr:        "unknown challenge challenge-9-missing in package gin",
+		},
+		{
+			name:           "nested package file",
+			author:         "alice",
+			file:           "packages/gin/challenge-1-basic-routing/submissions/alice/x/solution.go",
+			wantSubmission: true,
+			wantErr:        "must be directly in packages/gin/challenge-1-basic-routing/submissions/<username>/",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			submission, err := sp.CheckChangedFile(tt.author, tt.file)
+			if submission != tt.wantSubmission {
+				t.Errorf("submission = %v, want %v", submission, tt.wantSubmission)
+			}
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+			} else if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("err = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
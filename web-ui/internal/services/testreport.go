@@ -0,0 +1,288 @@
+package services
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"web-ui/internal/models"
+)
+
+// testEvent mirrors the records emitted by `go test -json` (see cmd/test2json)
+type testEvent struct {
+	Time        time.Time `json:"Time"`
+	Action      string    `json:"Action"`
+	Package     string    `json:"Package"`
+	ImportPath  string    `json:"ImportPath"`
+	Test        string    `json:"Test"`
+	Elapsed     float64   `json:"Elapsed"`
+	Output      string    `json:"Output"`
+	FailedBuild string    `json:"FailedBuild"`
+}
+
+var (
+	// "    solution_test.go:25: expected 3, got 4"
+	testLogLocationRe = regexp.MustCompile(`^\s*([\w.\-]+\.go):(\d+): ?(.*)$`)
+	// "./solution-template.go:12:5: undefined: foo"
+	buildErrorRe = regexp.MustCompile(`^(?:\.[/\\])?([^\s:]+\.go):(\d+):(?:(\d+):)? (.*)$`)
+	// "\t/tmp/challenge-exec123/workspace/solution-template.go:12 +0x1d"
+	stackFrameRe = regexp.MustCompile(`^\s+(\S+\.go):(\d+)(?: \+0x[0-9a-f]+)?$`)
+)
+
+// testReportBuilder assembles a TestReport from go test -json output line by line
+type testReportBuilder struct {
+	report      *models.TestReport
+	tests       map[string]*models.TestCase
+	text        strings.Builder // The equivalent `go test -v` output
+	buildOutput strings.Builder
+	sawJSON     bool
+}
+
+// newTestReportBuilder creates an empty report builder
+func newTestReportBuilder() *testReportBuilder {
+	return &testReportBuilder{
+		report: &models.TestReport{Tests: []*models.TestCase{}},
+		tests:  make(map[string]*models.TestCase),
+	}
+}
+
+// parseTestJSON builds a report and the plain text output from complete go test -json output
+func parseTestJSON(output string) (*models.TestReport, string) {
+	builder := newTestReportBuilder()
+	for _, line := range strings.Split(output, "\n") {
+		builder.AddLine(line)
+	}
+	return builder.Finish(), builder.Text()
+}
+
+// AddLine consumes one line of output and returns the decoded event, or nil for non-JSON lines
+func (b *testReportBuilder) AddLine(line string) *testEvent {
+	if strings.TrimSpace(line) == "" {
+		return nil
+	}
+
+	var event testEvent
+	if !strings.HasPrefix(line, "{") || json.Unmarshal([]byte(line), &event) != nil {
+		// Older toolchains print compiler errors as plain text on stderr
+		b.text.WriteString(line + "\n")
+		b.countRace(line)
+		if b.sawJSON {
+			b.report.ExtraOutput = append(b.report.ExtraOutput, line)
+		} else {
+			b.buildOutput.WriteString(line + "\n")
+		}
+		return nil
+	}
+	b.sawJSON = true
+
+	switch event.Action {
+	case "build-output":
+		b.text.WriteString(event.Output)
+		b.buildOutput.WriteString(event.Output)
+	case "build-fail":
+		b.report.BuildFailed = true
+	case "run":
+		b.startTest(event.Test)
+	case "output":
+		b.text.WriteString(event.Output)
+		b.countRace(event.Output)
+		b.addOutput(event.Test, strings.TrimRight(event.Output, "\n"))
+	case "pass", "fail", "skip":
+		if event.Test == "" {
+			if event.FailedBuild != "" {
+				b.report.BuildFailed = true
+			}
+			break
+		}
+		tc := b.startTest(event.Test)
+		tc.Status = event.Action
+		tc.ElapsedMs = int64(event.Elapsed * 1000)
+		if tc.Status == models.TestStatusFail && tc.Failure == nil {
+			tc.Failure = failureLocation(tc.Output)
+		}
+	}
+
+	return &event
+}
+
+// countRace counts race detector reports
+func (b *testReportBuilder) countRace(line string) {
+	if strings.Contains(line, "WARNING: DATA RACE") {
+		b.report.DataRaces++
+	}
+}
+
+// startTest returns the test case for name, creating it under its parent if needed
+func (b *testReportBuilder) startTest(name string) *models.TestCase {
+	if tc, exists := b.tests[name]; exists {
+		return tc
+	}
+
+	tc := &models.TestCase{Name: name}
+	b.tests[name] = tc
+
+	// Subtest names may contain slashes themselves, so look for the longest known parent
+	parentName := name
+	for {
+		idx := strings.LastIndex(parentName, "/")
+		if idx < 0 {
+			b.report.Tests = append(b.report.Tests, tc)
+			return tc
+		}
+		parentName = parentName[:idx]
+		if parent, exists := b.tests[parentName]; exists {
+			parent.Subtests = append(parent.Subtests, tc)
+			return tc
+		}
+	}
+}
+
+// addOutput attaches an output line to its test, dropping the framing lines
+func (b *testReportBuilder) addOutput(testName, line string) {
+	trimmed := strings.TrimSpace(line)
+	if isTestFramingLine(trimmed) || strings.HasPrefix(trimmed, "exit status ") {
+		return
+	}
+
+	if testName == "" {
+		if trimmed == "PASS" || trimmed == "FAIL" || strings.HasPrefix(trimmed, "ok ") ||
+			strings.HasPrefix(trimmed, "FAIL\t") || strings.HasPrefix(trimmed, "coverage:") {
+			return
+		}
+		b.report.ExtraOutput = append(b.report.ExtraOutput, line)
+		if strings.HasPrefix(trimmed, "panic:") && b.report.PackageError == "" {
+			b.report.PackageError = trimmed
+		}
+		return
+	}
+
+	tc := b.startTest(testName)
+	tc.Output = append(tc.Output, line)
+}
+
+// isTestFramingLine reports lines that go test prints around every test
+func isTestFramingLine(trimmed string) bool {
+	for _, prefix := range []string{"=== RUN", "=== PAUSE", "=== CONT", "=== NAME", "--- PASS", "--- FAIL", "--- SKIP"} {
+		if strings.HasPrefix(trimmed, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// failureLocation finds where a failing test reported its error
+func failureLocation(output []string) *models.SourceLocation {
+	// Prefer t.Error/t.Fatal lines, which carry file:line
+	for _, line := range output {
+		if match := testLogLocationRe.FindStringSubmatch(line); match != nil {
+			lineNo, _ := strconv.Atoi(match[2])
+			return &models.SourceLocation{File: match[1], Line: lineNo, Message: strings.TrimSpace(match[3])}
+		}
+	}
+
+	// Otherwise it was a panic; use the first stack frame outside the standard library
+	panicMessage := ""
+	for _, line := range output {
+		trimmed := strings.TrimSpace(line)
+		if panicMessage == "" && strings.HasPrefix(trimmed, "panic:") {
+			panicMessage = trimmed
+			continue
+		}
+		match := stackFrameRe.FindStringSubmatch(line)
+		if match == nil || panicMessage == "" || isStandardLibraryFrame(match[1]) {
+			continue
+		}
+		lineNo, _ := strconv.Atoi(match[2])
+		return &models.SourceLocation{File: filepath.Base(match[1]), Line: lineNo, Message: panicMessage}
+	}
+	if panicMessage != "" {
+		return &models.SourceLocation{Message: panicMessage}
+	}
+	return nil
+}
+
+// isStandardLibraryFrame reports stack frames that belong to the Go runtime or testing package
+func isStandardLibraryFrame(path string) bool {
+	for _, dir := range []string{"/src/runtime/", "/src/testing/", "/src/reflect/", "/src/sync/", "_testmain.go"} {
+		if strings.Contains(path, dir) {
+			return true
+		}
+	}
+	return false
+}
+
+// Finish finalizes statuses and counts and returns the report
+func (b *testReportBuilder) Finish() *models.TestReport {
+	report := b.report
+	report.BuildOutput = b.buildOutput.String()
+	report.BuildErrors = parseBuildErrors(report.BuildOutput)
+	if len(report.BuildErrors) > 0 && len(b.tests) == 0 {
+		report.BuildFailed = true
+	}
+
+	report.Passed, report.Failed, report.Skipped, report.Total = 0, 0, 0, 0
+	for _, tc := range report.Tests {
+		b.finishTest(tc)
+	}
+	return report
+}
+
+// finishTest marks tests that never reported a result as failed and counts leaves
+func (b *testReportBuilder) finishTest(tc *models.TestCase) {
+	if tc.Status == "" {
+		// The test binary died (panic, timeout, limit) before this test finished
+		tc.Status = models.TestStatusFail
+		if tc.Failure == nil {
+			tc.Failure = failureLocation(append(tc.Output, b.report.ExtraOutput...))
+		}
+	}
+
+	if tc.Status == models.TestStatusFail && tc.Failure == nil {
+		tc.Failure = failureLocation(tc.Output)
+	}
+
+	if len(tc.Subtests) > 0 {
+		for _, sub := range tc.Subtests {
+			b.finishTest(sub)
+		}
+		return
+	}
+
+	b.report.Total++
+	switch tc.Status {
+	case models.TestStatusPass:
+		b.report.Passed++
+	case models.TestStatusSkip:
+		b.report.Skipped++
+	default:
+		b.report.Failed++
+	}
+}
+
+// Text returns the human readable output equivalent to `go test -v`
+func (b *testReportBuilder) Text() string {
+	return b.text.String()
+}
+
+// parseBuildErrors extracts file:line:col diagnostics from compiler output
+func parseBuildErrors(output string) []models.BuildError {
+	var errs []models.BuildError
+	for _, line := range strings.Split(output, "\n") {
+		match := buildErrorRe.FindStringSubmatch(strings.TrimSpace(line))
+		if match == nil {
+			continue
+		}
+		lineNo, _ := strconv.Atoi(match[2])
+		column, _ := strconv.Atoi(match[3])
+		errs = append(errs, models.BuildError{
+			File:    filepath.Base(match[1]),
+			Line:    lineNo,
+			Column:  column,
+			Message: match[4],
+		})
+	}
+	return errs
+}
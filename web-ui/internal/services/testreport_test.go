@@ -0,0 +1,143 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"web-ui/internal/models"
+)
+
+func TestParseTestJSON(t *testing.T) {
+	tests := []struct {
+		name   string
+		output []string
+		check  func(t *testing.T, report *models.TestReport)
+	}{
+		{
+			name: "passing test with subtests",
+			output: []string{
+				`{"Action":"start","Package":"challenge"}`,
+				`{"Action":"run","Package":"challenge","Test":"TestSum"}`,
+				`{"Action":"run","Package":"challenge","Test":"TestSum/positive"}`,
+				`{"Action":"output","Package":"challenge","Test":"TestSum/positive","Output":"=== RUN   TestSum/positive\n"}`,
+				`{"Action":"pass","Package":"challenge","Test":"TestSum/positive","Elapsed":0.01}`,
+				`{"Action":"run","Package":"challenge","Test":"TestSum/zero"}`,
+				`{"Action":"skip","Package":"challenge","Test":"TestSum/zero"}`,
+				`{"Action":"pass","Package":"challenge","Test":"TestSum","Elapsed":0.02}`,
+				`{"Action":"output","Package":"challenge","Output":"PASS\n"}`,
+				`{"Action":"pass","Package":"challenge","Elapsed":0.03}`,
+			},
+			check: func(t *testing.T, report *models.TestReport) {
+				if report.Total != 2 || report.Passed != 1 || report.Skipped != 1 || report.Failed != 0 {
+					t.Errorf("counts = %d total, %d passed, %d skipped, %d failed; want 2, 1, 1, 0", report.Total, report.Passed, report.Skipped, report.Failed)
+				}
+				if len(report.Tests) != 1 || len(report.Tests[0].Subtests) != 2 {
+					t.Fatalf("want TestSum with two subtests, got %+v", report.Tests)
+				}
+				if sub := report.Tests[0].Subtests[0]; sub.Name != "TestSum/positive" || sub.ElapsedMs != 10 || len(sub.Output) != 0 {
+					t.Errorf("first subtest = %+v", sub)
+				}
+				if len(report.ExtraOutput) != 0 {
+					t.Errorf("extra output = %q, want none", report.ExtraOutput)
+				}
+			},
+		},
+		{
+			name: "failing test reports its location",
+			output: []string{
+				`{"Action":"run","Package":"challenge","Test":"TestSum"}`,
+				`{"Action":"output","Package":"challenge","Test":"TestSum","Output":"    solution_test.go:25: expected 5, got 0\n"}`,
+				`{"Action":"fail","Package":"challenge","Test":"TestSum","Elapsed":0}`,
+				`{"Action":"fail","Package":"challenge","Elapsed":0.01}`,
+			},
+			check: func(t *testing.T, report *models.TestReport) {
+				if report.Failed != 1 || report.Total != 1 {
+					t.Errorf("failed %d of %d, want 1 of 1", report.Failed, report.Total)
+				}
+				failure := report.Tests[0].Failure
+				if failure == nil || failure.File != "solution_test.go" || failure.Line != 25 || failure.Message != "expected 5, got 0" {
+					t.Errorf("failure = %+v", failure)
+				}
+			},
+		},
+		{
+			name: "build failure",
+			output: []string{
+				`{"ImportPath":"challenge [challenge.test]","Action":"build-output","Output":"# challenge [challenge.test]\n"}`,
+				`{"ImportPath":"challenge [challenge.test]","Action":"build-output","Output":"./solution-template.go:12:5: undefined: foo\n"}`,
+				`{"ImportPath":"challenge [challenge.test]","Action":"build-fail"}`,
+				`{"Action":"fail","Package":"challenge","Elapsed":0,"FailedBuild":"challenge [challenge.test]"}`,
+			},
+			check: func(t *testing.T, report *models.TestReport) {
+				if !report.BuildFailed {
+					t.Error("build failure not reported")
+				}
+				want := models.BuildError{File: "solution-template.go", Line: 12, Column: 5, Message: "undefined: foo"}
+				if len(report.BuildErrors) != 1 || report.BuildErrors[0] != want {
+					t.Errorf("build errors = %+v, want %+v", report.BuildErrors, want)
+				}
+			},
+		},
+		{
+			name: "plain text compiler output of older toolchains",
+			output: []string{
+				`# challenge [challenge.test]`,
+				`./solution-template.go:3:1: syntax error: non-declaration statement outside function body`,
+				`{"Action":"fail","Package":"challenge","Elapsed":0}`,
+			},
+			check: func(t *testing.T, report *models.TestReport) {
+				if !report.BuildFailed || len(report.BuildErrors) != 1 || report.BuildErrors[0].Line != 3 {
+					t.Errorf("build failed %v with errors %+v, want one error on line 3", report.BuildFailed, report.BuildErrors)
+				}
+			},
+		},
+		{
+			name: "panic ends the test binary",
+			output: []string{
+				`{"Action":"run","Package":"challenge","Test":"TestSum"}`,
+				`{"Action":"output","Package":"challenge","Test":"TestSum","Output":"panic: runtime error: index out of range [3] with length 3\n"}`,
+				`{"Action":"output","Package":"challenge","Test":"TestSum","Output":"\t/usr/local/go/src/testing/testing.go:1690 +0x1d\n"}`,
+				`{"Action":"output","Package":"challenge","Test":"TestSum","Output":"\t/tmp/challenge-exec1/workspace/solution-template.go:9 +0x1d\n"}`,
+				`{"Action":"fail","Package":"challenge","Elapsed":0.01}`,
+			},
+			check: func(t *testing.T, report *models.TestReport) {
+				tc := report.Tests[0]
+				if tc.Status != models.TestStatusFail || report.Failed != 1 {
+					t.Errorf("status %q with %d failed, want fail with 1", tc.Status, report.Failed)
+				}
+				if tc.Failure == nil || tc.Failure.File != "solution-template.go" || tc.Failure.Line != 9 || !strings.HasPrefix(tc.Failure.Message, "panic: runtime error") {
+					t.Errorf("failure = %+v", tc.Failure)
+				}
+			},
+		},
+		{
+			name: "race reports and package output",
+			output: []string{
+				`{"Action":"run","Package":"challenge","Test":"TestCounter"}`,
+				`{"Action":"output","Package":"challenge","Test":"TestCounter","Output":"WARNING: DATA RACE\n"}`,
+				`{"Action":"output","Package":"challenge","Test":"TestCounter","Output":"    testing.go:1490: race detected during execution of test\n"}`,
+				`{"Action":"fail","Package":"challenge","Test":"TestCounter","Elapsed":0}`,
+				`{"Action":"output","Package":"challenge","Output":"panic: init failed\n"}`,
+				`{"Action":"fail","Package":"challenge","Elapsed":0}`,
+			},
+			check: func(t *testing.T, report *models.TestReport) {
+				if report.DataRaces != 1 {
+					t.Errorf("data races = %d, want 1", report.DataRaces)
+				}
+				if report.PackageError != "panic: init failed" {
+					t.Errorf("package error = %q", report.PackageError)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report, text := parseTestJSON(strings.Join(tt.output, "\n") + "\n")
+			tt.check(t, report)
+			if strings.Contains(text, `"Action"`) {
+				t.Errorf("text output contains JSON:\n%s", text)
+			}
+		})
+	}
+}
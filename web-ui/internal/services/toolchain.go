@@ -0,0 +1,225 @@
+package services
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+
+	"web-ui/internal/models"
+)
+
+// "go 1.23.4" and "toolchain go1.25.1" lines of a go.mod
+var (
+	goDirectiveRe        = regexp.MustCompile(`(?m)^go\s+(\S+)`)
+	toolchainDirectiveRe = regexp.MustCompile(`(?m)^toolchain\s+(\S+)`)
+)
+
+// "1.23", "go1.23.4", "go1.24rc1"
+var goVersionRe = regexp.MustCompile(`^(?:go)?(\d+)(?:\.(\d+))?(?:\.(\d+))?((?:rc|beta)\d+)?$`)
+
+// Toolchain is a locally installed Go SDK
+type Toolchain struct {
+	Version string // e.g. "go1.25.1"
+	GoRoot  string
+}
+
+// Toolchains discovers the Go SDKs installed on this machine and picks the one a
+// run uses. Besides the go command on PATH it finds SDKs in GO_TOOLCHAINS_DIR (a
+// list of directories, ~/sdk by default, where golang.org/dl installs them) and
+// toolchains that GOTOOLCHAIN downloaded into the shared module cache.
+type Toolchains struct {
+	defaultToolchain Toolchain
+	available        []Toolchain // Oldest first
+}
+
+// NewToolchains discovers the local toolchains
+func NewToolchains(moduleCacheDir string) *Toolchains {
+	tc := &Toolchains{
+		defaultToolchain: Toolchain{Version: resolveGoEnv("GOVERSION"), GoRoot: resolveGoEnv("GOROOT")},
+	}
+
+	seen := make(map[string]bool)
+	add := func(toolchain Toolchain) {
+		if toolchain.Version == "" || seen[toolchain.Version] || !goVersionRe.MatchString(toolchain.Version) {
+			return
+		}
+		seen[toolchain.Version] = true
+		tc.available = append(tc.available, toolchain)
+	}
+	add(tc.defaultToolchain)
+
+	sdkDirs := filepath.SplitList(os.Getenv("GO_TOOLCHAINS_DIR"))
+	if len(sdkDirs) == 0 {
+		if home, err := os.UserHomeDir(); err == nil {
+			sdkDirs = []string{filepath.Join(home, "sdk")}
+		}
+	}
+	var roots []string
+	for _, dir := range sdkDirs {
+		matches, _ := filepath.Glob(filepath.Join(dir, "go*"))
+		roots = append(roots, matches...)
+	}
+	// GOTOOLCHAIN=go1.25.1 go version, run with the shared GOMODCACHE, provisions these
+	matches, _ := filepath.Glob(filepath.Join(moduleCacheDir, "golang.org", fmt.Sprintf("toolchain@v0.0.1-go*.%s-%s", runtime.GOOS, runtime.GOARCH)))
+	roots = append(roots, matches...)
+
+	for _, root := range roots {
+		if toolchain, ok := toolchainAt(root); ok {
+			add(toolchain)
+		}
+	}
+
+	sort.Slice(tc.available, func(i, j int) bool {
+		return compareGoVersions(tc.available[i].Version, tc.available[j].Version) < 0
+	})
+	return tc
+}
+
+// toolchainAt reads the version of the Go SDK installed at root
+func toolchainAt(root string) (Toolchain, bool) {
+	if _, err := os.Stat(filepath.Join(root, "bin", "go")); err != nil {
+		return Toolchain{}, false
+	}
+	file, err := os.Open(filepath.Join(root, "VERSION"))
+	if err != nil {
+		return Toolchain{}, false
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	if !scanner.Scan() {
+		return Toolchain{}, false
+	}
+	return Toolchain{Version: strings.TrimSpace(scanner.Text()), GoRoot: root}, true
+}
+
+// List returns the installed toolchains, oldest first
+func (tc *Toolchains) List() []models.ToolchainInfo {
+	list := make([]models.ToolchainInfo, 0, len(tc.available))
+	for _, toolchain := range tc.available {
+		list = append(list, models.ToolchainInfo{
+			Version: toolchain.Version,
+			Default: toolchain.Version == tc.defaultToolchain.Version,
+		})
+	}
+	return list
+}
+
+// Select picks the toolchain of a run. A requested version ("1.24" or
+// "go1.24.3") must be installed and satisfy the challenge's minimum. Otherwise
+// the challenge's target is used when installed, then the default toolchain,
+// then the oldest installed one that is new enough.
+func (tc *Toolchains) Select(requirement *models.ToolchainRequirement, requested string) (Toolchain, error) {
+	var minimum, target string
+	if requirement != nil {
+		minimum, target = requirement.Minimum, requirement.Target
+	}
+	satisfies := func(toolchain Toolchain) bool {
+		return minimum == "" || compareGoVersions(toolchain.Version, minimum) >= 0
+	}
+
+	if requested != "" {
+		toolchain, ok := tc.find(requested)
+		if !ok {
+			return Toolchain{}, fmt.Errorf("Go toolchain %s is not installed; available: %s", requested, strings.Join(tc.versions(), ", "))
+		}
+		if !satisfies(toolchain) {
+			return Toolchain{}, fmt.Errorf("this challenge requires go%s or newer, %s was requested", strings.TrimPrefix(minimum, "go"), toolchain.Version)
+		}
+		return toolchain, nil
+	}
+
+	if target != "" {
+		if toolchain, ok := tc.find(target); ok && satisfies(toolchain) {
+			return toolchain, nil
+		}
+	}
+	if tc.defaultToolchain.Version != "" && satisfies(tc.defaultToolchain) {
+		return tc.defaultToolchain, nil
+	}
+	for _, toolchain := range tc.available {
+		if satisfies(toolchain) {
+			return toolchain, nil
+		}
+	}
+	return Toolchain{}, fmt.Errorf("this challenge requires go%s or newer; installed: %s", strings.TrimPrefix(minimum, "go"), strings.Join(tc.versions(), ", "))
+}
+
+// find returns the newest installed toolchain matching version, e.g. "1.24" matches go1.24.3
+func (tc *Toolchains) find(version string) (Toolchain, bool) {
+	version = "go" + strings.TrimPrefix(version, "go")
+	for i := len(tc.available) - 1; i >= 0; i-- {
+		toolchain := tc.available[i]
+		if toolchain.Version == version || strings.HasPrefix(toolchain.Version, version+".") {
+			return toolchain, true
+		}
+	}
+	return Toolchain{}, false
+}
+
+func (tc *Toolchains) versions() []string {
+	versions := make([]string, 0, len(tc.available))
+	for _, toolchain := range tc.available {
+		versions = append(versions, toolchain.Version)
+	}
+	return versions
+}
+
+// ParseToolchainRequirement reads the go and toolchain directives of a go.mod,
+// returning nil when it declares neither
+func ParseToolchainRequirement(goMod string) *models.ToolchainRequirement {
+	requirement := &models.ToolchainRequirement{}
+	if match := goDirectiveRe.FindStringSubmatch(goMod); match != nil {
+		requirement.Minimum = match[1]
+	}
+	if match := toolchainDirectiveRe.FindStringSubmatch(goMod); match != nil && match[1] != "default" {
+		requirement.Target = match[1]
+	}
+	if requirement.Minimum == "" && requirement.Target == "" {
+		return nil
+	}
+	return requirement
+}
+
+// compareGoVersions orders Go versions such as "1.23", "go1.23.4" and "go1.24rc1".
+// Release candidates sort before the release; unparsable versions sort first.
+func compareGoVersions(a, b string) int {
+	partsA, preA := parseGoVersion(a)
+	partsB, preB := parseGoVersion(b)
+	for i := range partsA {
+		if partsA[i] != partsB[i] {
+			if partsA[i] < partsB[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	switch {
+	case preA == preB:
+		return 0
+	case preA == "":
+		return 1
+	case preB == "":
+		return -1
+	}
+	return strings.Compare(preA, preB)
+}
+
+// parseGoVersion splits a Go version into major, minor and patch numbers and a pre-release suffix
+func parseGoVersion(version string) ([3]int, string) {
+	var parts [3]int
+	match := goVersionRe.FindStringSubmatch(version)
+	if match == nil {
+		return parts, ""
+	}
+	for i := range parts {
+		parts[i], _ = strconv.Atoi(match[i+1])
+	}
+	return parts, match[4]
+}
@@ -2,9 +2,6 @@ package services
 
 import (
 	"fmt"
-	"io/ioutil"
-	"os"
-	"path/filepath"
 	"strconv"
 	"strings"
 	"sync"
@@ -14,18 +11,20 @@ import (
 
 // UserService handles user-related operations
 type UserService struct {
+	content      *ContentRoot
 	userAttempts models.UserAttemptsMap
 	mutex        sync.RWMutex
 }
 
-// NewUserService creates a new user service
-func NewUserService() *UserService {
+// NewUserService creates a new user service for the submissions in content
+func NewUserService(content *ContentRoot) *UserService {
 	return &UserService{
+		content:      content,
 		userAttempts: make(models.UserAttemptsMap),
 	}
 }
 
-// LoadUserAttempts checks the filesystem for submission directories
+// LoadUserAttempts checks the content root for submission directories
 func (us *UserService) LoadUserAttempts(username string, challenges models.ChallengeMap) *models.UserAttemptedChallenges {
 	// Check cache with read lock
 	us.mutex.RLock()
@@ -61,24 +60,8 @@ func (us *UserService) LoadUserAttempts(username string, challenges models.Chall
 
 // hasUserSubmission checks if a user has a submission for a challenge
 func (us *UserService) hasUserSubmission(username string, challengeID int) bool {
-	// Try different path formats to handle potential path issues
-	// Absolute path
-	submissionDir := filepath.Join("..", fmt.Sprintf("challenge-%d", challengeID), "submissions", username)
-	submissionFile := filepath.Join(submissionDir, "solution-template.go")
-
-	// Check if the file exists
-	if _, err := os.Stat(submissionFile); err == nil {
-		return true
-	}
-
-	// Alternative path (direct from workspace root)
-	altSubmissionFile := filepath.Join(fmt.Sprintf("challenge-%d", challengeID), "submissions", username, "solution-template.go")
-
-	if _, err := os.Stat(altSubmissionFile); err == nil {
-		return true
-	}
-
-	return false
+	_, err := us.content.Stat(fmt.Sprintf("challenge-%d", challengeID), "submissions", username, "solution-template.go")
+	return err == nil
 }
 
 // GetExistingSolution returns the content of an existing solution file if it exists
@@ -87,22 +70,11 @@ func (us *UserService) GetExistingSolution(username string, challengeID int) str
 		return ""
 	}
 
-	// Try different path formats
-	// First try the relative path from web-ui
-	submissionFile := filepath.Join("..", fmt.Sprintf("challenge-%d", challengeID), "submissions", username, "solution-template.go")
-	content, err := ioutil.ReadFile(submissionFile)
-	if err == nil {
-		return string(content)
-	}
-
-	// Try alternative path from root directory
-	altSubmissionFile := filepath.Join(fmt.Sprintf("challenge-%d", challengeID), "submissions", username, "solution-template.go")
-	content, err = ioutil.ReadFile(altSubmissionFile)
-	if err == nil {
-		return string(content)
+	content, err := us.content.ReadFile(fmt.Sprintf("challenge-%d", challengeID), "submissions", username, "solution-template.go")
+	if err != nil {
+		return ""
 	}
-
-	return ""
+	return string(content)
 }
 
 // RefreshUserAttempts clears the cache for a user and reloads their attempts
@@ -115,6 +87,25 @@ func (us *UserService) RefreshUserAttempts(username string, challenges models.Ch
 	return us.LoadUserAttempts(username, challenges)
 }
 
+// InvalidateAttempts drops the cached attempts of the given users, or of
+// every user when none are given, so they are loaded again when next requested
+func (us *UserService) InvalidateAttempts(usernames ...string) {
+	us.mutex.Lock()
+	defer us.mutex.Unlock()
+	if len(usernames) == 0 {
+		us.userAttempts = make(models.UserAttemptsMap)
+		return
+	}
+	for cached := range us.userAttempts {
+		for _, username := range usernames {
+			// Usernames are case-insensitive, as on GitHub
+			if strings.EqualFold(cached, username) {
+				delete(us.userAttempts, cached)
+			}
+		}
+	}
+}
+
 // GetUserAttempts returns the cached user attempts or loads them if not cached
 func (us *UserService) GetUserAttempts(username string, challenges models.ChallengeMap) *models.UserAttemptedChallenges {
 	us.mutex.RLock()
@@ -129,16 +120,10 @@ func (us *UserService) GetUserAttempts(username string, challenges models.Challe
 // calculateScore calculates the score for a user's submission for a challenge
 func (us *UserService) calculateScore(username string, challengeID int) int {
 	// Read the scoreboard file for this challenge
-	scoreboardPath := filepath.Join("..", fmt.Sprintf("challenge-%d", challengeID), "SCOREBOARD.md")
-	content, err := ioutil.ReadFile(scoreboardPath)
+	content, err := us.content.ReadFile(fmt.Sprintf("challenge-%d", challengeID), "SCOREBOARD.md")
 	if err != nil {
-		// Try alternative path
-		scoreboardPath = filepath.Join(fmt.Sprintf("challenge-%d", challengeID), "SCOREBOARD.md")
-		content, err = ioutil.ReadFile(scoreboardPath)
-		if err != nil {
-			// No scoreboard file, return default score
-			return 50
-		}
+		// No scoreboard file, return default score
+		return 50
 	}
 
 	scoreboardContent := string(content)
@@ -0,0 +1,326 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"web-ui/internal/models"
+)
+
+// challengeFiles are the files every classic and package challenge directory needs
+var challengeFiles = []string{"README.md", MainSolutionFile, "solution-template_test.go", "go.mod", "metadata.json"}
+
+// Classic challenge directories such as "challenge-12"
+var classicChallengeDirRe = regexp.MustCompile(`^challenge-([1-9][0-9]*)$`)
+
+// ValidationIssue is a problem found in the content root
+type ValidationIssue struct {
+	Path    string // Content path of the offending file or directory
+	Message string
+}
+
+// String formats the issue as "path: message"
+func (vi ValidationIssue) String() string {
+	return vi.Path + ": " + vi.Message
+}
+
+// ContentValidator checks that the challenges and packages of a content root
+// are well-formed: their files exist and parse, package learning paths point
+// at challenges, templates compile against their tests without passing them,
+// and reference solutions pass them.
+type ContentValidator struct {
+	content    *ContentRoot
+	challenges *ChallengeService
+	packages   *PackageService
+	execution  *ExecutionService // Builds and runs the tests; nil only checks files
+}
+
+// NewContentValidator creates a validator for content. With a nil execution
+// service templates and reference solutions are not built.
+func NewContentValidator(content *ContentRoot, execution *ExecutionService) *ContentValidator {
+	return &ContentValidator{
+		content:    content,
+		challenges: NewChallengeService(content),
+		packages:   NewPackageService(content),
+		execution:  execution,
+	}
+}
+
+// Targets returns every directory Validate checks: the classic challenges in
+// numerical order, then each package followed by its challenge directories
+func (cv *ContentValidator) Targets() ([]string, error) {
+	var classic []string
+	entries, err := cv.content.ReadDir(".")
+	if err != nil {
+		return nil, fmt.Errorf("could not list %s: %v", cv.content, err)
+	}
+	for _, entry := range entries {
+		if entry.IsDir() && strings.HasPrefix(entry.Name(), "challenge-") {
+			classic = append(classic, entry.Name())
+		}
+	}
+	sort.Slice(classic, func(i, j int) bool {
+		return challengeNumber(classic[i]) < challengeNumber(classic[j])
+	})
+
+	targets := classic
+	packages, err := cv.content.ReadDir(packagesDir)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("could not list %s: %v", packagesDir, err)
+	}
+	for _, pkg := range packages {
+		if !pkg.IsDir() {
+			continue
+		}
+		packageDir := path.Join(packagesDir, pkg.Name())
+		targets = append(targets, packageDir)
+		challenges, _ := cv.content.ReadDir(packageDir)
+		for _, challenge := range challenges {
+			if challenge.IsDir() && strings.HasPrefix(challenge.Name(), "challenge-") {
+				targets = append(targets, path.Join(packageDir, challenge.Name()))
+			}
+		}
+	}
+	return targets, nil
+}
+
+// challengeNumber returns N of "challenge-N", or 0 for other names
+func challengeNumber(dir string) int {
+	n, _ := strconv.Atoi(strings.TrimPrefix(dir, "challenge-"))
+	return n
+}
+
+// Validate checks a directory returned by Targets and returns its problems
+func (cv *ContentValidator) Validate(ctx context.Context, dir string) []ValidationIssue {
+	parts := strings.Split(dir, "/")
+	switch {
+	case len(parts) == 1:
+		return cv.validateClassic(ctx, dir)
+	case len(parts) == 2 && parts[0] == packagesDir:
+		return cv.validatePackage(dir)
+	case len(parts) == 3 && parts[0] == packagesDir:
+		return cv.validatePackageChallenge(ctx, parts[1], parts[2])
+	default:
+		return []ValidationIssue{{dir, "not a challenge or package directory"}}
+	}
+}
+
+// validateClassic checks a classic challenge directory
+func (cv *ContentValidator) validateClassic(ctx context.Context, dir string) []ValidationIssue {
+	match := classicChallengeDirRe.FindStringSubmatch(dir)
+	if match == nil {
+		return []ValidationIssue{{dir, "classic challenge directories are named challenge-N"}}
+	}
+	issues := cv.checkFiles(dir)
+	issues = append(issues, cv.checkMetadata(dir)...)
+	if len(issues) > 0 || cv.execution == nil {
+		return issues
+	}
+
+	id, _ := strconv.Atoi(match[1])
+	challenge, err := cv.challenges.loadSingleChallenge(id, dir)
+	if err != nil {
+		return append(issues, ValidationIssue{dir, err.Error()})
+	}
+	// Templates of performance challenges can be correct and only miss the benchmark thresholds
+	template := map[string]string{MainSolutionFile: challenge.Template}
+	options := models.RunOptions{Bench: challenge.Benchmark != nil}
+	issues = append(issues, checkTemplateRun(dir, cv.execution.RunCode(ctx, template, challenge, options))...)
+
+	referenceDir := path.Join(dir, "reference")
+	if _, err := cv.content.Stat(referenceDir); err != nil {
+		return issues
+	}
+	reference, referenceIssues := cv.loadReference(referenceDir, challenge.Template)
+	issues = append(issues, referenceIssues...)
+	if reference != nil {
+		result := cv.execution.SubmitCode(ctx, reference, challenge, models.RunOptions{})
+		if !result.Passed {
+			issues = append(issues, ValidationIssue{referenceDir, "reference solution fails the tests:\n" + runSummary(result)})
+		}
+	}
+	return issues
+}
+
+// loadReference reads a reference solution, with its solution.go as the main
+// solution file, and checks that differential testing can use it
+func (cv *ContentValidator) loadReference(dir, template string) (map[string]string, []ValidationIssue) {
+	paths, _ := cv.content.Glob(path.Join(dir, "*.go"))
+	files := make(map[string]string, len(paths))
+	for _, file := range paths {
+		content, err := cv.content.ReadFile(file)
+		if err != nil {
+			return nil, []ValidationIssue{{file, err.Error()}}
+		}
+		files[path.Base(file)] = string(content)
+	}
+
+	var issues []ValidationIssue
+	if !HasDifferentialGenerator(files) {
+		issues = append(issues, ValidationIssue{dir, fmt.Sprintf("no %s function in a _test.go file", DifferentialGenerator)})
+	}
+	if len(DifferentialFunctions(template, files)) == 0 {
+		issues = append(issues, ValidationIssue{dir, "implements none of the template functions"})
+	}
+	if _, ok := files["solution.go"]; !ok {
+		return nil, append(issues, ValidationIssue{dir, "missing solution.go"})
+	}
+
+	solution := make(map[string]string)
+	for name, content := range files {
+		switch {
+		case strings.HasSuffix(name, "_test.go"):
+		case name == "solution.go":
+			solution[MainSolutionFile] = content
+		default:
+			solution[name] = content
+		}
+	}
+	return solution, issues
+}
+
+// validatePackage checks a package's package.json and its learning path
+func (cv *ContentValidator) validatePackage(dir string) []ValidationIssue {
+	file := path.Join(dir, "package.json")
+	content, err := cv.content.ReadFile(file)
+	if err != nil {
+		return []ValidationIssue{{file, "missing or unreadable: " + err.Error()}}
+	}
+	var metadata PackageMetadata
+	if err := json.Unmarshal(content, &metadata); err != nil {
+		return []ValidationIssue{{file, "invalid JSON: " + err.Error()}}
+	}
+
+	var issues []ValidationIssue
+	if metadata.Name != path.Base(dir) {
+		issues = append(issues, ValidationIssue{file, fmt.Sprintf("name %q does not match the directory", metadata.Name)})
+	}
+	if len(metadata.LearningPath) == 0 {
+		issues = append(issues, ValidationIssue{file, "learning_path is empty"})
+	}
+	listed := make(map[string]bool)
+	for _, challengeID := range metadata.LearningPath {
+		if listed[challengeID] {
+			issues = append(issues, ValidationIssue{file, fmt.Sprintf("learning_path lists %s twice", challengeID)})
+		}
+		listed[challengeID] = true
+		if info, err := cv.content.Stat(dir, challengeID); err != nil || !info.IsDir() {
+			issues = append(issues, ValidationIssue{file, fmt.Sprintf("learning_path entry %s has no directory", challengeID)})
+		}
+	}
+
+	entries, _ := cv.content.ReadDir(dir)
+	for _, entry := range entries {
+		if entry.IsDir() && strings.HasPrefix(entry.Name(), "challenge-") && !listed[entry.Name()] {
+			issues = append(issues, ValidationIssue{file, fmt.Sprintf("learning_path does not list %s", entry.Name())})
+		}
+	}
+	return issues
+}
+
+// validatePackageChallenge checks a package challenge directory
+func (cv *ContentValidator) validatePackageChallenge(ctx context.Context, packageName, challengeID string) []ValidationIssue {
+	dir := path.Join(packagesDir, packageName, challengeID)
+	var issues []ValidationIssue
+	if !contentNameRe.MatchString(challengeID) {
+		issues = append(issues, ValidationIssue{dir, "package challenge directories are named with lower-case letters, digits and hyphens"})
+	}
+	issues = append(issues, cv.checkFiles(dir)...)
+	issues = append(issues, cv.checkMetadata(dir)...)
+	if len(issues) > 0 || cv.execution == nil {
+		return issues
+	}
+
+	challenge, err := cv.packages.GetPackageChallenge(packageName, challengeID)
+	if err != nil {
+		return append(issues, ValidationIssue{dir, err.Error()})
+	}
+	template := map[string]string{MainSolutionFile: challenge.Template}
+	return append(issues, checkTemplateRun(dir, cv.execution.RunPackageChallenge(ctx, template, challenge, ""))...)
+}
+
+// checkFiles reports the challengeFiles missing from dir
+func (cv *ContentValidator) checkFiles(dir string) []ValidationIssue {
+	var issues []ValidationIssue
+	for _, name := range challengeFiles {
+		info, err := cv.content.Stat(dir, name)
+		switch {
+		case err != nil:
+			issues = append(issues, ValidationIssue{path.Join(dir, name), "missing"})
+		case !info.Mode().IsRegular():
+			issues = append(issues, ValidationIssue{path.Join(dir, name), "not a regular file"})
+		case info.Size() == 0:
+			issues = append(issues, ValidationIssue{path.Join(dir, name), "empty"})
+		}
+	}
+	return issues
+}
+
+// checkMetadata parses the metadata.json of dir, if it has one, and checks its values
+func (cv *ContentValidator) checkMetadata(dir string) []ValidationIssue {
+	file := path.Join(dir, "metadata.json")
+	content, err := cv.content.ReadFile(file)
+	if err != nil {
+		return nil // Reported by checkFiles
+	}
+	var metadata models.ChallengeMetadata
+	if err := json.Unmarshal(content, &metadata); err != nil {
+		return []ValidationIssue{{file, "invalid JSON: " + err.Error()}}
+	}
+
+	var issues []ValidationIssue
+	if strings.TrimSpace(metadata.Title) == "" {
+		issues = append(issues, ValidationIssue{file, "title is empty"})
+	}
+	switch metadata.Difficulty {
+	case "Beginner", "Intermediate", "Advanced":
+	default:
+		issues = append(issues, ValidationIssue{file, fmt.Sprintf("difficulty %q is not Beginner, Intermediate or Advanced", metadata.Difficulty)})
+	}
+	if metadata.Benchmark != nil {
+		for _, threshold := range metadata.Benchmark.Thresholds {
+			if !strings.HasPrefix(threshold.Name, "Benchmark") {
+				issues = append(issues, ValidationIssue{file, fmt.Sprintf("benchmark threshold %q does not name a benchmark", threshold.Name)})
+			}
+		}
+	}
+	return issues
+}
+
+// checkTemplateRun checks the run of a challenge template: the template must
+// compile against the tests, and must not pass them before it is solved
+func checkTemplateRun(dir string, result ExecutionResult) []ValidationIssue {
+	file := path.Join(dir, MainSolutionFile)
+	switch {
+	case result.Report == nil:
+		return []ValidationIssue{{file, "tests could not be run:\n" + runSummary(result)}}
+	case result.Report.BuildFailed:
+		return []ValidationIssue{{file, "does not compile with the tests:\n" + runSummary(result)}}
+	case result.Report.Total == 0:
+		return []ValidationIssue{{file, "the tests contain no test cases"}}
+	case result.Passed:
+		return []ValidationIssue{{file, "passes the tests before it is solved"}}
+	}
+	return nil
+}
+
+// runSummary returns the start of a run's output, indented for the report
+func runSummary(result ExecutionResult) string {
+	output := result.Output
+	if result.Report != nil && result.Report.BuildOutput != "" {
+		output = result.Report.BuildOutput
+	}
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	if len(lines) > 10 {
+		lines = append(lines[:10], fmt.Sprintf("... %d more lines", len(lines)-10))
+	}
+	return "    " + strings.Join(lines, "\n    ")
+}
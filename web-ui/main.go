@@ -2,9 +2,11 @@ package main
 
 import (
 	"bufio"
+	"context"
 	"embed"
-	"fmt"
+	"flag"
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"strings"
@@ -17,16 +19,44 @@ import (
 var content embed.FS
 
 func main() {
+	// Re-exec entry point used by the execution sandbox to apply rlimits
+	services.RunSandboxLauncherIfRequested()
+
+	// Command-line tools
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "check-submission-paths":
+			os.Exit(runCheckSubmissionPaths(os.Args[2:]))
+		case "validate":
+			os.Exit(runValidate(os.Args[2:]))
+		case "new-challenge":
+			os.Exit(runNewChallenge(os.Args[2:]))
+		}
+	}
+
+	contentFlag := flag.String("content", "", "repository directory or .zip snapshot to serve challenges from (default $CONTENT_ROOT, else found from the working directory)")
+	flag.Parse()
+
 	// Load environment variables from .env file
 	loadEnvFile()
 
+	// Find the challenges and packages to serve
+	contentRoot, err := services.ResolveContentRoot(*contentFlag)
+	if err != nil {
+		log.Fatalf("Failed to find content: %v", err)
+	}
+	log.Printf("Serving content from %s", contentRoot)
+
 	// Initialize services
-	challengeService := services.NewChallengeService()
-	scoreboardService := services.NewScoreboardService()
-	userService := services.NewUserService()
-	executionService := services.NewExecutionService()
-	packageService := services.NewPackageService()
+	challengeService := services.NewChallengeService(contentRoot)
+	scoreboardService := services.NewScoreboardService(contentRoot)
+	userService := services.NewUserService(contentRoot)
+	executionService := services.NewExecutionService(contentRoot)
+	packageService := services.NewPackageService(contentRoot)
 	aiService := services.NewAIService()
+	performanceService := services.NewPerformanceService()
+	submissionStore := services.NewFileSubmissionStore()
+	authService := services.NewAuthService()
 
 	// Load data
 	log.Println("Loading challenges...")
@@ -39,11 +69,49 @@ func main() {
 		log.Fatalf("Failed to load scoreboards: %v", err)
 	}
 
+	log.Println("Loading benchmark results...")
+	if err := performanceService.LoadPerformance(); err != nil {
+		log.Printf("Warning: %v", err)
+	}
+
+	log.Println("Loading submission history...")
+	if err := submissionStore.Load(); err != nil {
+		log.Printf("Warning: %v", err)
+	}
+
+	log.Println("Loading sessions...")
+	if err := authService.LoadSessions(); err != nil {
+		log.Fatalf("Failed to configure login: %v", err)
+	}
+	listenAddr := listenAddress(authService.Mode())
+	if authService.Mode() == services.AuthModeDev {
+		if isLoopbackAddress(listenAddr) {
+			log.Println("GitHub login is not configured; trusting the username cookie from localhost only (dev mode)")
+		} else {
+			// A reverse proxy on this machine makes every visitor a localhost client
+			log.Printf("Error: dev mode trusts the username cookie from localhost, but the server listens on %s, "+
+				"where anyone reaching it through a local proxy can act as any user. Set GITHUB_CLIENT_ID and "+
+				"GITHUB_CLIENT_SECRET to sign users in with GitHub, or LISTEN_ADDR=127.0.0.1:8080 to only accept local connections.", listenAddr)
+		}
+	}
+
 	log.Println("Loading packages...")
 	if err := packageService.LoadPackages(); err != nil {
 		log.Fatalf("Failed to load packages: %v", err)
 	}
 
+	// Pre-fill the shared module cache from each challenge's pinned go.mod/go.sum
+	if os.Getenv("EXECUTION_WARM_CACHE") != "false" {
+		go executionService.WarmModuleCache(context.Background(), services.ModuleSourcesFor(challengeService.GetChallenges(), packageService))
+	}
+
+	// Reload challenges, scoreboards and packages when their files change
+	contentReloader := services.NewContentReloader(contentRoot, challengeService, scoreboardService, packageService, userService)
+	if interval := services.ReloadInterval(); interval > 0 && !contentRoot.ReadOnly() {
+		log.Printf("Watching content for changes every %v", interval)
+		go contentReloader.Watch(context.Background(), interval)
+	}
+
 	// Initialize server
 	srv := server.NewServer(
 		content,
@@ -53,15 +121,44 @@ func main() {
 		executionService,
 		packageService,
 		aiService,
+		performanceService,
+		submissionStore,
+		authService,
+		contentRoot,
+		contentReloader,
 	)
 
 	// Setup routes
 	mux := srv.SetupRoutes()
 
 	// Start server
-	port := 8080
-	log.Printf("Server starting on http://localhost:%d", port)
-	log.Fatal(http.ListenAndServe(fmt.Sprintf(":%d", port), mux))
+	log.Printf("Server starting on %s", listenAddr)
+	log.Fatal(http.ListenAndServe(listenAddr, mux))
+}
+
+// listenAddress returns LISTEN_ADDR, or port 8080 on every interface with
+// GitHub login and on loopback only in dev mode
+func listenAddress(authMode string) string {
+	if addr := os.Getenv("LISTEN_ADDR"); addr != "" {
+		return addr
+	}
+	if authMode == services.AuthModeDev {
+		return "127.0.0.1:8080"
+	}
+	return ":8080"
+}
+
+// isLoopbackAddress reports whether a listen address only accepts connections from this machine
+func isLoopbackAddress(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
 }
 
 // loadEnvFile loads environment variables from a .env file
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	"web-ui/internal/services"
+)
+
+// runNewChallenge implements "web-ui new-challenge", which creates a classic
+// challenge, or a package challenge with -package, from the scaffold templates
+// and checks it with the validator. It prints the new directory and returns the
+// exit status: 0 on success, 1 when the challenge could not be created and 2
+// on usage errors.
+func runNewChallenge(args []string) int {
+	flags := flag.NewFlagSet("new-challenge", flag.ContinueOnError)
+	contentFlag := flags.String("content", "", "repository directory to add the challenge to (default $CONTENT_ROOT, else found from the working directory)")
+	title := flags.String("title", "", "title of the challenge (required)")
+	difficulty := flags.String("difficulty", "Beginner", "Beginner, Intermediate or Advanced")
+	packageName := flags.String("package", "", "package to add the challenge to; a classic challenge when empty")
+	slug := flags.String("slug", "", "package challenges: directory name after challenge-N- (default derived from the title)")
+	tags := flags.String("tags", "", "comma-separated tags")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if strings.TrimSpace(*title) == "" || flags.NArg() > 0 {
+		fmt.Fprintln(os.Stderr, "usage: web-ui new-challenge -title TITLE [-difficulty LEVEL] [-package NAME [-slug SLUG]] [-tags a,b]")
+		return 2
+	}
+
+	content, err := services.ResolveContentRoot(*contentFlag)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "new-challenge: %v\n", err)
+		return 2
+	}
+
+	spec := services.NewChallenge{
+		Package:    *packageName,
+		Title:      *title,
+		Difficulty: *difficulty,
+		Slug:       *slug,
+	}
+	for _, tag := range strings.Split(*tags, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			spec.Tags = append(spec.Tags, tag)
+		}
+	}
+	dir, err := services.NewScaffoldService(content).CreateChallenge(spec)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "new-challenge: %v\n", err)
+		return 1
+	}
+	fmt.Printf("Created %s\n", dir)
+
+	// The package is checked too, for its updated learning_path
+	targets := []string{dir}
+	if spec.Package != "" {
+		targets = append(targets, path.Dir(dir))
+	}
+	validator := services.NewContentValidator(content, nil)
+	for _, target := range targets {
+		for _, issue := range validator.Validate(context.Background(), target) {
+			fmt.Printf("Warning: %s\n", issue)
+		}
+	}
+
+	fmt.Printf("Next: fill in the TODOs of README.md, hints.md, learning.md, the template and its tests, then run\n")
+	fmt.Printf("    go run . validate %s\n", dir)
+	return 0
+}
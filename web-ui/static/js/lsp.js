@@ -0,0 +1,211 @@
+// Language intelligence for the challenge editors: completion, hover docs and
+// live diagnostics from a gopls session behind /api/lsp
+
+const LSP_ROOT_URI = 'file:///workspace';
+const LSP_DOCUMENT_URI = LSP_ROOT_URI + '/solution-template.go';
+
+// LSP CompletionItemKind to a short label shown next to each suggestion
+const LSP_COMPLETION_KINDS = {
+    2: 'method', 3: 'func', 4: 'constructor', 5: 'field', 6: 'var', 7: 'class',
+    8: 'interface', 9: 'package', 10: 'property', 13: 'enum', 14: 'keyword',
+    15: 'snippet', 21: 'const', 22: 'struct', 25: 'type'
+};
+
+// LSP DiagnosticSeverity to Ace annotation types
+const LSP_SEVERITIES = { 1: 'error', 2: 'warning', 3: 'info', 4: 'info' };
+
+// Connect an Ace editor to gopls. query selects the challenge, e.g.
+// "challengeId=3" or "package=gin&challenge=challenge-1-basic-routing".
+// Returns the client; it closes itself when the page is left.
+function connectLanguageServer(editor, query) {
+    if (!window.WebSocket) {
+        return null;
+    }
+    const scheme = location.protocol === 'https:' ? 'wss' : 'ws';
+    const socket = new WebSocket(`${scheme}://${location.host}/api/lsp?${query}`);
+    const pending = new Map();
+    let nextId = 1;
+    let version = 1;
+    let ready = false;
+    let changeTimer = null;
+    let changed = false;
+
+    function send(message) {
+        if (socket.readyState === WebSocket.OPEN) {
+            socket.send(JSON.stringify(Object.assign({ jsonrpc: '2.0' }, message)));
+        }
+    }
+
+    function request(method, params) {
+        const id = nextId++;
+        return new Promise((resolve, reject) => {
+            pending.set(id, { resolve, reject });
+            send({ id, method, params });
+        });
+    }
+
+    function notify(method, params) {
+        send({ method, params });
+    }
+
+    // Send the buffer to gopls now instead of waiting for the debounce
+    function flushChanges() {
+        clearTimeout(changeTimer);
+        if (!ready || !changed) {
+            return;
+        }
+        changed = false;
+        notify('textDocument/didChange', {
+            textDocument: { uri: LSP_DOCUMENT_URI, version: ++version },
+            contentChanges: [{ text: editor.getValue() }]
+        });
+    }
+
+    // Answer the requests gopls sends to the client with empty results
+    function answerServerRequest(message) {
+        let result = null;
+        if (message.method === 'workspace/configuration') {
+            result = ((message.params && message.params.items) || []).map(() => null);
+        }
+        send({ id: message.id, result });
+    }
+
+    function showDiagnostics(params) {
+        if (params.uri !== LSP_DOCUMENT_URI) {
+            return;
+        }
+        editor.session.setAnnotations((params.diagnostics || []).map(d => ({
+            row: d.range.start.line,
+            column: d.range.start.character,
+            text: d.source ? `${d.source}: ${d.message}` : d.message,
+            type: LSP_SEVERITIES[d.severity] || 'info'
+        })));
+    }
+
+    socket.addEventListener('open', () => {
+        request('initialize', {
+            processId: null,
+            rootUri: LSP_ROOT_URI,
+            workspaceFolders: [{ uri: LSP_ROOT_URI, name: 'workspace' }],
+            capabilities: {
+                textDocument: {
+                    synchronization: { dynamicRegistration: false },
+                    completion: { completionItem: { snippetSupport: false, documentationFormat: ['markdown', 'plaintext'] } },
+                    hover: { contentFormat: ['markdown', 'plaintext'] },
+                    publishDiagnostics: {}
+                }
+            }
+        }).then(() => {
+            notify('initialized', {});
+            notify('textDocument/didOpen', {
+                textDocument: { uri: LSP_DOCUMENT_URI, languageId: 'go', version, text: editor.getValue() }
+            });
+            ready = true;
+        }).catch(error => console.warn('gopls initialization failed:', error));
+    });
+
+    socket.addEventListener('message', event => {
+        const message = JSON.parse(event.data);
+        if (message.id !== undefined && message.method) {
+            answerServerRequest(message);
+        } else if (message.id !== undefined) {
+            const call = pending.get(message.id);
+            pending.delete(message.id);
+            if (call) {
+                message.error ? call.reject(new Error(message.error.message)) : call.resolve(message.result);
+            }
+        } else if (message.method === 'textDocument/publishDiagnostics') {
+            showDiagnostics(message.params);
+        }
+    });
+
+    socket.addEventListener('close', event => {
+        ready = false;
+        pending.forEach(call => call.reject(new Error('language server disconnected')));
+        pending.clear();
+        if (event.reason) {
+            console.warn('Language server closed:', event.reason);
+        }
+    });
+
+    editor.session.on('change', () => {
+        changed = true;
+        clearTimeout(changeTimer);
+        changeTimer = setTimeout(flushChanges, 300);
+    });
+
+    // Completion through Ace's language tools
+    editor.completers = [{
+        getCompletions(ed, session, pos, prefix, callback) {
+            if (!ready) {
+                callback(null, []);
+                return;
+            }
+            flushChanges();
+            request('textDocument/completion', {
+                textDocument: { uri: LSP_DOCUMENT_URI },
+                position: { line: pos.row, character: pos.column }
+            }).then(result => {
+                const items = Array.isArray(result) ? result : ((result && result.items) || []);
+                callback(null, items.map((item, index) => ({
+                    caption: item.label,
+                    value: item.textEdit ? item.textEdit.newText : (item.insertText || item.label),
+                    meta: item.detail ? item.detail.slice(0, 40) : (LSP_COMPLETION_KINDS[item.kind] || ''),
+                    docText: typeof item.documentation === 'string' ? item.documentation : (item.documentation && item.documentation.value) || '',
+                    score: 1000 - index
+                })));
+            }).catch(() => callback(null, []));
+        }
+    }];
+    editor.setOptions({ enableBasicAutocompletion: true, enableLiveAutocompletion: true });
+
+    // Hover docs in a tooltip that follows the mouse
+    const tooltip = document.createElement('div');
+    tooltip.className = 'lsp-hover card shadow-sm small p-2 d-none';
+    tooltip.style.cssText = 'position: fixed; z-index: 2000; max-width: 480px; max-height: 300px; overflow: auto;';
+    document.body.appendChild(tooltip);
+    let hoverTimer = null;
+    function hideTooltip() {
+        clearTimeout(hoverTimer);
+        tooltip.classList.add('d-none');
+    }
+
+    editor.on('mousemove', event => {
+        hideTooltip();
+        if (!ready) {
+            return;
+        }
+        const pos = event.getDocumentPosition();
+        const { clientX, clientY } = event.domEvent;
+        hoverTimer = setTimeout(() => {
+            flushChanges();
+            request('textDocument/hover', {
+                textDocument: { uri: LSP_DOCUMENT_URI },
+                position: { line: pos.row, character: pos.column }
+            }).then(result => {
+                if (!result || !result.contents) {
+                    return;
+                }
+                const contents = result.contents;
+                const text = typeof contents === 'string' ? contents : (contents.value || '');
+                if (!text.trim()) {
+                    return;
+                }
+                renderMarkdown(text, tooltip);
+                tooltip.style.left = `${clientX + 12}px`;
+                tooltip.style.top = `${clientY + 12}px`;
+                tooltip.classList.remove('d-none');
+            }).catch(() => {});
+        }, 500);
+    });
+    editor.container.addEventListener('mouseleave', hideTooltip);
+    editor.on('change', hideTooltip);
+
+    window.addEventListener('beforeunload', () => socket.close());
+    return {
+        close() {
+            hideTooltip();
+            socket.close();
+        }
+    };
+}
@@ -484,6 +484,401 @@ function formatTestOutput(output) {
         .replace(/--- PASS/g, '<span class="text-success">--- PASS</span>');
 }
 
+// Render the structured go test -json report returned by the run and submit APIs
+function renderTestReport(report) {
+    if (!report) return '';
+
+    let html = '';
+
+    if (report.buildFailed) {
+        html += `<div class="card border-danger mb-3">
+            <div class="card-header bg-danger text-white"><i class="bi bi-hammer me-2"></i>Compilation failed</div>
+            <ul class="list-group list-group-flush">`;
+        (report.buildErrors || []).forEach(err => {
+            const where = err.column ? `${err.file}:${err.line}:${err.column}` : `${err.file}:${err.line}`;
+            html += `<li class="list-group-item"><code>${escapeHtml(where)}</code> ${escapeHtml(err.message)}</li>`;
+        });
+        if (!(report.buildErrors || []).length && report.buildOutput) {
+            html += `<li class="list-group-item"><pre class="mb-0">${escapeHtml(report.buildOutput)}</pre></li>`;
+        }
+        html += '</ul></div>';
+        return html;
+    }
+
+    html += `<div class="d-flex gap-3 mb-2 small">
+        <span class="text-success"><i class="bi bi-check-circle-fill"></i> ${report.passed} passed</span>
+        <span class="text-danger"><i class="bi bi-x-circle-fill"></i> ${report.failed} failed</span>
+        ${report.skipped ? `<span class="text-muted"><i class="bi bi-skip-forward-fill"></i> ${report.skipped} skipped</span>` : ''}
+    </div>`;
+
+    if (report.packageError) {
+        html += `<div class="alert alert-danger py-2 small mb-2"><code>${escapeHtml(report.packageError)}</code></div>`;
+    }
+
+    html += '<ul class="list-group mb-3 test-report">';
+    (report.tests || []).forEach(test => { html += renderTestCase(test, 0); });
+    html += '</ul>';
+    return html;
+}
+
+function renderTestCase(test, depth) {
+    const icons = {
+        pass: '<i class="bi bi-check-circle-fill text-success"></i>',
+        fail: '<i class="bi bi-x-circle-fill text-danger"></i>',
+        skip: '<i class="bi bi-skip-forward-fill text-muted"></i>'
+    };
+    const name = depth > 0 ? test.name.substring(test.name.lastIndexOf('/') + 1) : test.name;
+
+    let html = `<li class="list-group-item py-1" style="padding-left: ${1 + depth * 1.25}rem;">
+        <div class="d-flex justify-content-between align-items-center">
+            <span>${icons[test.status] || ''} <code>${escapeHtml(name)}</code></span>
+            <small class="text-muted">${test.elapsedMs}ms</small>
+        </div>`;
+
+    if (test.status === 'fail' && test.failure) {
+        const where = test.failure.file ? `${test.failure.file}:${test.failure.line}` : '';
+        html += `<div class="small text-danger">${where ? `<code>${escapeHtml(where)}</code> ` : ''}${escapeHtml(test.failure.message || '')}</div>`;
+    }
+    if (test.status === 'fail' && test.output && test.output.length && !(test.subtests || []).length) {
+        html += `<pre class="small bg-light p-2 mb-0 mt-1">${escapeHtml(test.output.join('\n'))}</pre>`;
+    }
+    html += '</li>';
+
+    (test.subtests || []).forEach(sub => { html += renderTestCase(sub, depth + 1); });
+    return html;
+}
+
+// Render the results of the race, vet and coverage checks of a run
+function renderRunChecks(result) {
+    const options = result.options || {};
+    let html = '';
+
+    if (result.goVersion) {
+        html += `<div class="text-muted small mb-2"><i class="bi bi-gear me-1"></i>Tested with ${escapeHtml(result.goVersion)}</div>`;
+    }
+
+    if (options.race) {
+        const races = result.report ? result.report.dataRaces || 0 : 0;
+        html += races
+            ? `<div class="alert alert-danger py-2 small mb-2"><i class="bi bi-shuffle me-1"></i>Race detector found ${races} data race${races === 1 ? '' : 's'}</div>`
+            : `<div class="alert alert-success py-2 small mb-2"><i class="bi bi-shuffle me-1"></i>No data races detected</div>`;
+    }
+
+    if (options.vet && (result.vetIssues || []).length) {
+        html += `<div class="card border-warning mb-3">
+            <div class="card-header"><i class="bi bi-search me-2"></i>go vet</div>
+            <ul class="list-group list-group-flush">`;
+        result.vetIssues.forEach(issue => {
+            const where = issue.file ? `${issue.file}:${issue.line}${issue.column ? ':' + issue.column : ''}` : '';
+            html += `<li class="list-group-item small">${where ? `<code>${escapeHtml(where)}</code> ` : ''}${escapeHtml(issue.message)}</li>`;
+        });
+        html += '</ul></div>';
+    }
+
+    const analysis = result.analysis;
+    if (analysis && ((analysis.diagnostics || []).length || (analysis.skipped || []).length)) {
+        const counts = (analysis.diagnostics || []).reduce((acc, d) => {
+            acc[d.severity] = (acc[d.severity] || 0) + 1;
+            return acc;
+        }, {});
+        const summary = ['error', 'warning', 'info']
+            .filter(severity => counts[severity])
+            .map(severity => `${counts[severity]} ${severity}${counts[severity] === 1 ? '' : 's'}`)
+            .join(', ');
+        html += `<div class="card mb-3 ${counts.error ? 'border-danger' : counts.warning ? 'border-warning' : ''}">
+            <div class="card-header d-flex justify-content-between">
+                <span><i class="bi bi-clipboard-check me-2"></i>Static analysis</span>
+                <span class="text-muted small">${summary || 'No findings'}</span>
+            </div>
+            <ul class="list-group list-group-flush">`;
+        (analysis.diagnostics || []).forEach(d => {
+            const icon = d.severity === 'error' ? 'bi-x-circle text-danger' : d.severity === 'warning' ? 'bi-exclamation-triangle text-warning' : 'bi-info-circle text-info';
+            const where = `${d.file}:${d.line}${d.column ? ':' + d.column : ''}`;
+            html += `<li class="list-group-item small"><i class="bi ${icon} me-2"></i><code>${escapeHtml(where)}</code> <span class="badge bg-light text-dark me-1">${escapeHtml(d.analyzer)}</span>${escapeHtml(d.message)}</li>`;
+        });
+        if ((analysis.skipped || []).length) {
+            html += `<li class="list-group-item small text-muted">Skipped until the code type-checks: ${escapeHtml(analysis.skipped.join(', '))}</li>`;
+        }
+        html += '</ul></div>';
+    }
+
+    if (result.hidden) {
+        const hidden = result.hidden;
+        const allPassed = !hidden.buildFailed && hidden.failed === 0;
+        html += `<div class="card mb-3 ${allPassed ? 'border-success' : 'border-danger'}">
+            <div class="card-header d-flex justify-content-between">
+                <span><i class="bi bi-eye-slash me-2"></i>Hidden tests</span>
+                <span class="${allPassed ? 'text-success' : 'text-danger'}">${hidden.passed}/${hidden.total} passed</span>
+            </div>
+            <ul class="list-group list-group-flush">`;
+        if (hidden.buildFailed) {
+            html += '<li class="list-group-item small text-danger">The hidden tests could not be compiled against your solution. Keep the exported names and signatures of the template.</li>';
+        }
+        (hidden.tests || []).forEach(test => {
+            const icon = test.status === 'pass' ? 'bi-check-circle text-success' : test.status === 'skip' ? 'bi-dash-circle text-muted' : 'bi-x-circle text-danger';
+            html += `<li class="list-group-item small"><i class="bi ${icon} me-2"></i><code>${escapeHtml(test.name)}</code></li>`;
+        });
+        html += '</ul></div>';
+    }
+
+    if (options.fuzz && result.fuzz) {
+        const fuzz = result.fuzz;
+        html += `<div class="card mb-3 ${fuzz.passed ? 'border-success' : 'border-danger'}">
+            <div class="card-header d-flex justify-content-between">
+                <span><i class="bi bi-shuffle me-2"></i>Fuzzing</span>
+                <span class="${fuzz.passed ? 'text-success' : 'text-danger'}">${fuzz.passed ? 'No failing input found' : 'Failing input found'}</span>
+            </div>
+            <ul class="list-group list-group-flush">`;
+        (fuzz.targets || []).forEach(target => {
+            html += `<li class="list-group-item small">
+                <i class="bi ${target.passed ? 'bi-check-circle text-success' : 'bi-x-circle text-danger'} me-2"></i><code>${escapeHtml(target.name)}</code>`;
+            if ((target.inputs || []).length) {
+                html += `<div class="mt-1">Input: <code>${escapeHtml(target.inputs.join(', '))}</code></div>`;
+            }
+            if (target.message) {
+                html += `<pre class="mt-1 mb-0 small">${escapeHtml(target.message)}</pre>`;
+            }
+            if (target.error) {
+                html += `<pre class="mt-1 mb-0 small text-danger">${escapeHtml(target.error)}</pre>`;
+            }
+            html += '</li>';
+        });
+        html += '</ul></div>';
+    }
+
+    if (options.differential && result.differential) {
+        const differential = result.differential;
+        html += `<div class="card mb-3 ${differential.passed ? 'border-success' : 'border-danger'}">
+            <div class="card-header d-flex justify-content-between">
+                <span><i class="bi bi-intersect me-2"></i>Reference comparison</span>
+                <span class="${differential.passed ? 'text-success' : 'text-danger'}">${differential.passed ? `Matched on ${differential.cases} inputs` : 'Results differ'}</span>
+            </div>
+            <ul class="list-group list-group-flush">`;
+        (differential.counterexamples || []).forEach(c => {
+            html += `<li class="list-group-item small">
+                <i class="bi bi-x-circle text-danger me-2"></i><code>${escapeHtml(c.function)}(${escapeHtml(c.inputs.join(', '))})</code>
+                <div class="mt-1">Got: <code>${escapeHtml(c.got)}</code></div>
+                <div>Want: <code>${escapeHtml(c.want)}</code></div>
+            </li>`;
+        });
+        if (differential.error) {
+            html += `<li class="list-group-item small"><pre class="mb-0 small text-danger">${escapeHtml(differential.error)}</pre></li>`;
+        }
+        html += '</ul></div>';
+    }
+
+    if (options.bench && result.benchmarks) {
+        const bench = result.benchmarks;
+        html += `<div class="card mb-3 ${bench.passed ? 'border-success' : 'border-danger'}">
+            <div class="card-header d-flex justify-content-between">
+                <span><i class="bi bi-lightning-charge me-2"></i>Benchmarks</span>
+                <span class="${bench.passed ? 'text-success' : 'text-danger'}">${bench.passed ? 'All thresholds met' : 'Thresholds missed'}</span>
+            </div>
+            <div class="table-responsive"><table class="table table-sm small mb-0">
+                <thead><tr><th>Benchmark</th><th class="text-end">ns/op</th><th class="text-end">B/op</th><th class="text-end">allocs/op</th></tr></thead>
+                <tbody>`;
+        (bench.results || []).forEach(r => {
+            html += `<tr class="${r.graded ? 'fw-bold' : 'text-muted'}">
+                <td><code>${escapeHtml(r.name)}</code>${(r.violations || []).map(v => `<div class="text-danger fw-normal">${escapeHtml(v)}</div>`).join('')}</td>
+                <td class="text-end">${formatNsPerOp(r.nsPerOp)}</td>
+                <td class="text-end">${r.bytesPerOp}</td>
+                <td class="text-end">${r.allocsPerOp}</td>
+            </tr>`;
+        });
+        (bench.baselines || []).forEach(r => {
+            html += `<tr class="text-muted">
+                <td><code>${escapeHtml(r.name)}</code> <span class="badge bg-secondary">reference</span></td>
+                <td class="text-end">${formatNsPerOp(r.nsPerOp)}</td>
+                <td class="text-end">${r.bytesPerOp}</td>
+                <td class="text-end">${r.allocsPerOp}</td>
+            </tr>`;
+        });
+        html += '</tbody></table></div></div>';
+    }
+
+    if (options.cover && result.coverage) {
+        html += `<div class="card mb-3">
+            <div class="card-header d-flex justify-content-between"><span><i class="bi bi-bar-chart me-2"></i>Coverage</span><strong>${result.coverage.total.toFixed(1)}%</strong></div>
+            <ul class="list-group list-group-flush">`;
+        (result.coverage.functions || []).forEach(fn => {
+            html += `<li class="list-group-item py-1 small d-flex justify-content-between">
+                <span><code>${escapeHtml(fn.function)}</code> <span class="text-muted">${escapeHtml(fn.file)}:${fn.line}</span></span>
+                <span>${fn.percent.toFixed(1)}%</span>
+            </li>`;
+        });
+        html += '</ul></div>';
+    }
+    return html;
+}
+
+// Show the static analysis findings of the main solution file as editor annotations
+function annotateEditor(editor, analysis) {
+    if (!editor) {
+        return;
+    }
+    const types = { error: 'error', warning: 'warning', info: 'info' };
+    const annotations = ((analysis && analysis.diagnostics) || [])
+        .filter(d => d.file === 'solution-template.go' && d.line > 0)
+        .map(d => ({
+            row: d.line - 1,
+            column: Math.max((d.column || 1) - 1, 0),
+            text: `${d.analyzer}: ${d.message}`,
+            type: types[d.severity] || 'info'
+        }));
+    editor.session.setAnnotations(annotations);
+}
+
+// Format the editor buffer on the server (gofmt plus import fixes), keeping the
+// cursor in place; syntax errors are shown as annotations instead. Resolves
+// with the /api/format result.
+async function formatEditor(editor) {
+    const response = await fetch('/api/format', {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify({ code: editor.getValue() })
+    });
+    const result = await parseRunResponse(response);
+
+    annotateEditor(editor, { diagnostics: result.errors || [] });
+    if (result.changed) {
+        const cursor = editor.getCursorPosition();
+        editor.setValue(result.code, -1);
+        editor.moveCursorToPosition(cursor);
+        editor.clearSelection();
+    }
+    return result;
+}
+
+// Bind Ctrl/Cmd-Shift-F in an editor to onFormat
+function addFormatShortcut(editor, onFormat) {
+    editor.commands.addCommand({
+        name: 'formatGo',
+        bindKey: { win: 'Ctrl-Shift-F', mac: 'Command-Shift-F' },
+        exec: onFormat
+    });
+}
+
+// Format a benchmark time per operation with a readable unit
+function formatNsPerOp(ns) {
+    if (ns >= 1e9) return (ns / 1e9).toFixed(2) + ' s/op';
+    if (ns >= 1e6) return (ns / 1e6).toFixed(2) + ' ms/op';
+    if (ns >= 1e3) return (ns / 1e3).toFixed(2) + ' µs/op';
+    return ns.toFixed(1) + ' ns/op';
+}
+
+// Parse a test run response; busy/queue-full errors come back as plain text
+function parseRunResponse(response) {
+    if (response.ok) {
+        return response.json();
+    }
+    return response.text().then(text => {
+        throw new Error(text.trim() || `Request failed with status ${response.status}`);
+    });
+}
+
+// Run tests through a Server-Sent Events endpoint, passing progress events to
+// onEvent; resolves with the final result, the same payload as the plain endpoint
+async function streamTestRun(url, body, onEvent) {
+    const response = await fetch(url, {
+        method: 'POST',
+        headers: {
+            'Content-Type': 'application/json',
+            'Accept': 'text/event-stream'
+        },
+        body: JSON.stringify(body)
+    });
+    if (!response.ok) {
+        return parseRunResponse(response);
+    }
+
+    const reader = response.body.getReader();
+    const decoder = new TextDecoder();
+    let buffer = '';
+    while (true) {
+        const { value, done } = await reader.read();
+        if (done) {
+            break;
+        }
+        buffer += decoder.decode(value, { stream: true });
+
+        let boundary;
+        while ((boundary = buffer.indexOf('\n\n')) >= 0) {
+            const block = buffer.slice(0, boundary);
+            buffer = buffer.slice(boundary + 2);
+            const data = block.split('\n')
+                .filter(line => line.startsWith('data:'))
+                .map(line => line.slice(5).trim())
+                .join('\n');
+            if (!data) {
+                continue;
+            }
+
+            const event = JSON.parse(data);
+            if (event.type === 'result') {
+                return event.result;
+            }
+            if (event.type === 'error') {
+                throw new Error(event.message);
+            }
+            if (typeof onEvent === 'function') {
+                onEvent(event);
+            }
+        }
+    }
+    throw new Error('Test run ended before reporting a result');
+}
+
+// Show live progress of a streamed test run in container; returns the event handler
+function renderRunProgress(container) {
+    container.innerHTML = `
+        <div class="d-flex align-items-center mb-2">
+            <div class="spinner-border spinner-border-sm text-primary me-2" role="status">
+                <span class="visually-hidden">Loading...</span>
+            </div>
+            <span class="run-progress-status">Starting...</span>
+            <span class="run-progress-counts ms-auto small text-muted"></span>
+        </div>
+        <pre class="run-progress-log bg-light p-2 small mb-0" style="max-height: 320px; overflow-y: auto;"></pre>
+    `;
+    const statusEl = container.querySelector('.run-progress-status');
+    const countsEl = container.querySelector('.run-progress-counts');
+    const logEl = container.querySelector('.run-progress-log');
+    let passed = 0;
+    let failed = 0;
+
+    const appendLog = text => {
+        logEl.textContent += text + '\n';
+        logEl.scrollTop = logEl.scrollHeight;
+    };
+
+    return event => {
+        switch (event.type) {
+            case 'queued':
+                statusEl.textContent = `Waiting for a free runner (position ${event.position})`;
+                break;
+            case 'status':
+                statusEl.textContent = event.message + '...';
+                break;
+            case 'test-start':
+                statusEl.textContent = `Running ${event.test}...`;
+                break;
+            case 'test-pass':
+                passed++;
+                break;
+            case 'test-fail':
+                failed++;
+                break;
+            case 'compile':
+            case 'output':
+                appendLog(event.output);
+                break;
+        }
+        if (passed || failed) {
+            countsEl.textContent = `${passed} passed, ${failed} failed`;
+        }
+    };
+}
+
 // Handle form submissions with AJAX
 function handleFormSubmit(formElement, successCallback, errorCallback) {
     formElement.addEventListener('submit', function(e) {
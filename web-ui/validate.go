@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"web-ui/internal/services"
+)
+
+// runValidate implements "web-ui validate", which checks that the challenges
+// and packages of the content root are well-formed. It checks the directories
+// given as arguments, relative to the content root, or all of them. It prints
+// a report and returns the exit status: 0 when every directory passes, 1 when
+// any has problems and 2 on usage errors.
+func runValidate(args []string) int {
+	flags := flag.NewFlagSet("validate", flag.ContinueOnError)
+	contentFlag := flags.String("content", "", "repository directory or .zip snapshot to validate (default $CONTENT_ROOT, else found from the working directory)")
+	build := flags.Bool("build", true, "compile the templates and run the reference solutions against their tests")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	content, err := services.ResolveContentRoot(*contentFlag)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "validate: %v\n", err)
+		return 2
+	}
+	var execution *services.ExecutionService
+	if *build {
+		execution = services.NewExecutionService(content)
+	}
+	validator := services.NewContentValidator(content, execution)
+
+	targets := flags.Args()
+	if len(targets) == 0 {
+		if targets, err = validator.Targets(); err != nil {
+			fmt.Fprintf(os.Stderr, "validate: %v\n", err)
+			return 2
+		}
+	}
+
+	fmt.Printf("Validating %s\n", content)
+	failed := 0
+	for _, target := range targets {
+		target = strings.Trim(filepath.ToSlash(filepath.Clean(target)), "/")
+		issues := validator.Validate(context.Background(), target)
+		if len(issues) == 0 {
+			fmt.Printf("ok    %s\n", target)
+			continue
+		}
+		failed++
+		fmt.Printf("FAIL  %s\n", target)
+		for _, issue := range issues {
+			fmt.Printf("      %s\n", strings.ReplaceAll(issue.String(), "\n", "\n      "))
+		}
+	}
+
+	if failed > 0 {
+		fmt.Printf("%d of %d directories have problems\n", failed, len(targets))
+		return 1
+	}
+	fmt.Printf("All %d directories are valid\n", len(targets))
+	return 0
+}